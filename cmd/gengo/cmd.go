@@ -5,7 +5,10 @@ import (
 	"log"
 
 	"github.com/naonao2323/testgen/pkg/cli/dao"
+	"github.com/naonao2323/testgen/pkg/cli/fixture"
 	"github.com/naonao2323/testgen/pkg/cli/gengo"
+	"github.com/naonao2323/testgen/pkg/cli/seed"
+	"github.com/naonao2323/testgen/pkg/cli/testdb"
 	"github.com/spf13/cobra"
 )
 
@@ -27,11 +30,14 @@ func main() {
 		Short:            "Gengo CLI tool",
 		PersistentPreRun: runParent,
 		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Println("Please use a subcommand like 'gengo gen' or 'gengo dao'.")
+			fmt.Println("Please use a subcommand like 'gengo gen', 'gengo dao', 'gengo fixture' or 'gengo seed'.")
 		},
 	}
 	gengoCmd.AddCommand(gengo.NewCommand())
 	gengoCmd.AddCommand(dao.NewCommand())
+	gengoCmd.AddCommand(fixture.NewCommand())
+	gengoCmd.AddCommand(seed.NewCommand())
+	gengoCmd.AddCommand(testdb.NewCommand())
 	if err := Execute(gengoCmd); err != nil {
 		log.Fatal(err)
 	}
@@ -24,13 +24,19 @@ type dao struct {
 	config     config.Config
 	confPath   string
 	outputPath string
+	request    common.Request
 }
 
 func NewCommand() *cobra.Command {
-	d := &dao{}
+	return NewGenerateCommand("dao", "generate dao by cli", common.DaoPostgresRequest)
+}
+
+// NewGenerateCommand returns a command rendering the files of request for every included table.
+func NewGenerateCommand(use string, short string, request common.Request) *cobra.Command {
+	d := &dao{request: request}
 	cmd := cobra.Command{
-		Use:     "dao",
-		Short:   "generate dao by cli",
+		Use:     use,
+		Short:   short,
 		RunE:    d.run,
 		PreRunE: d.setup,
 	}
@@ -86,19 +92,42 @@ func convertWriter(writer config.Writer) output.Writer {
 	}
 }
 
+func convertMode(mode config.Mode) output.Mode {
+	switch mode {
+	case config.Sql:
+		return output.Sql
+	case config.Pgx:
+		return output.Pgx
+	case config.Sqlx:
+		return output.Sqlx
+	case config.Gorm:
+		return output.Gorm
+	default:
+		return output.UnknownMode
+	}
+}
+
 func (d *dao) run(cmd *cobra.Command, args []string) error {
 	writer := d.config.GetWriter()
 	if writer == config.Unknown {
 		return errors.New("unknown writer error")
 	}
+	mode := d.config.GetMode()
+	if mode == config.UnknownMode {
+		return errors.New("unknown mode error")
+	}
 	ctx := context.Background()
-	events := d.optimizer.Optimize(ctx, d.config.GetInclude(), common.DaoPostgresRequest)
+	events := d.optimizer.Optimize(ctx, d.config.GetInclude(), d.request)
 	ctx, cancel := util.WithCondition(ctx, len(events))
 	errors := make(chan error, len(events))
 	template, err := template.NewTemplate(nil)
 	if err != nil {
 		return err
 	}
+	shared := output.NewOutputExecutor(template, d.outputPath, d.extractor, convertWriter(writer), convertMode(mode), d.config.GetVersion(), d.config.GetSoftDelete())
+	if _, err := shared.ExecuteShared(d.request); err != nil {
+		return err
+	}
 	var wg sync.WaitGroup
 	for i := 0; i < d.config.GetParallel(); i++ {
 		wg.Add(1)
@@ -108,7 +137,7 @@ func (d *dao) run(cmd *cobra.Command, args []string) error {
 				cancel,
 				executor.NewTreeExecutor(),
 				table.NewTableExecutor(d.extractor),
-				output.NewOutputExecutor(template, d.outputPath, d.extractor, convertWriter(writer)),
+				output.NewOutputExecutor(template, d.outputPath, d.extractor, convertWriter(writer), convertMode(mode), d.config.GetVersion(), d.config.GetSoftDelete()),
 			)
 			if err := state.Run(ctx, events); err != nil {
 				errors <- err
@@ -0,0 +1,104 @@
+package fixture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/naonao2323/testgen/pkg/config"
+	"github.com/naonao2323/testgen/pkg/extractor"
+	"github.com/naonao2323/testgen/pkg/extractor/postgres"
+	"github.com/naonao2323/testgen/pkg/fixture"
+	"github.com/spf13/cobra"
+)
+
+type capture struct {
+	confPath string
+	table    string
+	where    string
+	output   string
+	name     string
+}
+
+func newCaptureCommand() *cobra.Command {
+	c := &capture{}
+	cmd := cobra.Command{
+		Use:   "capture",
+		Short: "capture rows and the rows they reference into a fixture file or go code, masked by the anonymize rules of the config",
+		RunE:  c.run,
+	}
+	cmd.Flags().StringVar(&c.confPath, "path", c.confPath, "config file path")
+	cmd.Flags().StringVar(&c.table, "table", c.table, "table to start from")
+	cmd.Flags().StringVar(&c.where, "where", c.where, "sql condition selecting the starting rows")
+	cmd.Flags().StringVar(&c.output, "output", c.output, "output file; .yaml, .yml or .json for a fixture file, _test.go for go code beside the generated fixtures")
+	cmd.Flags().StringVar(&c.name, "name", c.name, "suffix of the generated Load function, the table by default")
+	return &cmd
+}
+
+func (c *capture) run(cmd *cobra.Command, args []string) error {
+	if c.confPath == "" {
+		return errors.New("undefined conf path")
+	}
+	if c.table == "" {
+		return errors.New("undefined table")
+	}
+	if c.output == "" {
+		return errors.New("undefined output")
+	}
+	isGo := strings.ToLower(filepath.Ext(c.output)) == ".go"
+	// the code calls the fixtures and helpers of the round-trip tests, which exist only in the tests of the DAO package.
+	if isGo && !strings.HasSuffix(c.output, "_test.go") {
+		return fmt.Errorf("go output %s must end with _test.go to build with the generated fixtures", c.output)
+	}
+	conf, err := config.NewConfig(config.Yaml, c.confPath)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	extractor, err := extractor.Extract(ctx, extractor.Postgres, conf.GetSchema(), conf.GetDbUrl())
+	if err != nil {
+		return err
+	}
+	db, err := postgres.NewDB(conf.GetDbUrl())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	// rules are checked before any row is read.
+	anonymizer := fixture.NewAnonymizer(extractor, conf.GetAnonymize(), conf.GetAnonymizeKey())
+	if err := anonymizer.Validate(); err != nil {
+		return err
+	}
+	capturer := fixture.NewCapturer(extractor, db)
+	set, err := capturer.Capture(ctx, c.table, c.where)
+	if err != nil {
+		return err
+	}
+	set, err = anonymizer.Apply(set)
+	if err != nil {
+		return err
+	}
+	if !isGo {
+		return fixture.WriteFile(c.output, set)
+	}
+	name := c.name
+	if name == "" {
+		name = c.table
+	}
+	source := c.table
+	if c.where != "" {
+		source = fmt.Sprintf("%s WHERE %s", c.table, c.where)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.output), 0o755); err != nil {
+		return err
+	}
+	file, err := os.Create(c.output)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return capturer.WriteGo(file, name, source, set)
+}
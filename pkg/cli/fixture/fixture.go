@@ -0,0 +1,16 @@
+package fixture
+
+import (
+	"github.com/naonao2323/testgen/pkg/cli/dao"
+	"github.com/naonao2323/testgen/pkg/common"
+	"github.com/spf13/cobra"
+)
+
+// NewCommand generates <table>_fixture_test.go next to the DAO files of the same output path;
+// its load subcommand inserts fixture files and capture writes them from a database.
+func NewCommand() *cobra.Command {
+	cmd := dao.NewGenerateCommand("fixture", "generate test fixtures by cli", common.TestFixturePostgresRequest)
+	cmd.AddCommand(newLoadCommand())
+	cmd.AddCommand(newCaptureCommand())
+	return cmd
+}
@@ -0,0 +1,49 @@
+package fixture
+
+import (
+	"context"
+	"errors"
+
+	"github.com/naonao2323/testgen/pkg/config"
+	"github.com/naonao2323/testgen/pkg/extractor"
+	"github.com/naonao2323/testgen/pkg/extractor/postgres"
+	"github.com/naonao2323/testgen/pkg/fixture"
+	"github.com/spf13/cobra"
+)
+
+type load struct {
+	confPath string
+}
+
+func newLoadCommand() *cobra.Command {
+	l := &load{}
+	cmd := cobra.Command{
+		Use:   "load [files...]",
+		Short: "load yaml or json fixture files into the database",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  l.run,
+	}
+	cmd.Flags().StringVar(&l.confPath, "path", l.confPath, "config file path")
+	return &cmd
+}
+
+func (l *load) run(cmd *cobra.Command, args []string) error {
+	if l.confPath == "" {
+		return errors.New("undefined conf path")
+	}
+	conf, err := config.NewConfig(config.Yaml, l.confPath)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	extractor, err := extractor.Extract(ctx, extractor.Postgres, conf.GetSchema(), conf.GetDbUrl())
+	if err != nil {
+		return err
+	}
+	db, err := postgres.NewDB(conf.GetDbUrl())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return fixture.NewLoader(extractor, db).LoadFiles(ctx, args...)
+}
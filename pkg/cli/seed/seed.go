@@ -0,0 +1,87 @@
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/naonao2323/testgen/pkg/config"
+	"github.com/naonao2323/testgen/pkg/extractor"
+	"github.com/naonao2323/testgen/pkg/extractor/postgres"
+	"github.com/naonao2323/testgen/pkg/seed"
+	"github.com/spf13/cobra"
+)
+
+type seedCommand struct {
+	confPath string
+	rows     int
+	batch    int
+	seed     int64
+}
+
+func NewCommand() *cobra.Command {
+	s := &seedCommand{rows: 10, batch: 500, seed: 1}
+	cmd := cobra.Command{
+		Use:   "seed",
+		Short: "populate the included tables with generated rows, sized by the seed section of the config",
+		RunE:  s.run,
+	}
+	cmd.Flags().StringVar(&s.confPath, "path", s.confPath, "config file path")
+	cmd.Flags().IntVar(&s.rows, "rows", s.rows, "rows of the tables the config does not size")
+	cmd.Flags().IntVar(&s.batch, "batch", s.batch, "rows inserted by each statement")
+	cmd.Flags().Int64Var(&s.seed, "seed", s.seed, "seed of the generated values")
+	return &cmd
+}
+
+func (s *seedCommand) run(cmd *cobra.Command, args []string) error {
+	if s.confPath == "" {
+		return errors.New("undefined conf path")
+	}
+	conf, err := config.NewConfig(config.Yaml, s.confPath)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	extractor, err := extractor.Extract(ctx, extractor.Postgres, conf.GetSchema(), conf.GetDbUrl())
+	if err != nil {
+		return err
+	}
+	db, err := postgres.NewDB(conf.GetDbUrl())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	tables := extractor.ListTableNames()
+	if include := conf.GetInclude(); include != nil {
+		tables = *include
+	}
+	sizes := conf.GetSeed()
+	plan := seed.Plan{
+		Rows:     sizes.Rows,
+		Default:  s.rows,
+		Batch:    s.batch,
+		Parallel: conf.GetParallel(),
+		Seed:     s.seed,
+	}
+	if len(sizes.Ratios) > 0 {
+		plan.Ratios = make(map[string]seed.Ratio, len(sizes.Ratios))
+		for table, ratio := range sizes.Ratios {
+			plan.Ratios[table] = seed.Ratio{Per: ratio.Per, Rows: ratio.Rows}
+		}
+	}
+	inserted, err := seed.NewSeeder(extractor, db).Seed(ctx, tables, plan)
+	names := make([]string, 0, len(inserted))
+	for table := range inserted {
+		names = append(names, table)
+	}
+	sort.Strings(names)
+	for _, table := range names {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s: %d rows\n", table, inserted[table])
+	}
+	if err != nil && len(names) > 0 {
+		// every table loads in a transaction of its own.
+		fmt.Fprintln(cmd.OutOrStdout(), "seeding stopped; the rows above stay committed")
+	}
+	return err
+}
@@ -0,0 +1,14 @@
+package testdb
+
+import (
+	"github.com/naonao2323/testgen/pkg/cli/dao"
+	"github.com/naonao2323/testgen/pkg/common"
+	"github.com/spf13/cobra"
+)
+
+// NewCommand generates the testdb package under the output path, which tests import to get
+// a database of their own with the extracted schema applied, and the dbassert package
+// asserting the rows of its tables.
+func NewCommand() *cobra.Command {
+	return dao.NewGenerateCommand("testdb", "generate a test database harness by cli", common.TestContainerPostgresRequest)
+}
@@ -7,6 +7,8 @@ const (
 	Float64
 	String
 	Bool
+	IntArray
+	StringArray
 )
 
 func Convert(goDataType GoDataType) string {
@@ -19,6 +21,10 @@ func Convert(goDataType GoDataType) string {
 		return "string"
 	case Bool:
 		return "bool"
+	case IntArray:
+		return "[]int64"
+	case StringArray:
+		return "[]string"
 	default:
 		return ""
 	}
@@ -0,0 +1,7 @@
+package common
+
+type ForeignKey struct {
+	Column string
+	Table  string
+	IsNull bool
+}
@@ -0,0 +1,83 @@
+package common
+
+import "math"
+
+// Limit narrows the values a column accepts beyond its type.
+type Limit struct {
+	// Length is the maximum number of characters, zero when unbounded.
+	Length int
+	// Precision and Scale bound numeric columns, zero when unbounded.
+	Precision int
+	Scale     int
+	// Enum lists the accepted values of an enum type or an IN check.
+	Enum []string
+	// Min and Max are inclusive bounds taken from simple checks, nil when unbounded.
+	Min *float64
+	Max *float64
+}
+
+// IntRange bounds an integer by its type and limits, preferring small positive values.
+// It reports false when the limits leave no value.
+func (l Limit) IntRange(databaseType string) (int64, int64, bool) {
+	typeMax := int64(math.MaxInt32)
+	switch databaseType {
+	case "smallint":
+		typeMax = math.MaxInt16
+	case "bigint":
+		typeMax = math.MaxInt64
+	}
+	lo, hi := int64(1), int64(100000)
+	if l.Min != nil {
+		lo = int64(math.Ceil(*l.Min))
+		hi = max(hi, lo+100000)
+	}
+	if l.Max != nil {
+		hi = int64(math.Floor(*l.Max))
+		if l.Min == nil && lo > hi {
+			lo = hi - 100000
+		}
+	}
+	hi = min(hi, typeMax)
+	lo = max(lo, -typeMax-1)
+	return lo, hi, lo <= hi
+}
+
+// FloatRange bounds a number by its precision and limits, preferring small positive values.
+// It reports false when the limits leave no value.
+func (l Limit) FloatRange() (float64, float64, bool) {
+	lo, hi := 0.0, 1000.0
+	bound := math.Inf(1)
+	if l.Precision > 0 {
+		bound = math.Pow10(l.Precision-l.Scale) - math.Pow10(-l.Scale)
+		hi = math.Min(hi, bound)
+	}
+	if l.Min != nil {
+		lo = *l.Min
+		hi = math.Max(hi, math.Min(lo+1000, bound))
+	}
+	if l.Max != nil {
+		hi = *l.Max
+		if l.Min == nil && lo > hi {
+			lo = hi - 1000
+		}
+	}
+	hi = math.Min(hi, bound)
+	lo = math.Max(lo, -bound)
+	return lo, hi, lo <= hi
+}
+
+// Steps counts the values of FloatRange in units of 10^-scale, two decimals unless the
+// precision sets the scale: the number first+i has the value (first+i)/10^scale.
+func (l Limit) Steps() (first int64, last int64, scale int, ok bool) {
+	lo, hi, ok := l.FloatRange()
+	if !ok {
+		return 0, 0, 0, false
+	}
+	scale = 2
+	if l.Precision > 0 {
+		scale = l.Scale
+	}
+	unit := math.Pow10(-scale)
+	first, last = int64(math.Ceil(lo/unit)), int64(math.Floor(hi/unit))
+	return first, last, scale, first <= last
+}
@@ -10,14 +10,40 @@ type Config interface {
 	GetParallel() int
 	GetInclude() *[]string
 	GetWriter() Writer
+	GetMode() Mode
+	GetVersion() map[string]string
+	GetSoftDelete() string
+	GetAnonymize() map[string]map[string]string
+	GetAnonymizeKey() string
+	GetSeed() Seed
 }
 
 type config struct {
-	schema   string
-	dbUrl    string
-	parallel int
-	include  *[]string
-	writer   string
+	schema     string
+	dbUrl      string
+	parallel   int
+	include    *[]string
+	writer     string
+	mode       string
+	version    map[string]string
+	softDelete string
+	// anonymize maps a table and column to the masking rule applied to captured values.
+	anonymize    map[string]map[string]string
+	anonymizeKey string
+	seed         Seed
+}
+
+// Seed sizes the tables filled by the seed command.
+type Seed struct {
+	// Rows is the number of rows of each table.
+	Rows map[string]int
+	// Ratios sizes a table by the rows of a parent, as in ten comments per memo.
+	Ratios map[string]Ratio
+}
+
+type Ratio struct {
+	Per  string
+	Rows int
 }
 
 type Writer = int
@@ -28,6 +54,16 @@ const (
 	Unknown
 )
 
+type Mode = int
+
+const (
+	Sql Mode = iota
+	Pgx
+	Sqlx
+	Gorm
+	UnknownMode
+)
+
 type Deploy = int
 
 const (
@@ -53,11 +89,17 @@ func NewConfig(format Format, path string) (Config, error) {
 			return nil, err
 		}
 		conf := config{
-			schema:   yaml.getSchema(),
-			dbUrl:    yaml.getDbUrl(),
-			parallel: yaml.getParallel(),
-			include:  yaml.getInclude(),
-			writer:   yaml.getWriter(),
+			schema:       yaml.getSchema(),
+			dbUrl:        yaml.getDbUrl(),
+			parallel:     yaml.getParallel(),
+			include:      yaml.getInclude(),
+			writer:       yaml.getWriter(),
+			mode:         yaml.getMode(),
+			version:      yaml.getVersion(),
+			softDelete:   yaml.getSoftDelete(),
+			anonymize:    yaml.getAnonymize(),
+			anonymizeKey: yaml.getAnonymizeKey(),
+			seed:         yaml.getSeed(),
 		}
 		return conf, nil
 	default:
@@ -91,3 +133,44 @@ func (c config) GetWriter() Writer {
 		return Unknown
 	}
 }
+
+func (c config) GetMode() Mode {
+	switch c.mode {
+	case "", "sql":
+		return Sql
+	case "pgx":
+		return Pgx
+	case "sqlx":
+		return Sqlx
+	case "gorm":
+		return Gorm
+	default:
+		return UnknownMode
+	}
+}
+
+// GetVersion returns the optimistic locking column of each table.
+func (c config) GetVersion() map[string]string {
+	return c.version
+}
+
+// GetSoftDelete returns the nullable timestamp column marking deleted rows; empty disables soft delete.
+func (c config) GetSoftDelete() string {
+	return c.softDelete
+}
+
+// GetAnonymize returns the masking rule of each captured column by table.
+func (c config) GetAnonymize() map[string]map[string]string {
+	return c.anonymize
+}
+
+// GetAnonymizeKey returns the secret keying every rule but null, so masked values cannot be
+// reversed by hashing guessed values.
+func (c config) GetAnonymizeKey() string {
+	return c.anonymizeKey
+}
+
+// GetSeed returns the row counts and ratios of the tables to seed.
+func (c config) GetSeed() Seed {
+	return c.seed
+}
@@ -8,11 +8,26 @@ import (
 )
 
 type yamlConfig struct {
-	Schema   string    `yaml:"schema"`
-	DbUrl    string    `yaml:"dbUrl"`
-	Parallel *int      `yaml:"parallel"`
-	Include  *[]string `yaml:"include"`
-	Writer   string    `yaml:"writer"`
+	Schema     string            `yaml:"schema"`
+	DbUrl      string            `yaml:"dbUrl"`
+	Parallel   *int              `yaml:"parallel"`
+	Include    *[]string         `yaml:"include"`
+	Writer     string            `yaml:"writer"`
+	Mode       string            `yaml:"mode"`
+	Version    map[string]string `yaml:"version"`
+	SoftDelete *string           `yaml:"softDelete"`
+	// Anonymize maps a table and column to a masking rule such as "email".
+	Anonymize    map[string]map[string]string `yaml:"anonymize"`
+	AnonymizeKey string                       `yaml:"anonymizeKey"`
+	Seed         yamlSeed                     `yaml:"seed"`
+}
+
+type yamlSeed struct {
+	Rows   map[string]int `yaml:"rows"`
+	Ratios map[string]struct {
+		Per  string `yaml:"per"`
+		Rows int    `yaml:"rows"`
+	} `yaml:"ratios"`
 }
 
 func parseYamlConfig(path string) (*yamlConfig, error) {
@@ -50,3 +65,37 @@ func (c yamlConfig) getParallel() int {
 func (c yamlConfig) getWriter() string {
 	return c.Writer
 }
+
+func (c yamlConfig) getMode() string {
+	return c.Mode
+}
+
+func (c yamlConfig) getVersion() map[string]string {
+	return c.Version
+}
+
+func (c yamlConfig) getSoftDelete() string {
+	if c.SoftDelete == nil {
+		return "deleted_at"
+	}
+	return *c.SoftDelete
+}
+
+func (c yamlConfig) getAnonymize() map[string]map[string]string {
+	return c.Anonymize
+}
+
+func (c yamlConfig) getAnonymizeKey() string {
+	return c.AnonymizeKey
+}
+
+func (c yamlConfig) getSeed() Seed {
+	seed := Seed{Rows: c.Seed.Rows}
+	if len(c.Seed.Ratios) > 0 {
+		seed.Ratios = make(map[string]Ratio, len(c.Seed.Ratios))
+		for table, ratio := range c.Seed.Ratios {
+			seed.Ratios[table] = Ratio{Per: ratio.Per, Rows: ratio.Rows}
+		}
+	}
+	return seed
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"sort"
 
 	"github.com/naonao2323/testgen/pkg/common"
@@ -16,6 +17,7 @@ type OutputResult struct{}
 
 type OutputExecutor interface {
 	Execute(request common.Request, table string, columns map[string]common.GoDataType, pk []string) (*OutputResult, error)
+	ExecuteShared(request common.Request) (*OutputResult, error)
 }
 
 type outputExecutor struct {
@@ -23,36 +25,157 @@ type outputExecutor struct {
 	template   *template.Template
 	outputPath string
 	writer     Writer
+	mode       Mode
+	// versions maps a table to its optimistic locking column.
+	versions map[string]string
+	// softDelete is the nullable column that marks a row deleted in any table having it.
+	softDelete string
 }
 
-func NewOutputExecutor(template *template.Template, outputPath string, extractor extractor.Extractor, writer Writer) OutputExecutor {
+func NewOutputExecutor(template *template.Template, outputPath string, extractor extractor.Extractor, writer Writer, mode Mode, versions map[string]string, softDelete string) OutputExecutor {
 	return outputExecutor{
 		template:   template,
 		outputPath: outputPath,
 		extractor:  extractor,
 		writer:     writer,
+		mode:       mode,
+		versions:   versions,
+		softDelete: softDelete,
 	}
 }
 
 func (t outputExecutor) Execute(request common.Request, table string, columns map[string]common.GoDataType, pk []string) (*OutputResult, error) {
-	writer, err := newWriter(t.outputPath, table, t.writer)
-	if err != nil {
-		return nil, err
-	}
 	switch request {
 	case common.DaoPostgresRequest:
-		err := t.template.Execute(template.PostgresDao, writer, t.newData(table, columns, pk))
+		writer, err := newWriter(t.outputPath, table, t.writer)
+		if err != nil {
+			return nil, err
+		}
+		data := t.newData(table, columns, pk)
+		err = t.template.Execute(t.daoTemplate(), writer, data)
 		if err != nil {
 			return &OutputResult{}, err
 		}
+		// the fake, mock and round-trip test build on the sql DAO and need columns for its filter and order types.
+		if t.mode != Sql || len(columns) == 0 {
+			return &OutputResult{}, nil
+		}
+		for _, file := range []struct {
+			suffix       string
+			templateType template.DefaultTemplateType
+		}{
+			{suffix: "_fake", templateType: template.PostgresFake},
+			{suffix: "_mock", templateType: template.PostgresMock},
+			{suffix: "_test", templateType: template.PostgresRoundTrip},
+		} {
+			writer, err := newWriter(t.outputPath, table+file.suffix, t.writer)
+			if err != nil {
+				return nil, err
+			}
+			if err := t.template.Execute(file.templateType, writer, data); err != nil {
+				return &OutputResult{}, err
+			}
+		}
 		return &OutputResult{}, nil
 	case common.FrameworkPostgresRequest:
 	case common.TestContainerPostgresRequest:
 	case common.TestFixturePostgresRequest:
+		// fixtures insert through the sql DAO and reuse the value helpers of its round-trip tests.
+		if t.mode != Sql {
+			return &OutputResult{}, errors.New("fixtures are generated for the sql mode only")
+		}
+		writer, err := newWriter(t.outputPath, table+"_fixture_test", t.writer)
+		if err != nil {
+			return nil, err
+		}
+		if err := t.template.Execute(template.PostgresTestFixture, writer, t.newData(table, columns, pk)); err != nil {
+			return &OutputResult{}, err
+		}
 	}
 	return &OutputResult{}, nil
 }
 
+func (t outputExecutor) ExecuteShared(request common.Request) (*OutputResult, error) {
+	templates := t.sharedTemplates(request)
+	if len(templates) == 0 {
+		return &OutputResult{}, nil
+	}
+	data := t.newSharedData()
+	if request == common.TestContainerPostgresRequest {
+		// the test database hands out a database/sql connection like the sql DAO.
+		if t.mode != Sql {
+			return &OutputResult{}, errors.New("the test database is generated for the sql mode only")
+		}
+		statements, err := schema(t.extractor)
+		if err != nil {
+			return &OutputResult{}, err
+		}
+		data.Schema = statements
+	}
+	for name, templateType := range templates {
+		writer, err := newWriter(t.outputPath, name, t.writer)
+		if err != nil {
+			return nil, err
+		}
+		if err := t.template.Execute(templateType, writer, data); err != nil {
+			return &OutputResult{}, err
+		}
+	}
+	return &OutputResult{}, nil
+}
+
+// sharedTemplates returns the files written once per package, keyed by file name.
+func (t outputExecutor) sharedTemplates(request common.Request) map[string]template.DefaultTemplateType {
+	switch request {
+	case common.DaoPostgresRequest:
+		switch t.mode {
+		case Pgx:
+			return map[string]template.DefaultTemplateType{
+				"dbtx":   template.PostgresPgxDbtx,
+				"errors": template.PostgresPgxErrors,
+			}
+		case Sqlx:
+			// sqlx runs on lib/pq like the sql DAO and shares its errors.
+			return map[string]template.DefaultTemplateType{
+				"dbtx":   template.PostgresSqlxDbtx,
+				"errors": template.PostgresErrors,
+			}
+		case Gorm:
+			return nil
+		default:
+			return map[string]template.DefaultTemplateType{
+				"dbtx":   template.PostgresDbtx,
+				"errors": template.PostgresErrors,
+				"fake":   template.PostgresFakeShared,
+				"mock":   template.PostgresMockShared,
+				// roundtrip_test.go holds the helpers of the generated <table>_test.go files.
+				"roundtrip_test": template.PostgresTestHelpers,
+			}
+		}
+	case common.TestContainerPostgresRequest:
+		// the harness and the assertions on its databases are packages of their own beside the DAO.
+		return map[string]template.DefaultTemplateType{
+			"testdb/testdb":     template.PostgresTestContainer,
+			"dbassert/dbassert": template.PostgresDBAssert,
+		}
+	default:
+		return nil
+	}
+}
+
+func (t outputExecutor) daoTemplate() template.DefaultTemplateType {
+	switch t.mode {
+	case Pgx:
+		return template.PostgresPgxDao
+	case Sqlx:
+		return template.PostgresSqlxDao
+	case Gorm:
+		return template.PostgresGormModel
+	default:
+		return template.PostgresDao
+	}
+}
+
 type Writer int
 
 const (
@@ -61,6 +184,16 @@ const (
 	Unknown Writer = -1
 )
 
+type Mode int
+
+const (
+	Sql Mode = iota
+	Pgx
+	Sqlx
+	Gorm
+	UnknownMode Mode = -1
+)
+
 func newWriter(output string, table string, writer Writer) (io.Writer, error) {
 	switch writer {
 	case File:
@@ -73,10 +206,11 @@ func newWriter(output string, table string, writer Writer) (io.Writer, error) {
 }
 
 func fileWriter(output string, table string) (io.Writer, error) {
-	_, err := os.Stat(output)
+	dir := filepath.Dir(filepath.Join(output, table))
+	_, err := os.Stat(dir)
 	if err != nil {
 		if os.IsNotExist(err) {
-			err = os.MkdirAll(output, 0o755)
+			err = os.MkdirAll(dir, 0o755)
 			if err != nil {
 				return nil, err
 			}
@@ -99,15 +233,6 @@ func columnsKey(columns map[string]common.GoDataType) []string {
 }
 
 func (t outputExecutor) newData(table string, columns map[string]common.GoDataType, pk []string) template.Data {
-	data := make(map[template.Column]template.DataType)
-	for clumn, dataType := range columns {
-		converted := common.Convert(dataType)
-		if converted == "-1" {
-			// TODO: error handling
-			continue
-		}
-		data[clumn] = common.Convert(dataType)
-	}
 	toSet := func(target []string) map[string]struct{} {
 		set := make(map[string]struct{}, len(target))
 		for i := range target {
@@ -116,12 +241,107 @@ func (t outputExecutor) newData(table string, columns map[string]common.GoDataTy
 
 		return set
 	}
+	nullable := toSet(t.extractor.GetNullable(table))
+	data := make(map[template.Column]template.DataType)
+	for clumn, dataType := range columns {
+		converted := common.Convert(dataType)
+		if converted == "" {
+			// TODO: error handling
+			continue
+		}
+		_, isNull := nullable[clumn]
+		data[clumn] = t.convertDataType(dataType, isNull)
+	}
 	reserved := t.extractor.ListReservedWord()
+	foreignKeys := t.extractor.GetForeignKeys(table)
+	references := make([]template.ForeignKey, 0, len(foreignKeys))
+	for i := range foreignKeys {
+		reference := ""
+		if parent := t.extractor.GetPk(foreignKeys[i].Table); len(parent) == 1 {
+			reference = parent[0]
+		}
+		references = append(references, template.ForeignKey{
+			Column:     foreignKeys[i].Column,
+			Table:      foreignKeys[i].Table,
+			IsNull:     foreignKeys[i].IsNull,
+			References: reference,
+		})
+	}
+	uniqueKeys := make([]template.UniqueKey, 0)
+	for name, columns := range t.extractor.GetUniqueKeys(table) {
+		uniqueKeys = append(uniqueKeys, template.UniqueKey{Name: name, Columns: columns})
+	}
+	sort.Slice(uniqueKeys, func(i, j int) bool {
+		return uniqueKeys[i].Name < uniqueKeys[j].Name
+	})
+	version := t.versions[table]
+	if _, ok := data[version]; !ok {
+		version = ""
+	}
+	softDelete := t.softDelete
+	if _, ok := nullable[softDelete]; !ok {
+		softDelete = ""
+	}
 	return template.Data{
-		TableName: table,
-		Pk:        pk,
-		DataTypes: data,
-		Columns:   columnsKey(columns),
-		Reserved:  toSet(reserved),
+		TableName:     table,
+		Pk:            pk,
+		DataTypes:     data,
+		DatabaseTypes: t.extractor.GetDatabaseTypes(table),
+		Limits:        t.extractor.GetLimits(table),
+		Columns:       columnsKey(columns),
+		Reserved:      toSet(reserved),
+		Defaults:      t.extractor.GetDefaults(table),
+		ForeignKeys:   references,
+		UniqueKeys:    uniqueKeys,
+		Constraints:   t.extractor.GetConstraints(table),
+		Version:       version,
+		SoftDelete:    softDelete,
+	}
+}
+
+// newSharedData describes every table of the schema, sorted by name.
+func (t outputExecutor) newSharedData() template.Data {
+	names := t.extractor.ListTableNames()
+	sort.Strings(names)
+	tables := make([]template.Data, 0, len(names))
+	for i := range names {
+		tables = append(tables, t.newData(names[i], t.extractor.GetColumns(names[i]), t.extractor.GetPk(names[i])))
+	}
+	return template.Data{Tables: tables}
+}
+
+// convertDataType maps a column type to the Go type used by the selected mode.
+func (t outputExecutor) convertDataType(dataType common.GoDataType, nullable bool) template.DataType {
+	converted := common.Convert(dataType)
+	switch t.mode {
+	case Pgx:
+		switch dataType {
+		case common.IntArray, common.StringArray:
+			return fmt.Sprintf("pgtype.FlatArray[%s]", converted[len("[]"):])
+		}
+		if !nullable {
+			return converted
+		}
+		switch dataType {
+		case common.Int:
+			return "pgtype.Int8"
+		case common.Float64:
+			return "pgtype.Float8"
+		case common.Bool:
+			return "pgtype.Bool"
+		default:
+			return "pgtype.Text"
+		}
+	default:
+		switch dataType {
+		case common.IntArray:
+			return "pq.Int64Array"
+		case common.StringArray:
+			return "pq.StringArray"
+		}
+		if !nullable {
+			return converted
+		}
+		return "*" + converted
 	}
 }
@@ -0,0 +1,120 @@
+package output
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/naonao2323/testgen/pkg/common"
+	"github.com/naonao2323/testgen/pkg/extractor"
+	"github.com/naonao2323/testgen/pkg/query"
+)
+
+var serialTypes = map[string]string{"integer": "serial", "bigint": "bigserial", "smallint": "smallserial"}
+
+// schema returns the statements recreating the extracted tables: every table with its
+// keys and the checks its limits describe, then the foreign keys to single column
+// primary keys. Enum columns become text checked against their labels, as the name
+// of their type is not extracted.
+func schema(extractor extractor.Extractor) ([]string, error) {
+	words := extractor.ListReservedWord()
+	reserved := make(map[string]struct{}, len(words))
+	for i := range words {
+		reserved[words[i]] = struct{}{}
+	}
+	builder := query.NewBuilder(query.Postgres, reserved)
+	tables := extractor.ListTableNames()
+	sort.Strings(tables)
+	statements := make([]string, 0, len(tables))
+	foreignKeys := make([]string, 0)
+	for _, table := range tables {
+		nullable := make(map[string]struct{})
+		for _, column := range extractor.GetNullable(table) {
+			nullable[column] = struct{}{}
+		}
+		databaseTypes := extractor.GetDatabaseTypes(table)
+		defaults := extractor.GetDefaults(table)
+		limits := extractor.GetLimits(table)
+		stmt := query.CreateTable{Table: table, PrimaryKey: extractor.GetPk(table)}
+		for _, column := range columnsKey(extractor.GetColumns(table)) {
+			_, isNull := nullable[column]
+			def, checks := columnDef(builder, column, databaseTypes[column], defaults[column], limits[column])
+			def.NotNull = !isNull
+			stmt.Columns = append(stmt.Columns, def)
+			stmt.Checks = append(stmt.Checks, checks...)
+		}
+		uniqueKeys := extractor.GetUniqueKeys(table)
+		names := make([]string, 0, len(uniqueKeys))
+		for name := range uniqueKeys {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			stmt.Unique = append(stmt.Unique, uniqueKeys[name])
+		}
+		q, err := builder.Build(stmt)
+		if err != nil {
+			return nil, fmt.Errorf("schema of %s: %w", table, err)
+		}
+		statements = append(statements, q.SQL)
+		for _, fk := range extractor.GetForeignKeys(table) {
+			pk := extractor.GetPk(fk.Table)
+			if len(pk) != 1 {
+				continue
+			}
+			q, err := builder.Build(query.AddForeignKey{Table: table, Columns: []string{fk.Column}, References: fk.Table, RefColumns: pk})
+			if err != nil {
+				return nil, fmt.Errorf("schema of %s: %w", table, err)
+			}
+			foreignKeys = append(foreignKeys, q.SQL)
+		}
+	}
+	return append(statements, foreignKeys...), nil
+}
+
+// columnDef returns the definition of a column and the checks of its limits.
+func columnDef(builder query.Builder, column string, databaseType string, value string, limit common.Limit) (query.ColumnDef, []string) {
+	def := query.ColumnDef{Name: column, Type: databaseType, Default: value}
+	ident := builder.Ident(column)
+	checks := make([]string, 0)
+	switch databaseType {
+	case "integer", "bigint", "smallint":
+		if strings.HasPrefix(value, "nextval(") {
+			def.Type, def.Default = serialTypes[databaseType], ""
+		}
+	case "varchar", "char":
+		if limit.Length > 0 {
+			def.Type = fmt.Sprintf("%s(%d)", databaseType, limit.Length)
+		}
+	case "numeric", "decimal":
+		if limit.Precision > 0 {
+			def.Type = fmt.Sprintf("%s(%d, %d)", databaseType, limit.Precision, limit.Scale)
+		}
+	case "double":
+		def.Type = "double precision"
+	case "enum":
+		def.Type = "text"
+		// the default is cast to the enum type, which is not created.
+		if i := strings.Index(value, "::"); i >= 0 {
+			def.Default = value[:i]
+		}
+	}
+	if limit.Length > 0 && databaseType != "varchar" && databaseType != "char" {
+		checks = append(checks, fmt.Sprintf("char_length(%s) <= %d", ident, limit.Length))
+	}
+	if len(limit.Enum) > 0 {
+		labels := make([]string, 0, len(limit.Enum))
+		for _, label := range limit.Enum {
+			labels = append(labels, "'"+strings.ReplaceAll(label, "'", "''")+"'")
+		}
+		checks = append(checks, fmt.Sprintf("%s IN (%s)", ident, strings.Join(labels, ", ")))
+	}
+	if limit.Min != nil {
+		checks = append(checks, fmt.Sprintf("%s >= %s", ident, strconv.FormatFloat(*limit.Min, 'f', -1, 64)))
+	}
+	if limit.Max != nil {
+		checks = append(checks, fmt.Sprintf("%s <= %s", ident, strconv.FormatFloat(*limit.Max, 'f', -1, 64)))
+	}
+	return def, checks
+}
@@ -0,0 +1,86 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/naonao2323/testgen/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func bound(v float64) *float64 { return &v }
+
+type fakeExtractor struct{}
+
+func (fakeExtractor) GetPk(table string) []string {
+	return map[string][]string{"users": {"id"}, "memos": {"id"}}[table]
+}
+
+func (fakeExtractor) GetNullable(table string) []string {
+	return map[string][]string{"memos": {"parent_id"}}[table]
+}
+
+func (fakeExtractor) GetDefaults(table string) map[string]string {
+	return map[string]map[string]string{
+		"users": {"id": "nextval('users_id_seq'::regclass)", "mood": "'ok'::mood"},
+	}[table]
+}
+
+func (fakeExtractor) GetColumns(table string) map[string]common.GoDataType {
+	return map[string]map[string]common.GoDataType{
+		"users": {"id": common.Int, "name": common.String, "mood": common.String, "price": common.Float64, "order": common.Int},
+		"memos": {"id": common.Int, "user_id": common.Int, "parent_id": common.Int, "body": common.String},
+	}[table]
+}
+
+func (fakeExtractor) GetDatabaseTypes(table string) map[string]string {
+	return map[string]map[string]string{
+		"users": {"id": "integer", "name": "varchar", "mood": "enum", "price": "numeric", "order": "smallint"},
+		"memos": {"id": "bigint", "user_id": "integer", "parent_id": "bigint", "body": "text"},
+	}[table]
+}
+
+func (fakeExtractor) GetForeignKeys(table string) []common.ForeignKey {
+	if table == "memos" {
+		return []common.ForeignKey{{Column: "parent_id", Table: "memos", IsNull: true}, {Column: "user_id", Table: "users"}}
+	}
+	return nil
+}
+
+func (fakeExtractor) GetUniqueKeys(table string) map[string][]string {
+	if table == "users" {
+		return map[string][]string{"users_name_key": {"name"}}
+	}
+	return nil
+}
+
+func (fakeExtractor) GetConstraints(table string) map[string][]string { return nil }
+
+func (fakeExtractor) GetLimits(table string) map[string]common.Limit {
+	return map[string]map[string]common.Limit{
+		"users": {
+			"name":  {Length: 20},
+			"mood":  {Enum: []string{"ok", "it's fine"}},
+			"price": {Precision: 6, Scale: 2, Min: bound(0.01)},
+			"order": {Min: bound(0), Max: bound(10)},
+		},
+		"memos": {"body": {Length: 140}},
+	}[table]
+}
+
+func (fakeExtractor) ListTableNames() []string { return []string{"users", "memos"} }
+
+func (fakeExtractor) ListReservedWord() []string { return []string{"order"} }
+
+func TestSchema(t *testing.T) {
+	t.Parallel()
+	statements, err := schema(fakeExtractor{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"CREATE TABLE memos (body text NOT NULL, id bigint NOT NULL, parent_id bigint, user_id integer NOT NULL, PRIMARY KEY (id), CHECK (char_length(body) <= 140))",
+		`CREATE TABLE users (id serial NOT NULL, mood text NOT NULL DEFAULT 'ok', name varchar(20) NOT NULL, "order" smallint NOT NULL, price numeric(6, 2) NOT NULL, ` +
+			`PRIMARY KEY (id), UNIQUE (name), CHECK (mood IN ('ok', 'it''s fine')), CHECK ("order" >= 0), CHECK ("order" <= 10), CHECK (price >= 0.01))`,
+		"ALTER TABLE memos ADD FOREIGN KEY (parent_id) REFERENCES memos (id)",
+		"ALTER TABLE memos ADD FOREIGN KEY (user_id) REFERENCES users (id)",
+	}, statements)
+}
@@ -2,6 +2,8 @@ package extractor
 
 import (
 	"context"
+	"fmt"
+	"sort"
 
 	"github.com/naonao2323/testgen/pkg/common"
 	"github.com/naonao2323/testgen/pkg/extractor/mysql"
@@ -10,7 +12,14 @@ import (
 
 type Extractor interface {
 	GetPk(table string) []string
+	GetNullable(table string) []string
+	GetDefaults(table string) map[string]string
 	GetColumns(table string) map[string]common.GoDataType
+	GetDatabaseTypes(table string) map[string]string
+	GetForeignKeys(table string) []common.ForeignKey
+	GetUniqueKeys(table string) map[string][]string
+	GetConstraints(table string) map[string][]string
+	GetLimits(table string) map[string]common.Limit
 	ListTableNames() []string
 	ListReservedWord() []string
 }
@@ -30,6 +39,10 @@ func Extract(ctx context.Context, provider Provider, schema string, source strin
 			return nil, err
 		}
 		extract.reserved = postgres.InitReservedWords(ctx, db)
+		extract.tableTree, err = postgres.InitForeignKeyTrees(ctx, db, extract.tables.ListTableNames())
+		if err != nil {
+			return nil, err
+		}
 		return extract, nil
 	default:
 		return nil, nil
@@ -48,6 +61,46 @@ func (e extract[A]) GetPk(table string) []string {
 	return e.tables.GetPk(table)
 }
 
+func (e extract[A]) GetNullable(table string) []string {
+	return e.tables.GetNullable(table)
+}
+
+func (e extract[A]) GetDefaults(table string) map[string]string {
+	return e.tables.GetDefaults(table)
+}
+
+func (e extract[A]) GetUniqueKeys(table string) map[string][]string {
+	return e.tables.GetUniqueKeys(table)
+}
+
+func (e extract[A]) GetConstraints(table string) map[string][]string {
+	return e.tables.GetConstraints(table)
+}
+
+// GetLimits returns the length, precision, enum values and check bounds of each column having one.
+func (e extract[A]) GetLimits(table string) map[string]common.Limit {
+	return e.tables.GetLimits(table)
+}
+
+func (e extract[A]) GetForeignKeys(table string) []common.ForeignKey {
+	if e.tableTree == nil {
+		return nil
+	}
+	referenced := e.tableTree.GetTree(table).Referenced()
+	keys := make([]common.ForeignKey, 0, len(referenced))
+	for k, v := range referenced {
+		keys = append(keys, common.ForeignKey{
+			Column: k.Name(),
+			Table:  v.Table(),
+			IsNull: k.IsNull(),
+		})
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Column < keys[j].Column
+	})
+	return keys
+}
+
 func (e extract[A]) GetColumns(table string) map[string]common.GoDataType {
 	columnTypes, err := e.tables.GetColumnType(table)
 	if err != nil {
@@ -65,14 +118,34 @@ func (e extract[A]) GetColumns(table string) map[string]common.GoDataType {
 	return converted
 }
 
+// GetDatabaseTypes returns the database type name of each column, such as "timestamp" or "uuid".
+func (e extract[A]) GetDatabaseTypes(table string) map[string]string {
+	columnTypes, err := e.tables.GetColumnType(table)
+	if err != nil {
+		return nil
+	}
+	names := make(map[string]string, len(columnTypes))
+	for column, dataType := range columnTypes {
+		if stringer, ok := any(dataType).(fmt.Stringer); ok {
+			names[column] = stringer.String()
+		}
+	}
+	return names
+}
+
 type extract[A postgres.PostgresDataType | mysql.MysqlDataType] struct {
-	tables TablesGetter[A]
-	// tableTree TableTreeGetter
-	reserved ReservedGetter[A]
+	tables    TablesGetter[A]
+	tableTree TableTreeGetter
+	reserved  ReservedGetter[A]
 }
 
 type TablesGetter[A postgres.PostgresDataType | mysql.MysqlDataType] interface {
 	GetPk(table string) []string
+	GetNullable(table string) []string
+	GetDefaults(table string) map[string]string
+	GetUniqueKeys(table string) map[string][]string
+	GetConstraints(table string) map[string][]string
+	GetLimits(table string) map[string]common.Limit
 	GetColumnNames(table string) []string
 	GetColumnType(table string) (map[string]A, error)
 	ListTableNames() []string
@@ -82,7 +155,9 @@ type ReservedGetter[A postgres.PostgresDataType | mysql.MysqlDataType] interface
 	ListReservedWord() []string
 }
 
-type TableTreeGetter interface{}
+type TableTreeGetter interface {
+	GetTree(table string) postgres.FKeyTree
+}
 
 type Provider int
 
@@ -115,12 +190,12 @@ func convertPostgresToGo(postgresType postgres.PostgresDataType) common.GoDataTy
 	case postgres.DATE, postgres.TIME, postgres.TIMESTAMP, postgres.INTERVAL:
 		return common.String
 	case postgres.INTEGERARRAY:
-		return common.Int
+		return common.IntArray
 	case postgres.TEXTARRAY:
-		return common.String
+		return common.StringArray
 	case postgres.JSON, postgres.JSONB:
 		return common.String
-	case postgres.UUID:
+	case postgres.UUID, postgres.ENUM:
 		return common.String
 	}
 	return -1
@@ -12,6 +12,7 @@ import (
 
 type fakeTableGetter[A postgres.PostgresDataType | mysql.MysqlDataType] struct {
 	pk          []string
+	nullable    []string
 	columnNames []string
 	columnType  map[string]A
 	err         error
@@ -21,6 +22,26 @@ func (ft fakeTableGetter[A]) GetPk(table string) []string {
 	return ft.pk
 }
 
+func (ft fakeTableGetter[A]) GetNullable(table string) []string {
+	return ft.nullable
+}
+
+func (ft fakeTableGetter[A]) GetDefaults(table string) map[string]string {
+	return nil
+}
+
+func (ft fakeTableGetter[A]) GetUniqueKeys(table string) map[string][]string {
+	return nil
+}
+
+func (ft fakeTableGetter[A]) GetConstraints(table string) map[string][]string {
+	return nil
+}
+
+func (ft fakeTableGetter[A]) GetLimits(table string) map[string]common.Limit {
+	return nil
+}
+
 func (ft fakeTableGetter[A]) GetColumnNames(table string) []string {
 	return ft.columnNames
 }
@@ -81,6 +102,29 @@ func Test_Exractor_GetColumn(t *testing.T) {
 				"test4": common.Bool,
 			},
 		},
+		{
+			name:  "succeeded in converting array data type",
+			table: "users",
+			extract: func() extract[postgres.PostgresDataType] {
+				return extract[postgres.PostgresDataType]{
+					tables: fakeTableGetter[postgres.PostgresDataType]{
+						pk:          []string{"test"},
+						columnNames: []string{"test", "test2", "test3"},
+						columnType: map[string]postgres.PostgresDataType{
+							"test":  postgres.INTEGER,
+							"test2": postgres.INTEGERARRAY,
+							"test3": postgres.TEXTARRAY,
+						},
+						err: nil,
+					},
+				}
+			},
+			expect: map[string]common.GoDataType{
+				"test":  common.Int,
+				"test2": common.IntArray,
+				"test3": common.StringArray,
+			},
+		},
 	}
 
 	for _, _test := range tests {
@@ -92,3 +136,57 @@ func Test_Exractor_GetColumn(t *testing.T) {
 		})
 	}
 }
+
+func Test_Exractor_GetDatabaseTypes(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		table   string
+		extract func() extract[postgres.PostgresDataType]
+		expect  map[string]string
+	}{
+		{
+			name:  "fail to fetch get column type",
+			table: "users",
+			extract: func() extract[postgres.PostgresDataType] {
+				return extract[postgres.PostgresDataType]{
+					tables: fakeTableGetter[postgres.PostgresDataType]{
+						err: errors.New("fail to fetch column type"),
+					},
+				}
+			},
+			expect: nil,
+		},
+		{
+			name:  "succeeded in naming database types",
+			table: "users",
+			extract: func() extract[postgres.PostgresDataType] {
+				return extract[postgres.PostgresDataType]{
+					tables: fakeTableGetter[postgres.PostgresDataType]{
+						columnType: map[string]postgres.PostgresDataType{
+							"test":  postgres.INTEGER,
+							"test2": postgres.TIMESTAMP,
+							"test3": postgres.UUID,
+							"test4": postgres.TEXTARRAY,
+						},
+					},
+				}
+			},
+			expect: map[string]string{
+				"test":  "integer",
+				"test2": "timestamp",
+				"test3": "uuid",
+				"test4": "text[]",
+			},
+		},
+	}
+
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual := test.extract().GetDatabaseTypes(test.table)
+			assert.Equal(t, test.expect, actual)
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/naonao2323/testgen/pkg/common"
+)
+
+var (
+	checkCast = regexp.MustCompile(`::[a-z]+( [a-z]+)*(\[\])?`)
+	// negative constants are printed quoted, as in "(x > '-1'::integer)".
+	checkBound     = regexp.MustCompile(`^(\w+) (>=|>|<=|<|=) '?(-?[\d.]+)'?$`)
+	checkReversed  = regexp.MustCompile(`^'?(-?[\d.]+)'? (>=|>|<=|<) (\w+)$`)
+	checkIn        = regexp.MustCompile(`^(\w+) = ANY ARRAY\[(.*)\]$`)
+	checkLength    = regexp.MustCompile(`^(?:char_length|length) (\w+) (<=|<) (\d+)$`)
+	checkLiteral   = regexp.MustCompile(`'((?:[^']|'')*)'`)
+	checkHeld      = regexp.MustCompile(`'(\d+)'`)
+	reversedBounds = map[string]string{">=": "<=", ">": "<", "<=": ">=", "<": ">"}
+)
+
+// check is a condition on one column understood from a CHECK constraint.
+type check struct {
+	column   string
+	operator string
+	number   float64
+	values   []string
+	length   int
+}
+
+// parseCheck reads the simple conditions of a constraint definition such as
+// "CHECK (((age >= 0) AND (age <= 150)))". Conditions joined by OR, or comparing
+// expressions, are not simple and are skipped.
+func parseCheck(definition string) []check {
+	body := strings.TrimSpace(strings.TrimPrefix(definition, "CHECK"))
+	body = strings.TrimSuffix(body, " NOT VALID")
+	if strings.Contains(body, " OR ") {
+		return nil
+	}
+	// literals are held aside so their parentheses and words survive the rewriting.
+	literals := make([]string, 0)
+	body = checkLiteral.ReplaceAllStringFunc(body, func(literal string) string {
+		literals = append(literals, literal)
+		return fmt.Sprintf("'%d'", len(literals)-1)
+	})
+	body = checkCast.ReplaceAllString(body, "")
+	body = strings.NewReplacer("(", " ", ")", " ", `"`, "").Replace(body)
+	checks := make([]check, 0)
+	for _, part := range strings.Split(body, " AND ") {
+		part = strings.Join(strings.Fields(part), " ")
+		part = strings.ReplaceAll(part, "[ ", "[")
+		part = strings.ReplaceAll(part, " ]", "]")
+		part = checkHeld.ReplaceAllStringFunc(part, func(held string) string {
+			i, _ := strconv.Atoi(strings.Trim(held, "'"))
+			return literals[i]
+		})
+		if m := checkBound.FindStringSubmatch(part); m != nil {
+			if n, err := strconv.ParseFloat(m[3], 64); err == nil {
+				checks = append(checks, check{column: m[1], operator: m[2], number: n})
+			}
+			continue
+		}
+		if m := checkReversed.FindStringSubmatch(part); m != nil {
+			if n, err := strconv.ParseFloat(m[1], 64); err == nil {
+				checks = append(checks, check{column: m[3], operator: reversedBounds[m[2]], number: n})
+			}
+			continue
+		}
+		if m := checkIn.FindStringSubmatch(part); m != nil {
+			values := make([]string, 0)
+			for _, literal := range checkLiteral.FindAllStringSubmatch(m[2], -1) {
+				values = append(values, strings.ReplaceAll(literal[1], "''", "'"))
+			}
+			checks = append(checks, check{column: m[1], operator: "IN", values: values})
+			continue
+		}
+		if m := checkLength.FindStringSubmatch(part); m != nil {
+			n, _ := strconv.Atoi(m[3])
+			if m[2] == "<" {
+				n--
+			}
+			checks = append(checks, check{column: m[1], operator: "LENGTH", length: n})
+		}
+	}
+	return checks
+}
+
+// GetLimits returns the limits of every column having one, from its type and the
+// simple CHECK constraints of the table.
+func (ts Tables) GetLimits(table string) map[string]common.Limit {
+	limits := make(map[string]common.Limit)
+	columns := make(map[string]column, len(ts[table].columns))
+	for _, c := range ts[table].columns {
+		columns[c.name] = c
+		var limit common.Limit
+		if c.maxLength != nil {
+			limit.Length = *c.maxLength
+		}
+		if c.precision != nil {
+			limit.Precision = *c.precision
+		}
+		if c.scale != nil {
+			limit.Scale = *c.scale
+		}
+		limit.Enum = c.enum
+		if limit.Length != 0 || limit.Precision != 0 || limit.Enum != nil {
+			limits[c.name] = limit
+		}
+	}
+	for _, definition := range ts[table].checks {
+		for _, ch := range parseCheck(definition) {
+			c, ok := columns[ch.column]
+			if !ok {
+				continue
+			}
+			limit := limits[ch.column]
+			// a strict bound moves by the smallest step the column stores.
+			step := 1.0
+			switch c.dataType {
+			case NUMERIC, DECIMAL:
+				step = math.Pow10(-limit.Scale)
+			case REAL, DOUBLE, DOUBLEPRECISION:
+				step = 1e-6
+			}
+			switch ch.operator {
+			case ">=":
+				limit.Min = tighter(limit.Min, ch.number, math.Max)
+			case ">":
+				limit.Min = tighter(limit.Min, ch.number+step, math.Max)
+			case "<=":
+				limit.Max = tighter(limit.Max, ch.number, math.Min)
+			case "<":
+				limit.Max = tighter(limit.Max, ch.number-step, math.Min)
+			case "=":
+				limit.Min = tighter(limit.Min, ch.number, math.Max)
+				limit.Max = tighter(limit.Max, ch.number, math.Min)
+			case "IN":
+				limit.Enum = ch.values
+			case "LENGTH":
+				if limit.Length == 0 || ch.length < limit.Length {
+					limit.Length = ch.length
+				}
+			}
+			limits[ch.column] = limit
+		}
+	}
+	return limits
+}
+
+func tighter(current *float64, bound float64, pick func(a, b float64) float64) *float64 {
+	if current != nil {
+		bound = pick(*current, bound)
+	}
+	return &bound
+}
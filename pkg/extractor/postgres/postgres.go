@@ -40,8 +40,40 @@ const (
 	JSON
 	JSONB
 	UUID
+	// ENUM is any enum type; its labels are read with the column.
+	ENUM
 )
 
+var dataTypeNames = map[PostgresDataType]string{
+	INTEGER:         "integer",
+	BIGINT:          "bigint",
+	SMALLINT:        "smallint",
+	NUMERIC:         "numeric",
+	DECIMAL:         "decimal",
+	REAL:            "real",
+	DOUBLE:          "double",
+	DOUBLEPRECISION: "double precision",
+	TEXT:            "text",
+	VARCHAR:         "varchar",
+	CHAR:            "char",
+	DATE:            "date",
+	TIME:            "time",
+	TIMESTAMP:       "timestamp",
+	INTERVAL:        "interval",
+	BOOLEAN:         "boolean",
+	INTEGERARRAY:    "integer[]",
+	TEXTARRAY:       "text[]",
+	JSON:            "json",
+	JSONB:           "jsonb",
+	UUID:            "uuid",
+	ENUM:            "enum",
+}
+
+// String returns the canonical type name; timestamps with and without time zone share one.
+func (t PostgresDataType) String() string {
+	return dataTypeNames[t]
+}
+
 func convert(dataType string) (PostgresDataType, error) {
 	dataTypeMap := map[string]PostgresDataType{
 		"integer":                     INTEGER,
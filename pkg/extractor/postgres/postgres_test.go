@@ -7,6 +7,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/naonao2323/testgen/pkg/common"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -247,6 +248,9 @@ func TestInitTables(t *testing.T) {
 							isPk:     true,
 						},
 					},
+					constraints: map[string][]string{
+						"users_pkey": {"id"},
+					},
 				},
 				"blogs": table{
 					name: "blogs",
@@ -259,6 +263,9 @@ func TestInitTables(t *testing.T) {
 							isPk:     true,
 						},
 					},
+					constraints: map[string][]string{
+						"blogs_pkey": {"id"},
+					},
 				},
 				"memos": table{
 					name: "memos",
@@ -285,6 +292,11 @@ func TestInitTables(t *testing.T) {
 							isPk:     false,
 						},
 					},
+					constraints: map[string][]string{
+						"memos_pkey":         {"id"},
+						"memos_user_id_fkey": {"user_id"},
+						"memos_blog_id_fkey": {"blog_id"},
+					},
 				},
 				"comments": table{
 					name: "comments",
@@ -304,6 +316,10 @@ func TestInitTables(t *testing.T) {
 							isPk:     false,
 						},
 					},
+					constraints: map[string][]string{
+						"comments_pkey":         {"id"},
+						"comments_memo_id_fkey": {"memo_id"},
+					},
 				},
 				"goods": table{
 					name: "goods",
@@ -323,6 +339,10 @@ func TestInitTables(t *testing.T) {
 							isPk:     false,
 						},
 					},
+					constraints: map[string][]string{
+						"goods_pkey":         {"id"},
+						"goods_user_id_fkey": {"user_id"},
+					},
 				},
 			},
 		},
@@ -418,3 +438,332 @@ func TestGetPk(t *testing.T) {
 		})
 	}
 }
+
+func TestGetNullable(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		table  string
+		tables Tables
+		expect []string
+	}{
+		{
+			name:  "there is no nullable column",
+			table: "users",
+			tables: Tables{
+				"users": table{
+					name: "users",
+					columns: []column{
+						{
+							name:   "id",
+							isNull: "NO",
+						},
+					},
+				},
+			},
+			expect: []string{},
+		},
+		{
+			name:  "there are nullable columns",
+			table: "memos",
+			tables: Tables{
+				"memos": table{
+					name: "memos",
+					columns: []column{
+						{
+							name:   "id",
+							isNull: "NO",
+						},
+						{
+							name:   "user_id",
+							isNull: "YES",
+						},
+						{
+							name:   "blog_id",
+							isNull: "YES",
+						},
+					},
+				},
+			},
+			expect: []string{"user_id", "blog_id"},
+		},
+	}
+
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual := test.tables.GetNullable(test.table)
+			assert.Equal(t, test.expect, actual)
+		})
+	}
+}
+
+func TestGetDefaults(t *testing.T) {
+	t.Parallel()
+	value := "nextval('users_id_seq'::regclass)"
+	tests := []struct {
+		name   string
+		table  string
+		tables Tables
+		expect map[string]string
+	}{
+		{
+			name:  "there is no default",
+			table: "users",
+			tables: Tables{
+				"users": table{
+					name: "users",
+					columns: []column{
+						{
+							name: "id",
+						},
+					},
+				},
+			},
+			expect: map[string]string{},
+		},
+		{
+			name:  "there is default",
+			table: "users",
+			tables: Tables{
+				"users": table{
+					name: "users",
+					columns: []column{
+						{
+							name:          "id",
+							columnDefault: &value,
+						},
+						{
+							name: "name",
+						},
+					},
+				},
+			},
+			expect: map[string]string{"id": value},
+		},
+	}
+
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual := test.tables.GetDefaults(test.table)
+			assert.Equal(t, test.expect, actual)
+		})
+	}
+}
+
+func TestInitForeignKeyTrees(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	db, err := NewDB(URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trees, err := InitForeignKeyTrees(ctx, db, []string{"users", "comments"})
+	require.NoError(t, err)
+	expected := Trees{
+		"users": FKeyTree{
+			table: "users",
+		},
+		"comments": FKeyTree{
+			table: "comments",
+			referenced: map[FKey]FKeyTree{
+				{
+					name:   "memo_id",
+					isNull: true,
+				}: {
+					table: "memos",
+				},
+			},
+		},
+	}
+	assert.Equal(t, expected, trees)
+}
+
+func TestGetUniqueKeys(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		table  string
+		tables Tables
+		expect map[string][]string
+	}{
+		{
+			name:  "there is no unique key",
+			table: "users",
+			tables: Tables{
+				"users": table{
+					name: "users",
+				},
+			},
+			expect: nil,
+		},
+		{
+			name:  "there are unique keys",
+			table: "users",
+			tables: Tables{
+				"users": table{
+					name: "users",
+					uniqueKeys: map[string][]string{
+						"users_email_key":       {"email"},
+						"users_org_id_name_key": {"org_id", "name"},
+					},
+				},
+			},
+			expect: map[string][]string{
+				"users_email_key":       {"email"},
+				"users_org_id_name_key": {"org_id", "name"},
+			},
+		},
+	}
+
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual := test.tables.GetUniqueKeys(test.table)
+			assert.Equal(t, test.expect, actual)
+		})
+	}
+}
+
+func TestGetConstraints(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		table  string
+		tables Tables
+		expect map[string][]string
+	}{
+		{
+			name:  "there is no constraint",
+			table: "users",
+			tables: Tables{
+				"users": table{
+					name: "users",
+				},
+			},
+			expect: nil,
+		},
+		{
+			name:  "there are constraints",
+			table: "members",
+			tables: Tables{
+				"members": table{
+					name: "members",
+					uniqueKeys: map[string][]string{
+						"members_email_key": {"email"},
+					},
+					constraints: map[string][]string{
+						"members_pkey":         {"group_id", "user_id"},
+						"members_email_key":    {"email"},
+						"members_user_id_fkey": {"user_id"},
+					},
+				},
+			},
+			expect: map[string][]string{
+				"members_pkey":         {"group_id", "user_id"},
+				"members_email_key":    {"email"},
+				"members_user_id_fkey": {"user_id"},
+			},
+		},
+	}
+
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual := test.tables.GetConstraints(test.table)
+			assert.Equal(t, test.expect, actual)
+		})
+	}
+}
+
+func TestGetLimits(t *testing.T) {
+	t.Parallel()
+	ptr := func(v int) *int { return &v }
+	bound := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name   string
+		table  string
+		tables Tables
+		expect map[string]common.Limit
+	}{
+		{
+			name:  "there is no limit",
+			table: "users",
+			tables: Tables{
+				"users": table{
+					name:    "users",
+					columns: []column{{name: "id", dataType: INTEGER}},
+				},
+			},
+			expect: map[string]common.Limit{},
+		},
+		{
+			name:  "limits come from the column types",
+			table: "items",
+			tables: Tables{
+				"items": table{
+					name: "items",
+					columns: []column{
+						{name: "code", dataType: VARCHAR, maxLength: ptr(8)},
+						{name: "price", dataType: NUMERIC, precision: ptr(6), scale: ptr(2)},
+						{name: "mood", dataType: ENUM, enum: []string{"sad", "ok", "happy"}},
+					},
+				},
+			},
+			expect: map[string]common.Limit{
+				"code":  {Length: 8},
+				"price": {Precision: 6, Scale: 2},
+				"mood":  {Enum: []string{"sad", "ok", "happy"}},
+			},
+		},
+		{
+			name:  "limits come from simple checks",
+			table: "items",
+			tables: Tables{
+				"items": table{
+					name: "items",
+					columns: []column{
+						{name: "age", dataType: INTEGER},
+						{name: "price", dataType: NUMERIC, precision: ptr(6), scale: ptr(2)},
+						{name: "delta", dataType: INTEGER},
+						{name: "status", dataType: VARCHAR, maxLength: ptr(20)},
+						{name: "name", dataType: TEXT},
+						{name: "order", dataType: INTEGER},
+					},
+					checks: []string{
+						"CHECK (((age >= 0) AND (age <= 150)))",
+						"CHECK ((price > (0)::numeric))",
+						"CHECK ((delta > '-5'::integer))",
+						"CHECK (((status)::text = ANY ((ARRAY['new'::character varying, 'done (ok)'::character varying, 'it''s AND'::character varying])::text[])))",
+						"CHECK ((char_length(name) < 11))",
+						`CHECK ((10 > "order"))`,
+						"CHECK (((age > 200) OR (age < 0)))",
+						"CHECK ((upper(name) = name))",
+					},
+				},
+			},
+			expect: map[string]common.Limit{
+				"age":    {Min: bound(0), Max: bound(150)},
+				"price":  {Precision: 6, Scale: 2, Min: bound(0.01)},
+				"delta":  {Min: bound(-4)},
+				"status": {Length: 20, Enum: []string{"new", "done (ok)", "it's AND"}},
+				"name":   {Length: 10},
+				"order":  {Max: bound(9)},
+			},
+		},
+	}
+
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual := test.tables.GetLimits(test.table)
+			assert.Equal(t, test.expect, actual)
+		})
+	}
+}
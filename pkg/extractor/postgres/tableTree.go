@@ -17,10 +17,54 @@ type FKeyTree struct {
 	referenced map[FKey]FKeyTree
 }
 
+func (k FKey) Name() string {
+	return k.name
+}
+
+func (k FKey) IsNull() bool {
+	return k.isNull
+}
+
+func (t FKeyTree) Table() string {
+	return t.table
+}
+
+func (t FKeyTree) Referenced() map[FKey]FKeyTree {
+	return t.referenced
+}
+
 func InitForeignKeyTree(ctx context.Context, db *sql.DB, entrypointTable string) (FKeyTree, error) {
 	return initForeignKeyTree(ctx, db, entrypointTable)
 }
 
+type Trees map[tableName]FKeyTree
+
+// InitForeignKeyTrees fetches only the direct references of each table so that
+// cyclic and self references terminate; deeper levels are found by looking up
+// the referenced table.
+func InitForeignKeyTrees(ctx context.Context, db *sql.DB, tables []string) (Trees, error) {
+	trees := make(Trees, len(tables))
+	for i := range tables {
+		constraints, err := getForeignConstraints(ctx, db, tables[i])
+		if err != nil {
+			return nil, err
+		}
+		refer, err := getReferenced(ctx, db, constraints)
+		if err != nil {
+			return nil, err
+		}
+		trees[tables[i]] = FKeyTree{
+			table:      tables[i],
+			referenced: refer,
+		}
+	}
+	return trees, nil
+}
+
+func (ts Trees) GetTree(table string) FKeyTree {
+	return ts[table]
+}
+
 func initForeignKeyTree(ctx context.Context, db *sql.DB, entrypointTable string) (FKeyTree, error) {
 	var tree FKeyTree
 	tree.table = entrypointTable
@@ -65,7 +109,7 @@ func getReferenced(ctx context.Context, db *sql.DB, constraints []string) (map[F
 	}
 	query := fmt.Sprintf(
 		`
-		SELECT src_col.attname AS source_column, tgt_table.relname AS target_table
+		SELECT src_col.attname AS source_column, src_table.relname AS source_table, tgt_table.relname AS target_table
 		FROM pg_constraint con
 		JOIN pg_class src_table ON con.conrelid = src_table.oid
 		JOIN pg_class tgt_table ON con.confrelid = tgt_table.oid
@@ -86,24 +130,27 @@ func getReferenced(ctx context.Context, db *sql.DB, constraints []string) (map[F
 	}()
 	type pair struct {
 		sourceColumn string
+		sourceTable  string
 		targetTable  string
 	}
 	pairs := make([]pair, 0, len(constraints))
 	for result.Next() {
 		pair := new(pair)
-		if err := result.Scan(&pair.sourceColumn, &pair.targetTable); err != nil {
+		if err := result.Scan(&pair.sourceColumn, &pair.sourceTable, &pair.targetTable); err != nil {
 			return nil, err
 		}
 		pairs = append(pairs, *pair)
 	}
 	for i := range pairs {
-		result := db.QueryRow(
+		result := db.QueryRowContext(
+			ctx,
 			`
 			SELECT column_name, is_nullable
 			FROM information_schema.columns
-			WHERE column_name = $1;
+			WHERE column_name = $1 AND table_name = $2;
 			`,
 			pairs[i].sourceColumn,
+			pairs[i].sourceTable,
 		)
 		if err := result.Err(); err != nil {
 			return nil, err
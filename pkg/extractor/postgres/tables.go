@@ -8,16 +8,28 @@ import (
 )
 
 type table struct {
-	name    string
-	columns []column
+	name       string
+	columns    []column
+	uniqueKeys map[string][]string
+	// constraints holds the columns of every primary, unique and foreign key by name.
+	constraints map[string][]string
+	// checks holds the definition of every CHECK constraint, such as "CHECK ((age >= 0))".
+	checks []string
 }
 
 type column struct {
-	name     string
-	isNull   string
-	isPk     bool
-	order    int
-	dataType PostgresDataType
+	name          string
+	isNull        string
+	isPk          bool
+	order         int
+	dataType      PostgresDataType
+	columnDefault *string
+	// maxLength bounds character columns, precision and scale numeric ones; nil when unbounded.
+	maxLength *int
+	precision *int
+	scale     *int
+	// enum lists the labels of an enum typed column.
+	enum []string
 }
 
 type (
@@ -35,6 +47,34 @@ func (ts Tables) GetPk(table string) []string {
 	return resp
 }
 
+func (ts Tables) GetNullable(table string) []string {
+	resp := make([]string, 0, len(ts[table].columns))
+	for _, c := range ts[table].columns {
+		if c.isNull == "YES" {
+			resp = append(resp, c.name)
+		}
+	}
+	return resp
+}
+
+func (ts Tables) GetDefaults(table string) map[string]string {
+	resp := make(map[string]string)
+	for _, c := range ts[table].columns {
+		if c.columnDefault != nil {
+			resp[c.name] = *c.columnDefault
+		}
+	}
+	return resp
+}
+
+func (ts Tables) GetUniqueKeys(table string) map[string][]string {
+	return ts[table].uniqueKeys
+}
+
+func (ts Tables) GetConstraints(table string) map[string][]string {
+	return ts[table].constraints
+}
+
 func (ts Tables) GetColumns(table string) []column {
 	return ts[table].columns
 }
@@ -98,6 +138,11 @@ func fetchTable(ctx context.Context, db *sql.DB, name string) (*table, error) {
 			c.is_nullable,
 			c.ordinal_position,
 			c.data_type,
+			c.column_default,
+			c.character_maximum_length,
+			c.numeric_precision,
+			c.numeric_scale,
+			c.udt_name,
 			CASE
 				WHEN kcu.column_name IS NOT NULL THEN 'TRUE'
 				ELSE 'FALSE'
@@ -128,20 +173,161 @@ func fetchTable(ctx context.Context, db *sql.DB, name string) (*table, error) {
 		}
 	}()
 	columns := make([]column, 0, 10)
+	// enumTypes holds the type name of each user defined column by its position.
+	enumTypes := make(map[int]string)
 	for result.Next() {
 		column := new(column)
 		dataType := new(string)
-		if err := result.Scan(&column.name, &column.isNull, &column.order, &dataType, &column.isPk); err != nil {
+		var maxLength, precision, scale *int
+		var udtName string
+		if err := result.Scan(&column.name, &column.isNull, &column.order, &dataType, &column.columnDefault, &maxLength, &precision, &scale, &udtName, &column.isPk); err != nil {
 			return nil, err
 		}
+		if *dataType == "USER-DEFINED" {
+			enumTypes[len(columns)] = udtName
+			column.dataType = ENUM
+			columns = append(columns, *column)
+			continue
+		}
 		converted, err := convert(*dataType)
 		if err != nil {
 			return nil, err
 		}
 		column.dataType = converted
+		switch converted {
+		case VARCHAR, CHAR:
+			column.maxLength = maxLength
+		case NUMERIC, DECIMAL:
+			column.precision, column.scale = precision, scale
+		}
 		columns = append(columns, *column)
 	}
-	return &table{name, columns}, nil
+	for i, typeName := range enumTypes {
+		labels, err := fetchEnumLabels(ctx, db, typeName)
+		if err != nil {
+			return nil, err
+		}
+		// user defined types other than enums are not supported.
+		if len(labels) == 0 {
+			return nil, fmt.Errorf("unknown Postgres data type: %s", typeName)
+		}
+		columns[i].enum = labels
+	}
+	uniqueKeys, constraints, err := fetchConstraints(ctx, db, name)
+	if err != nil {
+		return nil, err
+	}
+	checks, err := fetchChecks(ctx, db, name)
+	if err != nil {
+		return nil, err
+	}
+	return &table{name, columns, uniqueKeys, constraints, checks}, nil
+}
+
+func fetchEnumLabels(ctx context.Context, db *sql.DB, typeName string) ([]string, error) {
+	result, err := db.QueryContext(
+		ctx,
+		`
+		SELECT e.enumlabel
+		FROM pg_enum e
+		JOIN pg_type t ON t.oid = e.enumtypid
+		WHERE t.typname = $1
+		ORDER BY e.enumsortorder
+		`,
+		typeName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := result.Close(); err != nil {
+			panic(err)
+		}
+	}()
+	var labels []string
+	for result.Next() {
+		var label string
+		if err := result.Scan(&label); err != nil {
+			return nil, err
+		}
+		labels = append(labels, label)
+	}
+	return labels, nil
+}
+
+func fetchChecks(ctx context.Context, db *sql.DB, name string) ([]string, error) {
+	result, err := db.QueryContext(
+		ctx,
+		`
+		SELECT pg_get_constraintdef(con.oid)
+		FROM pg_constraint con
+		JOIN pg_class rel ON rel.oid = con.conrelid
+		WHERE rel.relname = $1 AND con.contype = 'c'
+		ORDER BY con.conname
+		`,
+		name,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := result.Close(); err != nil {
+			panic(err)
+		}
+	}()
+	var checks []string
+	for result.Next() {
+		var check string
+		if err := result.Scan(&check); err != nil {
+			return nil, err
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+func fetchConstraints(ctx context.Context, db *sql.DB, name string) (map[string][]string, map[string][]string, error) {
+	result, err := db.QueryContext(
+		ctx,
+		`
+		SELECT tc.constraint_name, tc.constraint_type, kcu.column_name
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage kcu
+			ON tc.constraint_name = kcu.constraint_name
+			AND tc.table_schema = kcu.table_schema
+			AND tc.table_name = kcu.table_name
+		WHERE tc.table_name = $1 AND tc.constraint_type IN ('PRIMARY KEY', 'UNIQUE', 'FOREIGN KEY')
+		ORDER BY tc.constraint_name, kcu.ordinal_position
+		`,
+		name,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if err := result.Close(); err != nil {
+			panic(err)
+		}
+	}()
+	var uniqueKeys, constraints map[string][]string
+	for result.Next() {
+		var constraint, constraintType, column string
+		if err := result.Scan(&constraint, &constraintType, &column); err != nil {
+			return nil, nil, err
+		}
+		if constraints == nil {
+			constraints = make(map[string][]string)
+		}
+		constraints[constraint] = append(constraints[constraint], column)
+		if constraintType != "UNIQUE" {
+			continue
+		}
+		if uniqueKeys == nil {
+			uniqueKeys = make(map[string][]string)
+		}
+		uniqueKeys[constraint] = append(uniqueKeys[constraint], column)
+	}
+	return uniqueKeys, constraints, nil
 }
 
 func listTableNames(ctx context.Context, db *sql.DB, schema string) ([]string, error) {
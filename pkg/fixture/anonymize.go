@@ -0,0 +1,329 @@
+package fixture
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/naonao2323/testgen/pkg/common"
+	"github.com/naonao2323/testgen/pkg/extractor"
+)
+
+// Rule names how a column is masked.
+type Rule string
+
+const (
+	// Hash replaces a string with a keyed hash.
+	Hash Rule = "hash"
+	// Email replaces a string with a fake address at example.com.
+	Email Rule = "email"
+	// Name replaces a string with a fake full name.
+	Name Rule = "name"
+	// Null clears a nullable column.
+	Null Rule = "null"
+	// Format replaces every digit and letter and keeps case, punctuation and length,
+	// so phone numbers or postal codes still look valid.
+	Format Rule = "format"
+	// Pseudonym replaces a string or integer with an opaque value of the same type.
+	Pseudonym Rule = "pseudonym"
+)
+
+var (
+	firstNames = []string{"Alex", "Blake", "Casey", "Dana", "Emery", "Finley", "Gray", "Harper", "Jamie", "Kai", "Logan", "Morgan", "Noel", "Parker", "Quinn", "Riley", "Sage", "Taylor"}
+	lastNames  = []string{"Adams", "Baker", "Clark", "Diaz", "Evans", "Foster", "Garcia", "Hayes", "Ito", "Jensen", "Kim", "Lopez", "Miller", "Novak", "Ortiz", "Patel", "Reed", "Silva"}
+)
+
+// Anonymizer masks the values of a Set column by column. Every rule is a function of the
+// key and the value alone, so equal values are masked alike in every table and run and
+// joins on them still match. References are left as they are.
+type Anonymizer struct {
+	extractor extractor.Extractor
+	rules     map[string]map[string]Rule
+	key       []byte
+}
+
+func NewAnonymizer(extractor extractor.Extractor, rules map[string]map[string]string, key string) Anonymizer {
+	converted := make(map[string]map[string]Rule, len(rules))
+	for table, columns := range rules {
+		converted[table] = make(map[string]Rule, len(columns))
+		for column, rule := range columns {
+			converted[table][column] = Rule(rule)
+		}
+	}
+	return Anonymizer{
+		extractor: extractor,
+		rules:     converted,
+		key:       []byte(key),
+	}
+}
+
+// Validate checks that every rule names a known column and fits its type and length, and
+// that a key is set for the rules keyed by it.
+func (a Anonymizer) Validate() error {
+	tables := make(map[string]struct{})
+	for _, table := range a.extractor.ListTableNames() {
+		tables[table] = struct{}{}
+	}
+	var errs []error
+	keyless := false
+	for _, table := range sortedKeys(a.rules) {
+		if _, ok := tables[table]; !ok {
+			errs = append(errs, fmt.Errorf("fixture: anonymize: unknown table %q", table))
+			continue
+		}
+		columns := a.extractor.GetColumns(table)
+		databaseTypes := a.extractor.GetDatabaseTypes(table)
+		limits := a.extractor.GetLimits(table)
+		nullable := make(map[string]struct{})
+		for _, column := range a.extractor.GetNullable(table) {
+			nullable[column] = struct{}{}
+		}
+		for _, column := range sortedKeys(a.rules[table]) {
+			rule := a.rules[table][column]
+			dataType, ok := columns[column]
+			if !ok {
+				errs = append(errs, fmt.Errorf("fixture: anonymize: unknown column %s.%s", table, column))
+				continue
+			}
+			_, isNull := nullable[column]
+			if err := checkRule(rule, dataType, databaseTypes[column], isNull); err != nil {
+				errs = append(errs, fmt.Errorf("fixture: anonymize: %s.%s: %w", table, column, err))
+				continue
+			}
+			if limit := limits[column]; rule != Null && (len(limit.Enum) > 0 || limit.Min != nil || limit.Max != nil) {
+				errs = append(errs, fmt.Errorf("fixture: anonymize: %s.%s: %s does not keep the values within the enum or check of the column", table, column, rule))
+			}
+			if length := limits[column].Length; dataType == common.String && length > 0 && maxLength(rule) > length {
+				errs = append(errs, fmt.Errorf("fixture: anonymize: %s.%s: %s writes up to %d characters, more than the %d of the column", table, column, rule, maxLength(rule), length))
+			}
+			if rule != Null && len(a.key) == 0 {
+				keyless = true
+			}
+		}
+	}
+	if keyless {
+		// without a secret the masked values of known inputs can be recomputed and matched.
+		errs = append(errs, errors.New("fixture: anonymize: rules other than null need an anonymize key"))
+	}
+	return errors.Join(errs...)
+}
+
+// maxLength returns the most characters rule writes into a string, zero when it keeps the length.
+func maxLength(rule Rule) int {
+	switch rule {
+	case Hash:
+		return 32
+	case Email:
+		return len("user@example.com") + 10
+	case Name:
+		longest := func(names []string) int {
+			n := 0
+			for i := range names {
+				n = max(n, len(names[i]))
+			}
+			return n
+		}
+		return longest(firstNames) + 1 + longest(lastNames)
+	case Pseudonym:
+		return 16
+	}
+	return 0
+}
+
+func checkRule(rule Rule, dataType common.GoDataType, databaseType string, nullable bool) error {
+	switch rule {
+	case Null:
+		if !nullable {
+			return errors.New("null needs a nullable column")
+		}
+		return nil
+	case Hash, Email, Name, Format, Pseudonym:
+	default:
+		return fmt.Errorf("unknown rule %q", rule)
+	}
+	isJSON := databaseType == "json" || databaseType == "jsonb"
+	switch {
+	case dataType == common.String && !isJSON:
+		return nil
+	case dataType == common.Int && (rule == Format || rule == Pseudonym):
+		return nil
+	}
+	return fmt.Errorf("%s does not apply to a %s column", rule, databaseTypeOr(databaseType, dataType))
+}
+
+func databaseTypeOr(databaseType string, dataType common.GoDataType) string {
+	if databaseType != "" {
+		return databaseType
+	}
+	return common.Convert(dataType)
+}
+
+// Apply returns a masked copy of set. Rows named after a masked primary key are renamed
+// after the masked value, and the references to them follow.
+func (a Anonymizer) Apply(set Set) (Set, error) {
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	masked := make(Set, len(set))
+	renamed := make(map[ref]string)
+	for table, rows := range set {
+		rules := a.rules[table]
+		pk := a.extractor.GetPk(table)
+		renames := false
+		for i := range pk {
+			if _, ok := rules[pk[i]]; ok {
+				renames = true
+			}
+		}
+		masked[table] = make(map[string]Row, len(rows))
+		databaseTypes := a.extractor.GetDatabaseTypes(table)
+		for _, name := range sortedKeys(rows) {
+			row := make(Row, len(rows[name]))
+			for column, value := range rows[name] {
+				rule, ok := rules[column]
+				if !ok {
+					row[column] = value
+					continue
+				}
+				v, err := a.mask(rule, databaseTypes[column], value)
+				if err != nil {
+					return nil, fmt.Errorf("fixture: anonymize %s.%s.%s: %w", table, name, column, err)
+				}
+				row[column] = v
+			}
+			newName := name
+			if renames && len(pk) > 0 {
+				keys := make([]any, 0, len(pk))
+				for i := range pk {
+					keys = append(keys, unescape(row[pk[i]]))
+				}
+				newName = rowName(keys)
+				if _, ok := masked[table][newName]; ok {
+					return nil, fmt.Errorf("fixture: anonymize %s: rows %s and another mask to the same key", table, name)
+				}
+				renamed[ref{table: table, row: name}] = newName
+			}
+			masked[table][newName] = row
+		}
+	}
+	for _, rows := range masked {
+		for _, row := range rows {
+			for column, value := range row {
+				r, ok := parseRef(value)
+				if !ok {
+					continue
+				}
+				if name, ok := renamed[ref{table: r.table, row: r.row}]; ok {
+					r.row = name
+					row[column] = r.String()
+				}
+			}
+		}
+	}
+	return masked, nil
+}
+
+// mask applies rule to one value of a column of databaseType; references and NULL are kept.
+// Masked integers are reduced into the range of the column type.
+func (a Anonymizer) mask(rule Rule, databaseType string, value any) (any, error) {
+	if _, ok := parseRef(value); ok || value == nil {
+		return value, nil
+	}
+	if rule == Null {
+		return nil, nil
+	}
+	if n, ok := toInt(value); ok {
+		switch rule {
+		case Format:
+			digits := strconv.FormatInt(n, 10)
+			masked := a.format(strings.TrimPrefix(digits, "-"))
+			// keep the digit count without a leading zero.
+			masked = string(rune('1'+a.digest(Format, masked)[0]%9)) + masked[1:]
+			// 19 digits fit a uint64 but not always an int64.
+			u, err := strconv.ParseUint(masked, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			resp := int64(u % (uint64(intMax(databaseType)) + 1))
+			if n < 0 {
+				resp = -resp
+			}
+			return resp, nil
+		case Pseudonym:
+			return int64(binary.BigEndian.Uint32(a.digest(rule, n))>>1) % (min(intMax(databaseType), math.MaxInt32) + 1), nil
+		}
+	}
+	s, ok := unescape(value).(string)
+	if !ok {
+		return nil, fmt.Errorf("%v (%T) cannot be masked with %s", value, value, rule)
+	}
+	switch rule {
+	case Hash:
+		return escape(hex.EncodeToString(a.digest(rule, s)[:16])), nil
+	case Email:
+		return fmt.Sprintf("user%s@example.com", hex.EncodeToString(a.digest(rule, s)[:5])), nil
+	case Name:
+		d := a.digest(rule, s)
+		return fmt.Sprintf("%s %s", firstNames[int(d[0])%len(firstNames)], lastNames[int(d[1])%len(lastNames)]), nil
+	case Format:
+		return escape(a.format(s)), nil
+	case Pseudonym:
+		return hex.EncodeToString(a.digest(rule, s)[:8]), nil
+	}
+	return nil, fmt.Errorf("unknown rule %q", rule)
+}
+
+// intMax returns the largest value of an integer column type.
+func intMax(databaseType string) int64 {
+	switch databaseType {
+	case "smallint":
+		return math.MaxInt16
+	case "bigint":
+		return math.MaxInt64
+	}
+	return math.MaxInt32
+}
+
+// format replaces each digit and letter with one drawn from a stream keyed by s.
+func (a Anonymizer) format(s string) string {
+	runes := []rune(s)
+	stream := make([]byte, 0, len(runes))
+	for block := 0; len(stream) < len(runes); block++ {
+		stream = append(stream, a.digest(Format, s, block)...)
+	}
+	for i, r := range runes {
+		switch {
+		case unicode.IsDigit(r):
+			runes[i] = rune('0' + stream[i]%10)
+		case unicode.IsUpper(r):
+			runes[i] = rune('A' + stream[i]%26)
+		case unicode.IsLetter(r):
+			runes[i] = rune('a' + stream[i]%26)
+		}
+	}
+	return string(runes)
+}
+
+func (a Anonymizer) digest(rule Rule, values ...any) []byte {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(rule))
+	for i := range values {
+		fmt.Fprintf(mac, "\x00%v", values[i])
+	}
+	return mac.Sum(nil)
+}
+
+// unescape drops the "$" escape doubling a leading "$".
+func unescape(value any) any {
+	if s, ok := value.(string); ok && strings.HasPrefix(s, "$$") {
+		return s[1:]
+	}
+	return value
+}
@@ -0,0 +1,168 @@
+package fixture
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnonymizerValidate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		rules    map[string]map[string]string
+		key      string
+		expected []string
+	}{
+		{
+			name:  "valid",
+			rules: map[string]map[string]string{"users": {"id": "pseudonym", "name": "email", "profile": "null"}, "memos": {"body": "format"}},
+			key:   "secret",
+		},
+		{
+			name:  "null without a key",
+			rules: map[string]map[string]string{"users": {"profile": "null"}},
+		},
+		{
+			name:     "without a key",
+			rules:    map[string]map[string]string{"users": {"name": "hash", "profile": "null"}},
+			expected: []string{"fixture: anonymize: rules other than null need an anonymize key"},
+		},
+		{
+			name:  "longer than the column",
+			rules: map[string]map[string]string{"memos": {"body": "hash"}},
+			key:   "secret",
+			expected: []string{
+				"fixture: anonymize: memos.body: hash writes up to 32 characters, more than the 20 of the column",
+			},
+		},
+		{
+			name:  "outside a check",
+			rules: map[string]map[string]string{"memos": {"user_id": "pseudonym"}},
+			key:   "secret",
+			expected: []string{
+				"fixture: anonymize: memos.user_id: pseudonym does not keep the values within the enum or check of the column",
+			},
+		},
+		{
+			name: "invalid",
+			rules: map[string]map[string]string{
+				"posts": {"title": "hash"},
+				"users": {"nickname": "name", "profile": "hash", "id": "email"},
+				"memos": {"user_id": "null", "body": "shuffle"},
+			},
+			key: "secret",
+			expected: []string{
+				`fixture: anonymize: unknown table "posts"`,
+				`fixture: anonymize: unknown column users.nickname`,
+				`fixture: anonymize: users.profile: hash does not apply to a jsonb column`,
+				`fixture: anonymize: users.id: email does not apply to a int column`,
+				`fixture: anonymize: memos.user_id: null needs a nullable column`,
+				`fixture: anonymize: memos.body: unknown rule "shuffle"`,
+			},
+		},
+	}
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			err := NewAnonymizer(fakeExtractor{}, test.rules, test.key).Validate()
+			if len(test.expected) == 0 {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			for _, expected := range test.expected {
+				assert.Contains(t, err.Error(), expected)
+			}
+		})
+	}
+}
+
+func TestAnonymizerMask(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		rule         Rule
+		databaseType string
+		value        any
+		pattern      string
+	}{
+		{name: "hash", rule: Hash, value: "alice", pattern: `^[0-9a-f]{32}$`},
+		{name: "email", rule: Email, value: "alice@corp.test", pattern: `^user[0-9a-f]{10}@example\.com$`},
+		{name: "name", rule: Name, value: "Alice Liddell", pattern: `^[A-Z][a-z]+ [A-Z][a-z]+$`},
+		{name: "format", rule: Format, value: "+81 (03) AB-12x", pattern: `^\+\d\d \(\d\d\) [A-Z][A-Z]-\d\d[a-z]$`},
+		{name: "escaped format", rule: Format, value: "$$12", pattern: `^\$\$\d\d$`},
+		{name: "pseudonym", rule: Pseudonym, value: "ext-9", pattern: `^[0-9a-f]{16}$`},
+		{name: "int format", rule: Format, value: int64(40213), pattern: `^[1-9]\d{4}$`},
+		{name: "int pseudonym", rule: Pseudonym, value: int64(7), pattern: `^\d+$`},
+		{name: "smallint format", rule: Format, databaseType: "smallint", value: int64(40213), pattern: `^\d{1,5}$`},
+		{name: "bigint format", rule: Format, databaseType: "bigint", value: int64(9223372036854775807), pattern: `^\d{1,19}$`},
+		{name: "null", rule: Null, value: "alice", pattern: `^<nil>$`},
+		{name: "reference", rule: Hash, value: "$users.1", pattern: `^\$users\.1$`},
+	}
+	anonymizer := NewAnonymizer(fakeExtractor{}, nil, "secret")
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := anonymizer.mask(test.rule, test.databaseType, test.value)
+			require.NoError(t, err)
+			assert.Regexp(t, regexp.MustCompile(test.pattern), actual)
+			again, err := anonymizer.mask(test.rule, test.databaseType, test.value)
+			require.NoError(t, err)
+			assert.Equal(t, actual, again)
+		})
+	}
+}
+
+func TestAnonymizerMaskSmallint(t *testing.T) {
+	t.Parallel()
+	anonymizer := NewAnonymizer(fakeExtractor{}, nil, "secret")
+	for n := int64(1); n <= 1000; n++ {
+		for _, rule := range []Rule{Format, Pseudonym} {
+			for _, value := range []int64{n, 32767 - n} {
+				actual, err := anonymizer.mask(rule, "smallint", value)
+				require.NoError(t, err)
+				assert.LessOrEqual(t, actual, int64(32767))
+				assert.GreaterOrEqual(t, actual, int64(0))
+			}
+		}
+	}
+}
+
+func TestAnonymizerApply(t *testing.T) {
+	t.Parallel()
+	set := Set{
+		"users": {"1": {"id": int64(1), "name": "alice"}, "2": {"id": int64(2), "name": "alice"}},
+		"memos": {"10": {"id": int64(10), "user_id": "$users.2", "body": "call 555-0100"}},
+	}
+	rules := map[string]map[string]string{"users": {"id": "pseudonym", "name": "hash"}, "memos": {"body": "format"}}
+	actual, err := NewAnonymizer(fakeExtractor{}, rules, "secret").Apply(set)
+	require.NoError(t, err)
+	require.Len(t, actual["users"], 2)
+	names := make(map[string]any)
+	for name, row := range actual["users"] {
+		assert.NotContains(t, []string{"1", "2"}, name)
+		assert.Equal(t, rowName([]any{row["id"]}), name)
+		names[name] = row["name"]
+	}
+	memo := actual["memos"]["10"]
+	r, ok := parseRef(memo["user_id"])
+	require.True(t, ok)
+	require.Contains(t, actual["users"], r.row)
+	assert.NotEqual(t, set["users"]["2"]["id"], actual["users"][r.row]["id"])
+	assert.Regexp(t, `^[a-z]{4} \d{3}-\d{4}$`, memo["body"])
+	for _, name := range names {
+		// equal values mask alike.
+		assert.Equal(t, actual["users"][r.row]["name"], name)
+	}
+	// the input is left untouched.
+	assert.Equal(t, "alice", set["users"]["1"]["name"])
+
+	other, err := NewAnonymizer(fakeExtractor{}, rules, "other").Apply(set)
+	require.NoError(t, err)
+	assert.NotEqual(t, actual["memos"]["10"]["body"], other["memos"]["10"]["body"])
+}
@@ -0,0 +1,360 @@
+package fixture
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/lib/pq"
+	"github.com/naonao2323/testgen/pkg/common"
+	"github.com/naonao2323/testgen/pkg/extractor"
+	"github.com/naonao2323/testgen/pkg/query"
+	"github.com/naonao2323/testgen/pkg/template"
+)
+
+// Capturer reads rows and every row they reference through foreign keys into a Set.
+// Foreign keys are followed only to parents with a single column primary key, which
+// they are assumed to reference.
+type Capturer struct {
+	extractor extractor.Extractor
+	db        *sql.DB
+}
+
+func NewCapturer(extractor extractor.Extractor, db *sql.DB) Capturer {
+	return Capturer{
+		extractor: extractor,
+		db:        db,
+	}
+}
+
+// captured is a row read from table, keyed by column, before it is put in a Set.
+type captured struct {
+	table  string
+	name   string
+	values map[string]any
+}
+
+// Capture selects the rows of table matching where, an SQL condition that may be empty,
+// and walks their foreign keys until every referenced row is part of the Set.
+// Foreign key columns become references such as "$users.1".
+func (c Capturer) Capture(ctx context.Context, table string, where string) (Set, error) {
+	builder := query.NewBuilder(query.Postgres, reserved(c.extractor))
+	q, err := builder.Build(query.Select{Table: table, Columns: sortedKeys(c.extractor.GetColumns(table))})
+	if err != nil {
+		return nil, err
+	}
+	statement := q.SQL
+	if where != "" {
+		statement += " WHERE " + where
+	}
+	pending, err := c.fetch(ctx, table, statement)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[ref]bool, len(pending))
+	for i := range pending {
+		seen[ref{table: table, row: pending[i].name}] = true
+	}
+	set := make(Set)
+	for len(pending) > 0 {
+		current := pending[0]
+		pending = pending[1:]
+		row := make(Row, len(current.values))
+		for column, value := range current.values {
+			row[column] = escape(value)
+		}
+		for _, fk := range c.extractor.GetForeignKeys(current.table) {
+			value := current.values[fk.Column]
+			pk := c.extractor.GetPk(fk.Table)
+			if value == nil || len(pk) != 1 {
+				continue
+			}
+			parent := ref{table: fk.Table, row: rowName([]any{value})}
+			if !seen[parent] {
+				q, err := builder.Build(query.Select{
+					Table:   fk.Table,
+					Columns: sortedKeys(c.extractor.GetColumns(fk.Table)),
+					Where:   pk,
+				})
+				if err != nil {
+					return nil, err
+				}
+				parents, err := c.fetch(ctx, fk.Table, q.SQL, value)
+				if err != nil {
+					return nil, err
+				}
+				if len(parents) == 0 {
+					// a dangling key stays a literal value.
+					continue
+				}
+				seen[parent] = true
+				pending = append(pending, parents...)
+			}
+			row[fk.Column] = parent.String()
+		}
+		if set[current.table] == nil {
+			set[current.table] = make(map[string]Row)
+		}
+		set[current.table][current.name] = row
+	}
+	return set, nil
+}
+
+// fetch runs statement and names each row after its primary key, or its position without one.
+func (c Capturer) fetch(ctx context.Context, table string, statement string, args ...any) ([]captured, error) {
+	columns := sortedKeys(c.extractor.GetColumns(table))
+	types := c.extractor.GetColumns(table)
+	databaseTypes := c.extractor.GetDatabaseTypes(table)
+	pk := c.extractor.GetPk(table)
+	rows, err := c.db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("fixture: capture %s: %w", table, err)
+	}
+	defer rows.Close()
+	resp := make([]captured, 0)
+	for rows.Next() {
+		dest := make([]any, len(columns))
+		for i := range dest {
+			dest[i] = new(any)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("fixture: capture %s: %w", table, err)
+		}
+		values := make(map[string]any, len(columns))
+		for i, column := range columns {
+			value, err := fromDatabase(types[column], databaseTypes[column], *dest[i].(*any))
+			if err != nil {
+				return nil, fmt.Errorf("fixture: capture %s.%s: %w", table, column, err)
+			}
+			values[column] = value
+		}
+		name := fmt.Sprintf("row%d", len(resp)+1)
+		if len(pk) > 0 {
+			keys := make([]any, 0, len(pk))
+			for i := range pk {
+				keys = append(keys, values[pk[i]])
+			}
+			name = rowName(keys)
+		}
+		resp = append(resp, captured{table: table, name: name, values: values})
+	}
+	return resp, rows.Err()
+}
+
+// rowName joins primary key values into a row name usable in references and Go identifiers.
+func rowName(keys []any) string {
+	parts := make([]string, 0, len(keys))
+	for i := range keys {
+		parts = append(parts, fmt.Sprint(keys[i]))
+	}
+	return identifier(strings.Join(parts, "_"))
+}
+
+func identifier(s string) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, s)
+	if name == "" {
+		return "_"
+	}
+	return name
+}
+
+// fromDatabase turns a scanned value into the form fixture files hold.
+func fromDatabase(dataType common.GoDataType, databaseType string, value any) (any, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		switch dataType {
+		case common.IntArray:
+			var ints pq.Int64Array
+			if err := ints.Scan(v); err != nil {
+				return nil, err
+			}
+			resp := make([]any, 0, len(ints))
+			for i := range ints {
+				resp = append(resp, ints[i])
+			}
+			return resp, nil
+		case common.StringArray:
+			var strs pq.StringArray
+			if err := strs.Scan(v); err != nil {
+				return nil, err
+			}
+			resp := make([]any, 0, len(strs))
+			for i := range strs {
+				resp = append(resp, strs[i])
+			}
+			return resp, nil
+		case common.Int:
+			return strconv.ParseInt(string(v), 10, 64)
+		case common.Float64:
+			return strconv.ParseFloat(string(v), 64)
+		}
+		return fromDatabase(dataType, databaseType, string(v))
+	case string:
+		if databaseType == "json" || databaseType == "jsonb" {
+			var decoded any
+			decoder := json.NewDecoder(strings.NewReader(v))
+			decoder.UseNumber()
+			if err := decoder.Decode(&decoded); err != nil {
+				return nil, err
+			}
+			return fromJSON(decoded), nil
+		}
+		return v, nil
+	case time.Time:
+		switch databaseType {
+		case "date":
+			return v.Format("2006-01-02"), nil
+		case "time":
+			return v.Format("15:04:05.999999"), nil
+		case "timetz":
+			return v.Format("15:04:05.999999Z07:00"), nil
+		case "timestamp":
+			return v.Format("2006-01-02 15:04:05.999999"), nil
+		default:
+			return v.Format(time.RFC3339Nano), nil
+		}
+	}
+	return value, nil
+}
+
+// escape doubles a leading "$" so a captured string is not read as a reference.
+func escape(value any) any {
+	if s, ok := value.(string); ok && strings.HasPrefix(s, "$") {
+		return "$" + s
+	}
+	return value
+}
+
+// WriteGo renders set as Go test code inserting every row through the generated fixtures.
+// name suffixes the Load function and source documents where the rows came from.
+func (c Capturer) WriteGo(w io.Writer, name string, source string, set Set) error {
+	entries, err := NewLoader(c.extractor, nil).order(set)
+	if err != nil {
+		return err
+	}
+	referenced := make(map[ref]bool)
+	for _, rows := range set {
+		for _, row := range rows {
+			for _, value := range row {
+				if r, ok := parseRef(value); ok {
+					referenced[ref{table: r.table, row: r.row}] = true
+				}
+			}
+		}
+	}
+	rows := make([]template.CapturedRow, 0, len(entries))
+	for _, e := range entries {
+		types := c.extractor.GetColumns(e.table)
+		databaseTypes := c.extractor.GetDatabaseTypes(e.table)
+		nullable := make(map[string]struct{})
+		for _, column := range c.extractor.GetNullable(e.table) {
+			nullable[column] = struct{}{}
+		}
+		row := template.CapturedRow{Table: e.table}
+		if referenced[ref{table: e.table, row: e.name}] {
+			row.Var = variable(e.table, e.name)
+		}
+		for _, column := range sortedKeys(e.row) {
+			value := e.row[column]
+			if value == nil {
+				continue
+			}
+			if r, ok := parseRef(value); ok {
+				row.Values = append(row.Values, template.CapturedValue{Column: column, Parent: variable(r.table, r.row)})
+				continue
+			}
+			_, isNull := nullable[column]
+			literal, err := goLiteral(types[column], databaseTypes[column], isNull, value)
+			if err != nil {
+				return fmt.Errorf("fixture: %s.%s.%s: %w", e.table, e.name, column, err)
+			}
+			row.Values = append(row.Values, template.CapturedValue{Column: column, Literal: literal})
+		}
+		rows = append(rows, row)
+	}
+	tmpl, err := template.NewTemplate(nil)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(template.PostgresCapture, w, template.Data{
+		Capture: &template.Capture{Name: name, Source: source, Rows: rows},
+	})
+}
+
+func variable(table string, row string) string {
+	return identifier(table + "_" + row)
+}
+
+// goLiteral renders value as an argument of the With setter of the sql DAO column type.
+func goLiteral(dataType common.GoDataType, databaseType string, nullable bool, value any) (string, error) {
+	var literal string
+	switch dataType {
+	case common.Int:
+		n, ok := toInt(value)
+		if !ok {
+			return "", fmt.Errorf("%v (%T) is not an integer", value, value)
+		}
+		literal = strconv.FormatInt(n, 10)
+	case common.Float64:
+		f, ok := value.(float64)
+		if n, isInt := toInt(value); isInt {
+			f, ok = float64(n), true
+		}
+		if !ok {
+			return "", fmt.Errorf("%v (%T) is not a number", value, value)
+		}
+		literal = strconv.FormatFloat(f, 'g', -1, 64)
+		if !strings.ContainsAny(literal, ".eEN") {
+			literal += ".0"
+		}
+	case common.Bool:
+		literal = fmt.Sprint(value)
+	case common.IntArray, common.StringArray:
+		// arrays are never pointers; an untyped slice converts to the pq array type.
+		values, _ := value.([]any)
+		elements := make([]string, 0, len(values))
+		for i := range values {
+			if dataType == common.IntArray {
+				elements = append(elements, fmt.Sprint(values[i]))
+			} else {
+				elements = append(elements, strconv.Quote(fmt.Sprint(values[i])))
+			}
+		}
+		return fmt.Sprintf("%s{%s}", common.Convert(dataType), strings.Join(elements, ", ")), nil
+	default:
+		s, ok := value.(string)
+		switch {
+		case ok && strings.HasPrefix(s, "$$"):
+			s = s[1:]
+		case !ok && (databaseType == "json" || databaseType == "jsonb"):
+			var buf bytes.Buffer
+			encoder := json.NewEncoder(&buf)
+			encoder.SetEscapeHTML(false)
+			if err := encoder.Encode(value); err != nil {
+				return "", err
+			}
+			s = strings.TrimSuffix(buf.String(), "\n")
+		case !ok:
+			s = fmt.Sprint(value)
+		}
+		literal = strconv.Quote(s)
+	}
+	if nullable {
+		return fmt.Sprintf("testPtr(%s)", literal), nil
+	}
+	return literal, nil
+}
@@ -0,0 +1,130 @@
+package fixture
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/naonao2323/testgen/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromDatabase(t *testing.T) {
+	t.Parallel()
+	at := time.Date(2024, 1, 2, 3, 4, 5, 600000000, time.UTC)
+	tests := []struct {
+		name         string
+		dataType     common.GoDataType
+		databaseType string
+		value        any
+		expected     any
+	}{
+		{name: "null", dataType: common.String, value: nil, expected: nil},
+		{name: "int array", dataType: common.IntArray, databaseType: "integer[]", value: []byte("{1,2}"), expected: []any{int64(1), int64(2)}},
+		{name: "text array", dataType: common.StringArray, databaseType: "text[]", value: []byte(`{a,"b c"}`), expected: []any{"a", "b c"}},
+		{name: "numeric", dataType: common.Float64, databaseType: "numeric", value: []byte("12.50"), expected: 12.5},
+		{name: "text bytes", dataType: common.String, databaseType: "text", value: []byte("hello"), expected: "hello"},
+		{name: "jsonb", dataType: common.String, databaseType: "jsonb", value: []byte(`{"age": 3}`), expected: map[string]any{"age": int64(3)}},
+		{name: "date", dataType: common.String, databaseType: "date", value: at, expected: "2024-01-02"},
+		{name: "timestamp", dataType: common.String, databaseType: "timestamp", value: at, expected: "2024-01-02 03:04:05.6"},
+		{name: "timestamptz", dataType: common.String, databaseType: "timestamptz", value: at, expected: "2024-01-02T03:04:05.6Z"},
+		{name: "int", dataType: common.Int, databaseType: "integer", value: int64(7), expected: int64(7)},
+	}
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := fromDatabase(test.dataType, test.databaseType, test.value)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestGoLiteral(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		dataType     common.GoDataType
+		databaseType string
+		nullable     bool
+		value        any
+		expected     string
+	}{
+		{name: "int", dataType: common.Int, value: int64(3), expected: "3"},
+		{name: "nullable int", dataType: common.Int, nullable: true, value: int64(3), expected: "testPtr(3)"},
+		{name: "whole float", dataType: common.Float64, nullable: true, value: int64(2), expected: "testPtr(2.0)"},
+		{name: "float", dataType: common.Float64, value: 2.5, expected: "2.5"},
+		{name: "bool", dataType: common.Bool, value: true, expected: "true"},
+		{name: "escaped string", dataType: common.String, value: "$$5 \"off\"", expected: `"$5 \"off\""`},
+		{name: "json", dataType: common.String, databaseType: "jsonb", value: map[string]any{"a": "<b>"}, expected: `"{\"a\":\"<b>\"}"`},
+		{name: "int array", dataType: common.IntArray, nullable: true, value: []any{int64(1), int64(2)}, expected: "[]int64{1, 2}"},
+		{name: "text array", dataType: common.StringArray, value: []any{"a"}, expected: `[]string{"a"}`},
+	}
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := goLiteral(test.dataType, test.databaseType, test.nullable, test.value)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestRowName(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		keys     []any
+		expected string
+	}{
+		{name: "int", keys: []any{int64(42)}, expected: "42"},
+		{name: "composite", keys: []any{int64(1), "a"}, expected: "1_a"},
+		{name: "uuid", keys: []any{"0f8e-11.x"}, expected: "0f8e_11_x"},
+	}
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, test.expected, rowName(test.keys))
+		})
+	}
+}
+
+func TestWriteGo(t *testing.T) {
+	t.Parallel()
+	set := Set{
+		"users": {"1": {"id": int64(1), "name": "$$alice", "tags": nil}},
+		"memos": {
+			"10": {"id": int64(10), "user_id": "$users.1", "body": "root"},
+			"11": {"id": int64(11), "user_id": "$users.1", "parent_id": "$memos.10", "body": "reply"},
+		},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, NewCapturer(fakeExtractor{}, nil).WriteGo(&buf, "thread", "memos WHERE id = 11", set))
+	expected := `
+// LoadThread inserts the rows captured from memos WHERE id = 11 through the
+// generated fixtures, parents first. Captured keys are kept as they were.
+func LoadThread(t testing.TB, db DBTX) {
+	t.Helper()
+	users_1 := NewUsersFixture().
+		WithId(1).
+		WithName("$alice").
+		Insert(t, db)
+	memos_10 := NewMemosFixture().
+		WithBody("root").
+		WithId(10).
+		WithUser(users_1).
+		Insert(t, db)
+	NewMemosFixture().
+		WithBody("reply").
+		WithId(11).
+		WithParent(memos_10).
+		WithUser(users_1).
+		Insert(t, db)
+}
+`
+	assert.Contains(t, buf.String(), expected)
+}
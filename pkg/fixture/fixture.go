@@ -0,0 +1,162 @@
+package fixture
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// Set holds fixture rows by table and then by row name.
+	Set map[string]map[string]Row
+	// Row maps a column to its value. A string such as "$users.alice" refers to
+	// another row; "$users.alice.name" names the column explicitly.
+	Row map[string]any
+)
+
+type ref struct {
+	table  string
+	row    string
+	column string
+}
+
+func (r ref) String() string {
+	if r.column == "" {
+		return fmt.Sprintf("$%s.%s", r.table, r.row)
+	}
+	return fmt.Sprintf("$%s.%s.%s", r.table, r.row, r.column)
+}
+
+// parseRef reads a reference; ok is false for plain values. "$$" escapes a leading "$".
+func parseRef(value any) (ref, bool) {
+	s, ok := value.(string)
+	if !ok || !strings.HasPrefix(s, "$") || strings.HasPrefix(s, "$$") {
+		return ref{}, false
+	}
+	parts := strings.Split(s[1:], ".")
+	switch len(parts) {
+	case 2:
+		return ref{table: parts[0], row: parts[1]}, true
+	case 3:
+		return ref{table: parts[0], row: parts[1], column: parts[2]}, true
+	default:
+		return ref{}, false
+	}
+}
+
+// ParseFile reads a YAML (.yaml, .yml) or JSON (.json) fixture file.
+func ParseFile(path string) (Set, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	set := make(Set)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &set)
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&set)
+		if err == nil {
+			for _, rows := range set {
+				for _, row := range rows {
+					for column, value := range row {
+						row[column] = fromJSON(value)
+					}
+				}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("fixture: unknown file format: %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fixture: %s: %w", path, err)
+	}
+	return set, nil
+}
+
+// WriteFile writes set as YAML (.yaml, .yml) or JSON (.json), tables and rows sorted by name.
+func WriteFile(path string, set Set) error {
+	var data []byte
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		data, err = yaml.Marshal(set)
+	case ".json":
+		data, err = json.MarshalIndent(set, "", "  ")
+		data = append(data, '\n')
+	default:
+		return fmt.Errorf("fixture: unknown file format: %s", path)
+	}
+	if err != nil {
+		return fmt.Errorf("fixture: %s: %w", path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// fromJSON turns numbers into int64 or float64 as YAML decoding does.
+func fromJSON(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []any:
+		for i := range v {
+			v[i] = fromJSON(v[i])
+		}
+	case map[string]any:
+		for key := range v {
+			v[key] = fromJSON(v[key])
+		}
+	}
+	return value
+}
+
+// ParseFiles reads and merges fixture files; a row may be defined only once.
+func ParseFiles(paths ...string) (Set, error) {
+	sets := make([]Set, 0, len(paths))
+	for i := range paths {
+		set, err := ParseFile(paths[i])
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, set)
+	}
+	return Merge(sets...)
+}
+
+func Merge(sets ...Set) (Set, error) {
+	merged := make(Set)
+	var errs []error
+	for _, set := range sets {
+		for table, rows := range set {
+			if merged[table] == nil {
+				merged[table] = make(map[string]Row, len(rows))
+			}
+			for name, row := range rows {
+				if _, ok := merged[table][name]; ok {
+					errs = append(errs, fmt.Errorf("fixture: %s.%s is defined twice", table, name))
+					continue
+				}
+				merged[table][name] = row
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return merged, nil
+}
@@ -0,0 +1,353 @@
+package fixture
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/naonao2323/testgen/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeExtractor struct{}
+
+var schema = map[string]struct {
+	pk          []string
+	columns     map[string]common.GoDataType
+	nullable    []string
+	defaults    map[string]string
+	foreignKeys []common.ForeignKey
+}{
+	"users": {
+		pk:       []string{"id"},
+		columns:  map[string]common.GoDataType{"id": common.Int, "name": common.String, "tags": common.StringArray, "profile": common.String},
+		nullable: []string{"tags", "profile"},
+		defaults: map[string]string{"id": "nextval('users_id_seq'::regclass)"},
+	},
+	"memos": {
+		pk:          []string{"id"},
+		columns:     map[string]common.GoDataType{"id": common.Int, "user_id": common.Int, "parent_id": common.Int, "body": common.String},
+		nullable:    []string{"parent_id"},
+		foreignKeys: []common.ForeignKey{{Column: "parent_id", Table: "memos", IsNull: true}, {Column: "user_id", Table: "users"}},
+	},
+}
+
+func (fakeExtractor) GetPk(table string) []string { return schema[table].pk }
+
+func (fakeExtractor) GetNullable(table string) []string { return schema[table].nullable }
+
+func (fakeExtractor) GetDefaults(table string) map[string]string { return schema[table].defaults }
+
+func (fakeExtractor) GetColumns(table string) map[string]common.GoDataType {
+	return schema[table].columns
+}
+
+func (fakeExtractor) GetDatabaseTypes(table string) map[string]string {
+	if table == "users" {
+		return map[string]string{"profile": "jsonb"}
+	}
+	return nil
+}
+
+func (fakeExtractor) GetForeignKeys(table string) []common.ForeignKey {
+	return schema[table].foreignKeys
+}
+
+func (fakeExtractor) GetUniqueKeys(table string) map[string][]string { return nil }
+
+func (fakeExtractor) GetConstraints(table string) map[string][]string { return nil }
+
+var minID = 1.0
+
+func (fakeExtractor) GetLimits(table string) map[string]common.Limit {
+	if table == "memos" {
+		return map[string]common.Limit{"body": {Length: 20}, "user_id": {Min: &minID}}
+	}
+	return nil
+}
+
+func (fakeExtractor) ListTableNames() []string { return []string{"memos", "users"} }
+
+func (fakeExtractor) ListReservedWord() []string { return nil }
+
+func TestParseFile(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		expected Set
+		err      bool
+	}{
+		{
+			name: "yaml",
+			file: "users.yaml",
+			content: `users:
+  alice:
+    name: alice
+    tags: [a, b]
+memos:
+  hello:
+    user_id: $users.alice
+    body: hello
+`,
+			expected: Set{
+				"users": {"alice": {"name": "alice", "tags": []any{"a", "b"}}},
+				"memos": {"hello": {"user_id": "$users.alice", "body": "hello"}},
+			},
+		},
+		{
+			name:    "json",
+			file:    "users.json",
+			content: `{"users": {"alice": {"id": 1, "name": "alice", "profile": {"age": 2.5}}}}`,
+			expected: Set{
+				"users": {"alice": {"id": int64(1), "name": "alice", "profile": map[string]any{"age": 2.5}}},
+			},
+		},
+		{
+			name:    "unknown format",
+			file:    "users.toml",
+			content: ``,
+			err:     true,
+		},
+	}
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), test.file)
+			require.NoError(t, os.WriteFile(path, []byte(test.content), 0o600))
+			actual, err := ParseFile(path)
+			if test.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	t.Parallel()
+	set := Set{
+		"users": {"1": {"id": int64(1), "name": "$$alice", "tags": []any{"a"}, "profile": map[string]any{"age": 2.5}}},
+		"memos": {"10": {"id": int64(10), "user_id": "$users.1", "parent_id": nil}},
+	}
+	for _, _test := range []string{"fixtures.yaml", "fixtures.json"} {
+		test := _test
+		t.Run(test, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), "testdata", test)
+			require.NoError(t, WriteFile(path, set))
+			actual, err := ParseFile(path)
+			require.NoError(t, err)
+			// yaml decodes integers as int, so the sets are compared by their encoding.
+			expected, err := json.Marshal(set)
+			require.NoError(t, err)
+			encoded, err := json.Marshal(actual)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(expected), string(encoded))
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		sets     []Set
+		expected Set
+		err      bool
+	}{
+		{
+			name: "disjoint rows",
+			sets: []Set{
+				{"users": {"alice": {"name": "alice"}}},
+				{"users": {"bob": {"name": "bob"}}},
+			},
+			expected: Set{"users": {"alice": {"name": "alice"}, "bob": {"name": "bob"}}},
+		},
+		{
+			name: "row defined twice",
+			sets: []Set{
+				{"users": {"alice": {"name": "alice"}}},
+				{"users": {"alice": {"name": "bob"}}},
+			},
+			err: true,
+		},
+	}
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := Merge(test.sets...)
+			if test.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		set      Set
+		expected []string
+	}{
+		{
+			name: "valid",
+			set: Set{
+				"users": {"alice": {"name": "alice", "profile": map[string]any{"age": 1}}},
+				"memos": {
+					"hello": {"id": 1, "user_id": "$users.alice", "body": "hello"},
+					"reply": {"id": 2, "user_id": "$users.alice", "parent_id": "$memos.hello", "body": "$$5"},
+				},
+			},
+		},
+		{
+			name: "invalid",
+			set: Set{
+				"posts": {"a": {}},
+				"users": {"alice": {"nickname": "a"}},
+				"memos": {"hello": {"id": "one", "user_id": "$users.bob", "body": "$users.alice.age"}},
+			},
+			expected: []string{
+				`fixture: memos.hello.body: $users.alice.age reads unknown column "age"`,
+				`fixture: memos.hello.id: one (string) does not fit a int column`,
+				`fixture: memos.hello.user_id: unknown row $users.bob`,
+				`fixture: unknown table "posts"`,
+				`fixture: users.alice: missing NOT NULL column "name"`,
+				`fixture: users.alice: unknown column "nickname"`,
+			},
+		},
+		{
+			name: "null",
+			set: Set{
+				"users": {"alice": {"name": nil, "profile": nil}},
+				"memos": {"hello": {"id": nil, "user_id": "$users.alice", "parent_id": nil, "body": "hello"}},
+			},
+			expected: []string{
+				`fixture: memos.hello.id: null in a NOT NULL column`,
+				`fixture: users.alice.name: null in a NOT NULL column`,
+			},
+		},
+	}
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			err := NewLoader(fakeExtractor{}, nil).Validate(test.set)
+			if len(test.expected) == 0 {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			for _, expected := range test.expected {
+				assert.Contains(t, err.Error(), expected)
+			}
+		})
+	}
+}
+
+func TestOrder(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		set      Set
+		expected []string
+		err      error
+	}{
+		{
+			name: "parents first",
+			set: Set{
+				"memos": {
+					"a": {"user_id": "$users.bob", "parent_id": "$memos.b"},
+					"b": {"user_id": "$users.alice"},
+				},
+				"users": {"bob": {}, "alice": {}},
+			},
+			expected: []string{"users.alice", "users.bob", "memos.b", "memos.a"},
+		},
+		{
+			name: "literal keys follow the foreign key graph",
+			set: Set{
+				"memos": {"a": {"user_id": 1}},
+				"users": {"alice": {"id": 1}},
+			},
+			expected: []string{"users.alice", "memos.a"},
+		},
+		{
+			name: "cycle",
+			set: Set{
+				"memos": {
+					"a": {"parent_id": "$memos.b"},
+					"b": {"parent_id": "$memos.a"},
+				},
+			},
+			err: ErrCycle,
+		},
+	}
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			entries, err := NewLoader(fakeExtractor{}, nil).order(test.set)
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			actual := make([]string, 0, len(entries))
+			for _, e := range entries {
+				actual = append(actual, e.table+"."+e.name)
+			}
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestToArg(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		dataType     common.GoDataType
+		databaseType string
+		value        any
+		expected     any
+	}{
+		{
+			name:     "escaped dollar",
+			dataType: common.String,
+			value:    "$$5",
+			expected: "$5",
+		},
+		{
+			name:         "json object",
+			dataType:     common.String,
+			databaseType: "jsonb",
+			value:        map[string]any{"age": 1},
+			expected:     `{"age":1}`,
+		},
+		{
+			name:     "int",
+			dataType: common.Int,
+			value:    int64(3),
+			expected: int64(3),
+		},
+	}
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual := toArg(test.dataType, test.databaseType, test.value)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,471 @@
+package fixture
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/naonao2323/testgen/pkg/common"
+	"github.com/naonao2323/testgen/pkg/extractor"
+	"github.com/naonao2323/testgen/pkg/query"
+)
+
+var ErrCycle = errors.New("fixture: rows reference each other in a cycle")
+
+// Loader validates fixture sets against the extracted schema and inserts them.
+type Loader struct {
+	extractor extractor.Extractor
+	db        *sql.DB
+}
+
+func NewLoader(extractor extractor.Extractor, db *sql.DB) Loader {
+	return Loader{
+		extractor: extractor,
+		db:        db,
+	}
+}
+
+// entry is a row to insert, named by table and row name.
+type entry struct {
+	table string
+	name  string
+	row   Row
+}
+
+// LoadFiles parses, merges and loads fixture files.
+func (l Loader) LoadFiles(ctx context.Context, paths ...string) error {
+	set, err := ParseFiles(paths...)
+	if err != nil {
+		return err
+	}
+	return l.Load(ctx, set)
+}
+
+// Load validates set, then in one transaction empties its tables and inserts every row
+// after the rows it references. Calling it at the start of each test reloads a known state.
+// TRUNCATE cascades, so tables referencing the loaded ones are emptied as well.
+func (l Loader) Load(ctx context.Context, set Set) error {
+	if err := l.Validate(set); err != nil {
+		return err
+	}
+	entries, err := l.order(set)
+	if err != nil {
+		return err
+	}
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	builder := query.NewBuilder(query.Postgres, reserved(l.extractor))
+	tables := make([]string, 0, len(set))
+	for table := range set {
+		tables = append(tables, builder.Ident(table))
+	}
+	sort.Strings(tables)
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("TRUNCATE %s RESTART IDENTITY CASCADE", strings.Join(tables, ", "))); err != nil {
+		return err
+	}
+	returning := referenced(set, l.extractor)
+	inserted := make(map[string]map[string]map[string]any)
+	for _, e := range entries {
+		values, err := l.insert(ctx, tx, builder, e, returning[e.table][e.name], inserted)
+		if err != nil {
+			return fmt.Errorf("fixture: insert %s.%s: %w", e.table, e.name, err)
+		}
+		if inserted[e.table] == nil {
+			inserted[e.table] = make(map[string]map[string]any)
+		}
+		inserted[e.table][e.name] = values
+	}
+	for table := range set {
+		if err := l.resetSequences(ctx, tx, builder, table); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func reserved(extractor extractor.Extractor) map[string]struct{} {
+	words := extractor.ListReservedWord()
+	reserved := make(map[string]struct{}, len(words))
+	for i := range words {
+		reserved[words[i]] = struct{}{}
+	}
+	return reserved
+}
+
+// insert writes one row and returns its values, including the returning columns read back.
+func (l Loader) insert(ctx context.Context, tx *sql.Tx, builder query.Builder, e entry, returning []string, inserted map[string]map[string]map[string]any) (map[string]any, error) {
+	columns := make([]string, 0, len(e.row))
+	for column := range e.row {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	values := make(map[string]any, len(columns)+len(returning))
+	args := make([]any, 0, len(columns))
+	types := l.extractor.GetColumns(e.table)
+	databaseTypes := l.extractor.GetDatabaseTypes(e.table)
+	for _, column := range columns {
+		value := e.row[column]
+		if r, ok := parseRef(value); ok {
+			value = inserted[r.table][r.row][resolveColumn(l.extractor, r)]
+		}
+		values[column] = value
+		args = append(args, toArg(types[column], databaseTypes[column], value))
+	}
+	q, err := builder.Build(query.Insert{Table: e.table, Columns: columns, Returning: returning})
+	if err != nil {
+		return nil, err
+	}
+	if len(returning) == 0 {
+		_, err := tx.ExecContext(ctx, q.SQL, args...)
+		return values, err
+	}
+	dest := make([]any, len(returning))
+	for i := range dest {
+		dest[i] = new(any)
+	}
+	if err := tx.QueryRowContext(ctx, q.SQL, args...).Scan(dest...); err != nil {
+		return nil, err
+	}
+	for i := range returning {
+		value := *dest[i].(*any)
+		// text comes back as bytes, which would be sent as bytea when referenced.
+		if b, ok := value.([]byte); ok {
+			value = string(b)
+		}
+		values[returning[i]] = value
+	}
+	return values, nil
+}
+
+// toArg converts a decoded fixture value to a driver argument.
+func toArg(dataType common.GoDataType, databaseType string, value any) any {
+	switch v := value.(type) {
+	case string:
+		if strings.HasPrefix(v, "$$") {
+			return v[1:]
+		}
+		return v
+	case []any:
+		switch dataType {
+		case common.IntArray:
+			ints := make([]int64, 0, len(v))
+			for i := range v {
+				n, _ := toInt(v[i])
+				ints = append(ints, n)
+			}
+			return pq.Array(ints)
+		case common.StringArray:
+			strs := make([]string, 0, len(v))
+			for i := range v {
+				strs = append(strs, fmt.Sprint(v[i]))
+			}
+			return pq.Array(strs)
+		}
+	}
+	if databaseType == "json" || databaseType == "jsonb" {
+		if _, ok := value.(string); !ok && value != nil {
+			encoded, err := json.Marshal(value)
+			if err == nil {
+				return string(encoded)
+			}
+		}
+	}
+	return value
+}
+
+func toInt(value any) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint64:
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// resetSequences moves serial sequences past the loaded ids so later inserts do not collide.
+func (l Loader) resetSequences(ctx context.Context, tx *sql.Tx, builder query.Builder, table string) error {
+	defaults := l.extractor.GetDefaults(table)
+	columns := make([]string, 0, len(defaults))
+	for column, value := range defaults {
+		if strings.HasPrefix(value, "nextval(") {
+			columns = append(columns, column)
+		}
+	}
+	sort.Strings(columns)
+	for _, column := range columns {
+		_, err := tx.ExecContext(
+			ctx,
+			fmt.Sprintf(
+				"SELECT setval(pg_get_serial_sequence($1, $2), COALESCE(MAX(%s), 0) + 1, false) FROM %s",
+				builder.Ident(column),
+				builder.Ident(table),
+			),
+			table,
+			column,
+		)
+		if err != nil {
+			return fmt.Errorf("fixture: reset sequence of %s.%s: %w", table, column, err)
+		}
+	}
+	return nil
+}
+
+// resolveColumn returns the column a reference reads: the named one or else the single
+// column primary key foreign keys point at, empty when the table has none.
+func resolveColumn(extractor extractor.Extractor, r ref) string {
+	if r.column != "" {
+		return r.column
+	}
+	if pk := extractor.GetPk(r.table); len(pk) == 1 {
+		return pk[0]
+	}
+	return ""
+}
+
+// referenced lists per row the columns other rows read through references.
+func referenced(set Set, extractor extractor.Extractor) map[string]map[string][]string {
+	columns := make(map[string]map[string]map[string]struct{})
+	for _, rows := range set {
+		for _, row := range rows {
+			for _, value := range row {
+				r, ok := parseRef(value)
+				if !ok {
+					continue
+				}
+				if columns[r.table] == nil {
+					columns[r.table] = make(map[string]map[string]struct{})
+				}
+				if columns[r.table][r.row] == nil {
+					columns[r.table][r.row] = make(map[string]struct{})
+				}
+				columns[r.table][r.row][resolveColumn(extractor, r)] = struct{}{}
+			}
+		}
+	}
+	returning := make(map[string]map[string][]string, len(columns))
+	for table, rows := range columns {
+		returning[table] = make(map[string][]string, len(rows))
+		for name, set := range rows {
+			for column := range set {
+				returning[table][name] = append(returning[table][name], column)
+			}
+			sort.Strings(returning[table][name])
+		}
+	}
+	return returning
+}
+
+// Validate checks set against the schema without touching the database and reports every problem.
+func (l Loader) Validate(set Set) error {
+	tables := make(map[string]struct{})
+	for _, table := range l.extractor.ListTableNames() {
+		tables[table] = struct{}{}
+	}
+	var errs []error
+	for _, table := range sortedKeys(set) {
+		if _, ok := tables[table]; !ok {
+			errs = append(errs, fmt.Errorf("fixture: unknown table %q", table))
+			continue
+		}
+		columns := l.extractor.GetColumns(table)
+		required := l.required(table)
+		nullable := make(map[string]struct{})
+		for _, column := range l.extractor.GetNullable(table) {
+			nullable[column] = struct{}{}
+		}
+		for _, name := range sortedKeys(set[table]) {
+			row := set[table][name]
+			for _, column := range required {
+				if _, ok := row[column]; !ok {
+					errs = append(errs, fmt.Errorf("fixture: %s.%s: missing NOT NULL column %q", table, name, column))
+				}
+			}
+			for _, column := range sortedKeys(row) {
+				dataType, ok := columns[column]
+				if !ok {
+					errs = append(errs, fmt.Errorf("fixture: %s.%s: unknown column %q", table, name, column))
+					continue
+				}
+				_, isNull := nullable[column]
+				if err := l.validateValue(set, table, column, dataType, isNull, row[column]); err != nil {
+					errs = append(errs, fmt.Errorf("fixture: %s.%s.%s: %w", table, name, column, err))
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// required lists the NOT NULL columns without a default.
+func (l Loader) required(table string) []string {
+	nullable := make(map[string]struct{})
+	for _, column := range l.extractor.GetNullable(table) {
+		nullable[column] = struct{}{}
+	}
+	defaults := l.extractor.GetDefaults(table)
+	required := make([]string, 0)
+	for column := range l.extractor.GetColumns(table) {
+		_, isNull := nullable[column]
+		_, hasDefault := defaults[column]
+		if !isNull && !hasDefault {
+			required = append(required, column)
+		}
+	}
+	sort.Strings(required)
+	return required
+}
+
+func (l Loader) validateValue(set Set, table string, column string, dataType common.GoDataType, nullable bool, value any) error {
+	if r, ok := parseRef(value); ok {
+		if _, ok := set[r.table][r.row]; !ok {
+			return fmt.Errorf("unknown row %s", r)
+		}
+		referenced := resolveColumn(l.extractor, r)
+		if referenced == "" {
+			return fmt.Errorf("%s does not name a column and %s has no single column primary key", r, r.table)
+		}
+		if _, ok := l.extractor.GetColumns(r.table)[referenced]; !ok {
+			return fmt.Errorf("%s reads unknown column %q", r, referenced)
+		}
+		return nil
+	}
+	if value == nil {
+		if !nullable {
+			// an explicit null also overrides the default of the column.
+			return errors.New("null in a NOT NULL column")
+		}
+		return nil
+	}
+	ok := true
+	switch dataType {
+	case common.Int:
+		_, ok = toInt(value)
+	case common.Float64:
+		_, isInt := toInt(value)
+		_, isFloat := value.(float64)
+		ok = isInt || isFloat
+	case common.Bool:
+		_, ok = value.(bool)
+	case common.IntArray, common.StringArray:
+		_, ok = value.([]any)
+	case common.String:
+		switch value.(type) {
+		case string, time.Time:
+		default:
+			databaseType := l.extractor.GetDatabaseTypes(table)[column]
+			ok = databaseType == "json" || databaseType == "jsonb"
+		}
+	}
+	if !ok {
+		return fmt.Errorf("%v (%T) does not fit a %s column", value, value, common.Convert(dataType))
+	}
+	return nil
+}
+
+// order lists the rows so that each follows the tables its foreign keys point at and
+// the rows it references, breaking ties by table and row name.
+func (l Loader) order(set Set) ([]entry, error) {
+	rank := l.rankTables(set)
+	entries := make([]entry, 0)
+	for table, rows := range set {
+		for name, row := range rows {
+			entries = append(entries, entry{table: table, name: name, row: row})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if rank[entries[i].table] != rank[entries[j].table] {
+			return rank[entries[i].table] < rank[entries[j].table]
+		}
+		return entries[i].name < entries[j].name
+	})
+	done := make(map[ref]bool, len(entries))
+	ordered := make([]entry, 0, len(entries))
+	for len(ordered) < len(entries) {
+		progressed := false
+		for _, e := range entries {
+			key := ref{table: e.table, row: e.name}
+			if done[key] || !ready(e.row, done) {
+				continue
+			}
+			done[key] = true
+			ordered = append(ordered, e)
+			progressed = true
+			break
+		}
+		if !progressed {
+			return nil, ErrCycle
+		}
+	}
+	return ordered, nil
+}
+
+func ready(row Row, done map[ref]bool) bool {
+	for _, value := range row {
+		if r, ok := parseRef(value); ok && !done[ref{table: r.table, row: r.row}] {
+			return false
+		}
+	}
+	return true
+}
+
+// rankTables orders the tables of set so parents come before children; tables in a
+// foreign key cycle keep their relative name order.
+func (l Loader) rankTables(set Set) map[string]int {
+	tables := sortedKeys(set)
+	parents := make(map[string]map[string]struct{}, len(tables))
+	for _, table := range tables {
+		parents[table] = make(map[string]struct{})
+		for _, fk := range l.extractor.GetForeignKeys(table) {
+			if _, ok := set[fk.Table]; ok && fk.Table != table {
+				parents[table][fk.Table] = struct{}{}
+			}
+		}
+	}
+	rank := make(map[string]int, len(tables))
+	for len(rank) < len(tables) {
+		next := ""
+		for _, table := range tables {
+			if _, ok := rank[table]; ok {
+				continue
+			}
+			if next == "" {
+				next = table
+			}
+			placed := true
+			for parent := range parents[table] {
+				if _, ok := rank[parent]; !ok {
+					placed = false
+					break
+				}
+			}
+			if placed {
+				next = table
+				break
+			}
+		}
+		rank[next] = len(rank)
+	}
+	return rank
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
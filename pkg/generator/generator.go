@@ -0,0 +1,254 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/naonao2323/testgen/pkg/common"
+	"github.com/naonao2323/testgen/pkg/extractor"
+)
+
+var (
+	ErrEmptyRange = errors.New("generator: the limits of the column leave no value")
+	ErrExhausted  = errors.New("generator: no unused value is left for a unique key")
+)
+
+// retries bounds the attempts at drawing a key not taken yet.
+const retries = 100
+
+var words = []string{
+	"amber", "birch", "cedar", "delta", "ember", "fjord", "grove", "harbor", "iris", "juniper",
+	"kestrel", "lagoon", "maple", "nectar", "orchid", "pebble", "quartz", "raven", "saffron", "tundra",
+}
+
+var epoch = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Generator draws column values that fit their type, length, precision, enum values and
+// simple CHECK bounds. The same seed and sequence of calls give the same values, so a
+// failing test can be replayed from its seed.
+type Generator struct {
+	extractor extractor.Extractor
+	rand      *rand.Rand
+	// seen holds the values taken by each primary and unique key, by table and key name.
+	seen map[string]map[string]map[string]struct{}
+	// seq numbers the values of each table and column; strings end with it to stay unique.
+	seq map[string]int64
+}
+
+func NewGenerator(extractor extractor.Extractor, seed int64) *Generator {
+	return &Generator{
+		extractor: extractor,
+		rand:      rand.New(rand.NewSource(seed)),
+		seen:      make(map[string]map[string]map[string]struct{}),
+		seq:       make(map[string]int64),
+	}
+}
+
+// Value draws one value for the column: int64, float64, bool, string, []int64 or []string.
+// Strings carry the database representation of dates, times, uuids and json.
+func (g *Generator) Value(table string, column string) (any, error) {
+	dataType, ok := g.extractor.GetColumns(table)[column]
+	if !ok {
+		return nil, fmt.Errorf("generator: unknown column %s.%s", table, column)
+	}
+	g.seq[table+"."+column]++
+	n := g.seq[table+"."+column]
+	limit := g.extractor.GetLimits(table)[column]
+	databaseType := g.extractor.GetDatabaseTypes(table)[column]
+	if len(limit.Enum) > 0 {
+		return limit.Enum[g.rand.Intn(len(limit.Enum))], nil
+	}
+	switch dataType {
+	case common.Int:
+		lo, hi, ok := limit.IntRange(databaseType)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s.%s", ErrEmptyRange, table, column)
+		}
+		span := hi - lo + 1
+		if span <= 0 {
+			// the range is wider than an int64 holds.
+			span = math.MaxInt64
+		}
+		return lo + g.rand.Int63n(span), nil
+	case common.Float64:
+		first, last, scale, ok := limit.Steps()
+		if !ok {
+			return nil, fmt.Errorf("%w: %s.%s", ErrEmptyRange, table, column)
+		}
+		return round(float64(first+g.rand.Int63n(last-first+1))*math.Pow10(-scale), scale), nil
+	case common.Bool:
+		return g.rand.Intn(2) == 0, nil
+	case common.IntArray:
+		values := make([]int64, 1+g.rand.Intn(3))
+		for i := range values {
+			values[i] = 1 + g.rand.Int63n(1000)
+		}
+		return values, nil
+	case common.StringArray:
+		values := make([]string, 1+g.rand.Intn(3))
+		for i := range values {
+			values[i] = words[g.rand.Intn(len(words))]
+		}
+		return values, nil
+	}
+	return g.text(databaseType, limit, n), nil
+}
+
+func (g *Generator) text(databaseType string, limit common.Limit, n int64) string {
+	switch databaseType {
+	case "uuid":
+		b := make([]byte, 16)
+		g.rand.Read(b)
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+	case "date":
+		return epoch.AddDate(0, 0, g.rand.Intn(10000)).Format("2006-01-02")
+	case "time":
+		return epoch.Add(time.Duration(g.rand.Intn(86400)) * time.Second).Format("15:04:05")
+	case "timestamp":
+		return epoch.Add(time.Duration(g.rand.Int63n(int64(10000*24*time.Hour/time.Second))) * time.Second).Format(time.RFC3339)
+	case "interval":
+		return fmt.Sprintf("%d hours", 1+g.rand.Intn(1000))
+	case "json", "jsonb":
+		return fmt.Sprintf(`{"name":%q,"n":%d}`, words[g.rand.Intn(len(words))], n)
+	}
+	suffix := "-" + strconv.FormatInt(n, 10)
+	text := words[g.rand.Intn(len(words))] + suffix
+	if limit.Length > 0 && len(text) > limit.Length {
+		// the sequence number keeps texts distinct, so the word gives way first.
+		if keep := limit.Length - len(suffix); keep > 0 {
+			return text[:keep] + suffix
+		}
+		short := strconv.FormatInt(n, 36)
+		return short[max(0, len(short)-limit.Length):]
+	}
+	return text
+}
+
+func round(f float64, scale int) float64 {
+	unit := math.Pow10(scale)
+	return math.Round(f*unit) / unit
+}
+
+// Row draws a row of table, keeping the values of fixed. Columns filled from a sequence
+// are left to the database and foreign keys to the caller, who passes them in fixed.
+// Primary and unique keys are drawn again until they differ from every earlier row.
+func (g *Generator) Row(table string, fixed map[string]any) (map[string]any, error) {
+	row := make(map[string]any, len(fixed))
+	for column, value := range fixed {
+		row[column] = value
+	}
+	skip := make(map[string]struct{})
+	for column, value := range g.extractor.GetDefaults(table) {
+		if strings.HasPrefix(value, "nextval(") {
+			skip[column] = struct{}{}
+		}
+	}
+	for _, fk := range g.extractor.GetForeignKeys(table) {
+		skip[fk.Column] = struct{}{}
+	}
+	columns := sortedKeys(g.extractor.GetColumns(table))
+	for _, column := range columns {
+		if _, ok := row[column]; ok {
+			continue
+		}
+		if _, ok := skip[column]; ok {
+			continue
+		}
+		value, err := g.Value(table, column)
+		if err != nil {
+			return nil, err
+		}
+		row[column] = value
+	}
+	all := g.keys(table)
+	for _, name := range sortedKeys(all) {
+		for attempt := 0; ; attempt++ {
+			tuple, complete := keyOf(row, all[name])
+			if !complete {
+				// the database fills part of the key.
+				break
+			}
+			if _, ok := g.seen[table][name][tuple]; !ok {
+				g.seen[table][name][tuple] = struct{}{}
+				break
+			}
+			redrawn := false
+			for _, column := range all[name] {
+				if _, ok := fixed[column]; ok {
+					continue
+				}
+				value, err := g.Value(table, column)
+				if err != nil {
+					return nil, err
+				}
+				row[column] = value
+				redrawn = true
+			}
+			if !redrawn || attempt == retries {
+				return nil, fmt.Errorf("%w: %s %v", ErrExhausted, table, all[name])
+			}
+		}
+	}
+	return row, nil
+}
+
+// Taken marks the primary and unique keys of row, such as a row already in the database,
+// as used so Row draws other values. Keys row lacks a column of are left out.
+func (g *Generator) Taken(table string, row map[string]any) {
+	for name, columns := range g.keys(table) {
+		if tuple, complete := keyOf(row, columns); complete {
+			g.seen[table][name][tuple] = struct{}{}
+		}
+	}
+}
+
+// keys returns the columns of the primary key, named "", and of the unique keys of table,
+// and makes room for their values in seen.
+func (g *Generator) keys(table string) map[string][]string {
+	keys := g.extractor.GetUniqueKeys(table)
+	all := make(map[string][]string, len(keys)+1)
+	for name, columns := range keys {
+		all[name] = columns
+	}
+	if pk := g.extractor.GetPk(table); len(pk) > 0 {
+		all[""] = pk
+	}
+	if g.seen[table] == nil {
+		g.seen[table] = make(map[string]map[string]struct{})
+	}
+	for name := range all {
+		if g.seen[table][name] == nil {
+			g.seen[table][name] = make(map[string]struct{})
+		}
+	}
+	return all
+}
+
+func keyOf(row map[string]any, columns []string) (string, bool) {
+	parts := make([]string, 0, len(columns))
+	for _, column := range columns {
+		value, ok := row[column]
+		if !ok {
+			return "", false
+		}
+		parts = append(parts, fmt.Sprint(value))
+	}
+	return strings.Join(parts, "\x00"), true
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,278 @@
+package generator
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/naonao2323/testgen/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func bound(v float64) *float64 { return &v }
+
+type fakeExtractor struct{}
+
+var schema = map[string]struct {
+	pk            []string
+	columns       map[string]common.GoDataType
+	databaseTypes map[string]string
+	defaults      map[string]string
+	foreignKeys   []common.ForeignKey
+	uniqueKeys    map[string][]string
+	limits        map[string]common.Limit
+}{
+	"users": {
+		pk: []string{"id"},
+		columns: map[string]common.GoDataType{
+			"id": common.Int, "code": common.String, "price": common.Float64, "mood": common.String,
+			"age": common.Int, "rank": common.Int, "active": common.Bool, "uid": common.String,
+			"born": common.String, "seen_at": common.String, "profile": common.String,
+			"tags": common.StringArray, "scores": common.IntArray,
+		},
+		databaseTypes: map[string]string{
+			"id": "integer", "code": "varchar", "price": "numeric", "mood": "enum", "age": "integer",
+			"rank": "smallint", "uid": "uuid", "born": "date", "seen_at": "timestamp", "profile": "jsonb",
+		},
+		defaults:   map[string]string{"id": "nextval('users_id_seq'::regclass)"},
+		uniqueKeys: map[string][]string{"users_code_key": {"code"}},
+		limits: map[string]common.Limit{
+			"code":  {Length: 6},
+			"price": {Precision: 4, Scale: 2, Min: bound(0.01)},
+			"mood":  {Enum: []string{"sad", "ok", "happy"}},
+			"age":   {Min: bound(18), Max: bound(20)},
+		},
+	},
+	"memos": {
+		pk:          []string{"user_id", "kind"},
+		columns:     map[string]common.GoDataType{"user_id": common.Int, "kind": common.String},
+		foreignKeys: []common.ForeignKey{{Column: "user_id", Table: "users"}},
+		limits:      map[string]common.Limit{"kind": {Enum: []string{"a", "b"}}},
+	},
+	"empty": {
+		columns: map[string]common.GoDataType{"n": common.Int},
+		limits:  map[string]common.Limit{"n": {Min: bound(5), Max: bound(4)}},
+	},
+}
+
+func (fakeExtractor) GetPk(table string) []string { return schema[table].pk }
+
+func (fakeExtractor) GetNullable(table string) []string { return nil }
+
+func (fakeExtractor) GetDefaults(table string) map[string]string { return schema[table].defaults }
+
+func (fakeExtractor) GetColumns(table string) map[string]common.GoDataType {
+	return schema[table].columns
+}
+
+func (fakeExtractor) GetDatabaseTypes(table string) map[string]string {
+	return schema[table].databaseTypes
+}
+
+func (fakeExtractor) GetForeignKeys(table string) []common.ForeignKey {
+	return schema[table].foreignKeys
+}
+
+func (fakeExtractor) GetUniqueKeys(table string) map[string][]string {
+	return schema[table].uniqueKeys
+}
+
+func (fakeExtractor) GetConstraints(table string) map[string][]string { return nil }
+
+func (fakeExtractor) GetLimits(table string) map[string]common.Limit { return schema[table].limits }
+
+func (fakeExtractor) ListTableNames() []string { return []string{"empty", "memos", "users"} }
+
+func (fakeExtractor) ListReservedWord() []string { return nil }
+
+func TestGeneratorValue(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		column string
+		check  func(t *testing.T, value any)
+	}{
+		{
+			name:   "length",
+			column: "code",
+			check: func(t *testing.T, value any) {
+				assert.LessOrEqual(t, len(value.(string)), 6)
+			},
+		},
+		{
+			name:   "precision and check",
+			column: "price",
+			check: func(t *testing.T, value any) {
+				f := value.(float64)
+				assert.GreaterOrEqual(t, f, 0.01)
+				assert.LessOrEqual(t, f, 99.99)
+				assert.InDelta(t, f, float64(int64(f*100+0.5))/100, 1e-9)
+			},
+		},
+		{
+			name:   "enum",
+			column: "mood",
+			check: func(t *testing.T, value any) {
+				assert.Contains(t, []string{"sad", "ok", "happy"}, value)
+			},
+		},
+		{
+			name:   "check bounds",
+			column: "age",
+			check: func(t *testing.T, value any) {
+				assert.GreaterOrEqual(t, value.(int64), int64(18))
+				assert.LessOrEqual(t, value.(int64), int64(20))
+			},
+		},
+		{
+			name:   "smallint",
+			column: "rank",
+			check: func(t *testing.T, value any) {
+				assert.LessOrEqual(t, value.(int64), int64(32767))
+			},
+		},
+		{
+			name:   "uuid",
+			column: "uid",
+			check: func(t *testing.T, value any) {
+				assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), value)
+			},
+		},
+		{
+			name:   "date",
+			column: "born",
+			check: func(t *testing.T, value any) {
+				_, err := time.Parse("2006-01-02", value.(string))
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name:   "timestamp",
+			column: "seen_at",
+			check: func(t *testing.T, value any) {
+				_, err := time.Parse(time.RFC3339, value.(string))
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name:   "jsonb",
+			column: "profile",
+			check: func(t *testing.T, value any) {
+				assert.True(t, json.Valid([]byte(value.(string))))
+			},
+		},
+		{
+			name:   "arrays",
+			column: "tags",
+			check: func(t *testing.T, value any) {
+				assert.NotEmpty(t, value.([]string))
+			},
+		},
+	}
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewGenerator(fakeExtractor{}, 1)
+			for i := 0; i < 200; i++ {
+				value, err := g.Value("users", test.column)
+				require.NoError(t, err)
+				test.check(t, value)
+			}
+		})
+	}
+}
+
+func TestGeneratorDeterministic(t *testing.T) {
+	t.Parallel()
+	rows := func(seed int64) []map[string]any {
+		g := NewGenerator(fakeExtractor{}, seed)
+		resp := make([]map[string]any, 0, 5)
+		for i := 0; i < 5; i++ {
+			row, err := g.Row("users", nil)
+			require.NoError(t, err)
+			resp = append(resp, row)
+		}
+		return resp
+	}
+	assert.Equal(t, rows(42), rows(42))
+	assert.NotEqual(t, rows(42), rows(43))
+}
+
+func TestGeneratorRow(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		table string
+		fixed []map[string]any
+		err   error
+	}{
+		{
+			name:  "fixed values are kept and keys differ",
+			table: "memos",
+			fixed: []map[string]any{{"user_id": int64(1)}, {"user_id": int64(1)}},
+		},
+		{
+			name:  "keys run out",
+			table: "memos",
+			fixed: []map[string]any{{"user_id": int64(1)}, {"user_id": int64(1)}, {"user_id": int64(1)}},
+			err:   ErrExhausted,
+		},
+		{
+			name:  "limits leave no value",
+			table: "empty",
+			fixed: []map[string]any{nil},
+			err:   ErrEmptyRange,
+		},
+	}
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewGenerator(fakeExtractor{}, 7)
+			kinds := make(map[any]struct{})
+			var err error
+			for i := range test.fixed {
+				var row map[string]any
+				row, err = g.Row(test.table, test.fixed[i])
+				if err != nil {
+					break
+				}
+				assert.Equal(t, test.fixed[i]["user_id"], row["user_id"])
+				kinds[row["kind"]] = struct{}{}
+			}
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, kinds, len(test.fixed))
+		})
+	}
+}
+
+func TestGeneratorTaken(t *testing.T) {
+	t.Parallel()
+	g := NewGenerator(fakeExtractor{}, 7)
+	g.Taken("memos", map[string]any{"user_id": int64(1), "kind": "a"})
+	g.Taken("memos", map[string]any{"kind": "b"})
+	row, err := g.Row("memos", map[string]any{"user_id": int64(1)})
+	require.NoError(t, err)
+	assert.Equal(t, "b", row["kind"])
+	_, err = g.Row("memos", map[string]any{"user_id": int64(1)})
+	require.ErrorIs(t, err, ErrExhausted)
+}
+
+func TestGeneratorRowSkipsSequencesAndForeignKeys(t *testing.T) {
+	t.Parallel()
+	g := NewGenerator(fakeExtractor{}, 1)
+	user, err := g.Row("users", nil)
+	require.NoError(t, err)
+	assert.NotContains(t, user, "id")
+	assert.Contains(t, user, "code")
+	memo, err := g.Row("memos", nil)
+	require.NoError(t, err)
+	assert.NotContains(t, memo, "user_id")
+}
@@ -28,6 +28,34 @@ func (f fakeExtractor) GetPk(table string) []string {
 	return f.getPk
 }
 
+func (f fakeExtractor) GetNullable(table string) []string {
+	return nil
+}
+
+func (f fakeExtractor) GetDefaults(table string) map[string]string {
+	return nil
+}
+
+func (f fakeExtractor) GetForeignKeys(table string) []common.ForeignKey {
+	return nil
+}
+
+func (f fakeExtractor) GetUniqueKeys(table string) map[string][]string {
+	return nil
+}
+
+func (f fakeExtractor) GetConstraints(table string) map[string][]string {
+	return nil
+}
+
+func (f fakeExtractor) GetDatabaseTypes(table string) map[string]string {
+	return nil
+}
+
+func (f fakeExtractor) GetLimits(table string) map[string]common.Limit {
+	return nil
+}
+
 func (f fakeExtractor) GetColumns(table string) map[string]common.GoDataType {
 	return f.columns
 }
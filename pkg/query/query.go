@@ -0,0 +1,441 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type Dialect int
+
+const (
+	Postgres Dialect = iota
+	Mysql
+	Sqlite
+)
+
+type Clause int
+
+const (
+	Values Clause = iota
+	Set
+	Where
+	// Check binds the value a row is expected to still hold, e.g. a version.
+	Check
+)
+
+var (
+	ErrNoTable     = errors.New("query: undefined table")
+	ErrNoColumns   = errors.New("query: undefined columns")
+	ErrNoCondition = errors.New("query: undefined condition")
+	ErrReturning   = errors.New("query: returning is not supported by the dialect")
+)
+
+// Arg is a bound parameter of a built query in placeholder order.
+type Arg struct {
+	Column string
+	Clause Clause
+}
+
+type Query struct {
+	SQL  string
+	Args []Arg
+}
+
+type Statement interface {
+	build(b *builder) error
+}
+
+type (
+	Insert struct {
+		Table   string
+		Columns []string
+		// Rows is the number of value lists; zero means a single row.
+		Rows       int
+		OnConflict *Conflict
+		// Returning columns are read back from the inserted rows.
+		Returning []string
+	}
+	// Conflict overwrites Update from the rejected row when Columns collide,
+	// or leaves the existing row untouched when Update is empty.
+	Conflict struct {
+		Columns []string
+		Update  []string
+	}
+	Update struct {
+		Table string
+		Set   []string
+		// Touch columns are set to CURRENT_TIMESTAMP and Clear columns to NULL.
+		Touch   []string
+		Clear   []string
+		Where   []string
+		IsNull  []string
+		NotNull []string
+		Version *Version
+	}
+	// Version is a concurrency token the update requires unchanged and then advances.
+	Version struct {
+		Column string
+		// Timestamp advances to the current time instead of incrementing; on Postgres that is
+		// clock_timestamp(), as CURRENT_TIMESTAMP stays put within a transaction.
+		Timestamp bool
+	}
+	Delete struct {
+		Table   string
+		Where   []string
+		IsNull  []string
+		NotNull []string
+	}
+	Select struct {
+		Table   string
+		Columns []string
+		Where   []string
+		IsNull  []string
+		NotNull []string
+	}
+	Count struct {
+		Table   string
+		Where   []string
+		IsNull  []string
+		NotNull []string
+	}
+	Exists struct {
+		Table   string
+		Where   []string
+		IsNull  []string
+		NotNull []string
+	}
+	// CreateTable defines a table with its keys; foreign keys are added apart with
+	// AddForeignKey so tables may reference each other.
+	CreateTable struct {
+		Table      string
+		Columns    []ColumnDef
+		PrimaryKey []string
+		Unique     [][]string
+		// Checks are conditions written in the dialect, such as "age >= 0".
+		Checks []string
+	}
+	// ColumnDef is a column of CreateTable; Default is an expression and empty for none.
+	ColumnDef struct {
+		Name    string
+		Type    string
+		NotNull bool
+		Default string
+	}
+	AddForeignKey struct {
+		Table      string
+		Columns    []string
+		References string
+		RefColumns []string
+	}
+)
+
+type Builder struct {
+	dialect  Dialect
+	reserved map[string]struct{}
+	named    bool
+}
+
+func NewBuilder(dialect Dialect, reserved map[string]struct{}) Builder {
+	return Builder{
+		dialect:  dialect,
+		reserved: reserved,
+	}
+}
+
+// Named binds parameters by column name (:column) as sqlx named queries expect.
+func (b Builder) Named() Builder {
+	b.named = true
+	return b
+}
+
+func (b Builder) Build(stmt Statement) (Query, error) {
+	builder := &builder{Builder: b}
+	if err := stmt.build(builder); err != nil {
+		return Query{}, err
+	}
+	return Query{
+		SQL:  builder.sql.String(),
+		Args: builder.args,
+	}, nil
+}
+
+type builder struct {
+	Builder
+	sql  strings.Builder
+	args []Arg
+}
+
+func (b *builder) write(s ...string) {
+	for i := range s {
+		b.sql.WriteString(s[i])
+	}
+}
+
+// Ident quotes name when it is a reserved word.
+func (b Builder) Ident(name string) string {
+	if _, ok := b.reserved[name]; !ok {
+		return name
+	}
+	switch b.dialect {
+	case Mysql:
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	default:
+		return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+	}
+}
+
+func (b *builder) bind(column string, clause Clause) string {
+	b.args = append(b.args, Arg{Column: column, Clause: clause})
+	if b.named {
+		return ":" + column
+	}
+	switch b.dialect {
+	case Postgres:
+		return fmt.Sprintf("$%d", len(b.args))
+	default:
+		return "?"
+	}
+}
+
+func (b *builder) list(columns []string, each func(column string) string) {
+	for i := range columns {
+		if i > 0 {
+			b.write(", ")
+		}
+		b.write(each(columns[i]))
+	}
+}
+
+// where writes the conditions, if any; isNull and notNull follow the bound columns.
+func (b *builder) where(columns, isNull, notNull []string) {
+	conditions := make([]string, 0, len(columns)+len(isNull)+len(notNull))
+	for i := range columns {
+		conditions = append(conditions, b.Ident(columns[i])+" = "+b.bind(columns[i], Where))
+	}
+	for i := range isNull {
+		conditions = append(conditions, b.Ident(isNull[i])+" IS NULL")
+	}
+	for i := range notNull {
+		conditions = append(conditions, b.Ident(notNull[i])+" IS NOT NULL")
+	}
+	if len(conditions) == 0 {
+		return
+	}
+	b.write(" WHERE ", strings.Join(conditions, " AND "))
+}
+
+func (s Insert) build(b *builder) error {
+	if s.Table == "" {
+		return ErrNoTable
+	}
+	if len(s.Returning) > 0 && b.dialect == Mysql {
+		return ErrReturning
+	}
+	b.write("INSERT INTO ", b.Ident(s.Table))
+	if err := s.values(b); err != nil {
+		return err
+	}
+	if len(s.Returning) > 0 {
+		b.write(" RETURNING ")
+		b.list(s.Returning, b.Ident)
+	}
+	return nil
+}
+
+func (s Insert) values(b *builder) error {
+	if len(s.Columns) == 0 {
+		if b.dialect == Mysql {
+			b.write(" () VALUES ()")
+		} else {
+			b.write(" DEFAULT VALUES")
+		}
+		return nil
+	}
+	b.write(" (")
+	b.list(s.Columns, b.Ident)
+	b.write(") VALUES ")
+	for i := 0; i < max(s.Rows, 1); i++ {
+		if i > 0 {
+			b.write(", ")
+		}
+		b.write("(")
+		b.list(s.Columns, func(column string) string {
+			return b.bind(column, Values)
+		})
+		b.write(")")
+	}
+	if s.OnConflict != nil {
+		return s.OnConflict.build(b)
+	}
+	return nil
+}
+
+func (s Conflict) build(b *builder) error {
+	if len(s.Columns) == 0 {
+		return ErrNoCondition
+	}
+	if b.dialect == Mysql {
+		b.write(" ON DUPLICATE KEY UPDATE ")
+		if len(s.Update) == 0 {
+			b.write(b.Ident(s.Columns[0]), " = ", b.Ident(s.Columns[0]))
+			return nil
+		}
+		b.list(s.Update, func(column string) string {
+			return fmt.Sprintf("%s = VALUES(%s)", b.Ident(column), b.Ident(column))
+		})
+		return nil
+	}
+	b.write(" ON CONFLICT (")
+	b.list(s.Columns, b.Ident)
+	b.write(")")
+	if len(s.Update) == 0 {
+		b.write(" DO NOTHING")
+		return nil
+	}
+	b.write(" DO UPDATE SET ")
+	b.list(s.Update, func(column string) string {
+		return fmt.Sprintf("%s = EXCLUDED.%s", b.Ident(column), b.Ident(column))
+	})
+	return nil
+}
+
+func (s Update) build(b *builder) error {
+	if s.Table == "" {
+		return ErrNoTable
+	}
+	if len(s.Set)+len(s.Touch)+len(s.Clear) == 0 && s.Version == nil {
+		return ErrNoColumns
+	}
+	if len(s.Where) == 0 {
+		return ErrNoCondition
+	}
+	assignments := make([]string, 0, len(s.Set)+len(s.Touch)+len(s.Clear)+1)
+	for _, column := range s.Set {
+		assignments = append(assignments, fmt.Sprintf("%s = %s", b.Ident(column), b.bind(column, Set)))
+	}
+	for _, column := range s.Touch {
+		assignments = append(assignments, b.Ident(column)+" = CURRENT_TIMESTAMP")
+	}
+	for _, column := range s.Clear {
+		assignments = append(assignments, b.Ident(column)+" = NULL")
+	}
+	if s.Version != nil {
+		version := b.Ident(s.Version.Column)
+		switch {
+		case s.Version.Timestamp && b.dialect == Postgres:
+			assignments = append(assignments, version+" = clock_timestamp()")
+		case s.Version.Timestamp:
+			assignments = append(assignments, version+" = CURRENT_TIMESTAMP")
+		default:
+			assignments = append(assignments, version+" = "+version+" + 1")
+		}
+	}
+	b.write("UPDATE ", b.Ident(s.Table), " SET ", strings.Join(assignments, ", "))
+	b.where(s.Where, s.IsNull, s.NotNull)
+	if s.Version != nil {
+		b.write(" AND ", b.Ident(s.Version.Column), " = ", b.bind(s.Version.Column, Check))
+	}
+	return nil
+}
+
+func (s Delete) build(b *builder) error {
+	if s.Table == "" {
+		return ErrNoTable
+	}
+	if len(s.Where) == 0 {
+		return ErrNoCondition
+	}
+	b.write("DELETE FROM ", b.Ident(s.Table))
+	b.where(s.Where, s.IsNull, s.NotNull)
+	return nil
+}
+
+func (s Select) build(b *builder) error {
+	if s.Table == "" {
+		return ErrNoTable
+	}
+	if len(s.Columns) == 0 {
+		return ErrNoColumns
+	}
+	b.write("SELECT ")
+	b.list(s.Columns, b.Ident)
+	b.write(" FROM ", b.Ident(s.Table))
+	b.where(s.Where, s.IsNull, s.NotNull)
+	return nil
+}
+
+func (s Count) build(b *builder) error {
+	if s.Table == "" {
+		return ErrNoTable
+	}
+	b.write("SELECT COUNT(*) FROM ", b.Ident(s.Table))
+	b.where(s.Where, s.IsNull, s.NotNull)
+	return nil
+}
+
+func (s Exists) build(b *builder) error {
+	if s.Table == "" {
+		return ErrNoTable
+	}
+	if len(s.Where) == 0 {
+		return ErrNoCondition
+	}
+	b.write("SELECT EXISTS (SELECT 1 FROM ", b.Ident(s.Table))
+	b.where(s.Where, s.IsNull, s.NotNull)
+	b.write(")")
+	return nil
+}
+
+func (s CreateTable) build(b *builder) error {
+	if s.Table == "" {
+		return ErrNoTable
+	}
+	if len(s.Columns) == 0 {
+		return ErrNoColumns
+	}
+	definitions := make([]string, 0, len(s.Columns)+len(s.Unique)+len(s.Checks)+1)
+	for _, column := range s.Columns {
+		definition := b.Ident(column.Name) + " " + column.Type
+		if column.NotNull {
+			definition += " NOT NULL"
+		}
+		if column.Default != "" {
+			definition += " DEFAULT " + column.Default
+		}
+		definitions = append(definitions, definition)
+	}
+	idents := func(columns []string) string {
+		quoted := make([]string, 0, len(columns))
+		for i := range columns {
+			quoted = append(quoted, b.Ident(columns[i]))
+		}
+		return strings.Join(quoted, ", ")
+	}
+	if len(s.PrimaryKey) > 0 {
+		definitions = append(definitions, "PRIMARY KEY ("+idents(s.PrimaryKey)+")")
+	}
+	for _, unique := range s.Unique {
+		definitions = append(definitions, "UNIQUE ("+idents(unique)+")")
+	}
+	for _, check := range s.Checks {
+		definitions = append(definitions, "CHECK ("+check+")")
+	}
+	b.write("CREATE TABLE ", b.Ident(s.Table), " (", strings.Join(definitions, ", "), ")")
+	return nil
+}
+
+func (s AddForeignKey) build(b *builder) error {
+	if s.Table == "" || s.References == "" {
+		return ErrNoTable
+	}
+	if len(s.Columns) == 0 || len(s.Columns) != len(s.RefColumns) {
+		return ErrNoColumns
+	}
+	b.write("ALTER TABLE ", b.Ident(s.Table), " ADD FOREIGN KEY (")
+	b.list(s.Columns, b.Ident)
+	b.write(") REFERENCES ", b.Ident(s.References), " (")
+	b.list(s.RefColumns, b.Ident)
+	b.write(")")
+	return nil
+}
@@ -0,0 +1,863 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildInsert(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		dialect  Dialect
+		reserved map[string]struct{}
+		stmt     Insert
+		expected Query
+		err      error
+	}{
+		{
+			name:    "table is empty",
+			dialect: Postgres,
+			stmt:    Insert{Columns: []string{"id"}},
+			err:     ErrNoTable,
+		},
+		{
+			name:     "columns are empty on postgres",
+			dialect:  Postgres,
+			stmt:     Insert{Table: "users"},
+			expected: Query{SQL: "INSERT INTO users DEFAULT VALUES"},
+		},
+		{
+			name:     "columns are empty on sqlite",
+			dialect:  Sqlite,
+			stmt:     Insert{Table: "users"},
+			expected: Query{SQL: "INSERT INTO users DEFAULT VALUES"},
+		},
+		{
+			name:     "columns are empty on mysql",
+			dialect:  Mysql,
+			stmt:     Insert{Table: "users"},
+			expected: Query{SQL: "INSERT INTO users () VALUES ()"},
+		},
+		{
+			name:    "postgres",
+			dialect: Postgres,
+			stmt:    Insert{Table: "users", Columns: []string{"id", "name"}},
+			expected: Query{
+				SQL:  "INSERT INTO users (id, name) VALUES ($1, $2)",
+				Args: []Arg{{Column: "id", Clause: Values}, {Column: "name", Clause: Values}},
+			},
+		},
+		{
+			name:    "mysql",
+			dialect: Mysql,
+			stmt:    Insert{Table: "users", Columns: []string{"id", "name"}},
+			expected: Query{
+				SQL:  "INSERT INTO users (id, name) VALUES (?, ?)",
+				Args: []Arg{{Column: "id", Clause: Values}, {Column: "name", Clause: Values}},
+			},
+		},
+		{
+			name:    "sqlite",
+			dialect: Sqlite,
+			stmt:    Insert{Table: "users", Columns: []string{"id", "name"}},
+			expected: Query{
+				SQL:  "INSERT INTO users (id, name) VALUES (?, ?)",
+				Args: []Arg{{Column: "id", Clause: Values}, {Column: "name", Clause: Values}},
+			},
+		},
+		{
+			name:     "reserved on postgres",
+			dialect:  Postgres,
+			reserved: map[string]struct{}{"user": {}, "order": {}},
+			stmt:     Insert{Table: "user", Columns: []string{"id", "order"}},
+			expected: Query{
+				SQL:  `INSERT INTO "user" (id, "order") VALUES ($1, $2)`,
+				Args: []Arg{{Column: "id", Clause: Values}, {Column: "order", Clause: Values}},
+			},
+		},
+		{
+			name:    "multiple rows on postgres",
+			dialect: Postgres,
+			stmt:    Insert{Table: "users", Columns: []string{"id", "name"}, Rows: 2},
+			expected: Query{
+				SQL: "INSERT INTO users (id, name) VALUES ($1, $2), ($3, $4)",
+				Args: []Arg{
+					{Column: "id", Clause: Values},
+					{Column: "name", Clause: Values},
+					{Column: "id", Clause: Values},
+					{Column: "name", Clause: Values},
+				},
+			},
+		},
+		{
+			name:    "multiple rows on mysql",
+			dialect: Mysql,
+			stmt:    Insert{Table: "users", Columns: []string{"id"}, Rows: 3},
+			expected: Query{
+				SQL: "INSERT INTO users (id) VALUES (?), (?), (?)",
+				Args: []Arg{
+					{Column: "id", Clause: Values},
+					{Column: "id", Clause: Values},
+					{Column: "id", Clause: Values},
+				},
+			},
+		},
+		{
+			name:     "reserved on mysql",
+			dialect:  Mysql,
+			reserved: map[string]struct{}{"order": {}},
+			stmt:     Insert{Table: "users", Columns: []string{"id", "order"}},
+			expected: Query{
+				SQL:  "INSERT INTO users (id, `order`) VALUES (?, ?)",
+				Args: []Arg{{Column: "id", Clause: Values}, {Column: "order", Clause: Values}},
+			},
+		},
+		{
+			name:     "returning on postgres",
+			dialect:  Postgres,
+			reserved: map[string]struct{}{"order": {}},
+			stmt:     Insert{Table: "users", Columns: []string{"name"}, Returning: []string{"id", "order"}},
+			expected: Query{
+				SQL:  `INSERT INTO users (name) VALUES ($1) RETURNING id, "order"`,
+				Args: []Arg{{Column: "name", Clause: Values}},
+			},
+		},
+		{
+			name:     "returning with default values",
+			dialect:  Postgres,
+			stmt:     Insert{Table: "users", Returning: []string{"id"}},
+			expected: Query{SQL: "INSERT INTO users DEFAULT VALUES RETURNING id"},
+		},
+		{
+			name:    "returning on mysql",
+			dialect: Mysql,
+			stmt:    Insert{Table: "users", Columns: []string{"name"}, Returning: []string{"id"}},
+			err:     ErrReturning,
+		},
+	}
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewBuilder(test.dialect, test.reserved).Build(test.stmt)
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestBuildUpdate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		dialect  Dialect
+		reserved map[string]struct{}
+		stmt     Update
+		expected Query
+		err      error
+	}{
+		{
+			name:    "table is empty",
+			dialect: Postgres,
+			stmt:    Update{Set: []string{"name"}, Where: []string{"id"}},
+			err:     ErrNoTable,
+		},
+		{
+			name:    "set is empty",
+			dialect: Postgres,
+			stmt:    Update{Table: "users", Where: []string{"id"}},
+			err:     ErrNoColumns,
+		},
+		{
+			name:    "where is empty",
+			dialect: Postgres,
+			stmt:    Update{Table: "users", Set: []string{"name"}},
+			err:     ErrNoCondition,
+		},
+		{
+			name:    "postgres with composite key",
+			dialect: Postgres,
+			stmt:    Update{Table: "members", Set: []string{"name", "age"}, Where: []string{"group_id", "user_id"}},
+			expected: Query{
+				SQL: "UPDATE members SET name = $1, age = $2 WHERE group_id = $3 AND user_id = $4",
+				Args: []Arg{
+					{Column: "name", Clause: Set},
+					{Column: "age", Clause: Set},
+					{Column: "group_id", Clause: Where},
+					{Column: "user_id", Clause: Where},
+				},
+			},
+		},
+		{
+			name:    "mysql with composite key",
+			dialect: Mysql,
+			stmt:    Update{Table: "members", Set: []string{"name"}, Where: []string{"group_id", "user_id"}},
+			expected: Query{
+				SQL: "UPDATE members SET name = ? WHERE group_id = ? AND user_id = ?",
+				Args: []Arg{
+					{Column: "name", Clause: Set},
+					{Column: "group_id", Clause: Where},
+					{Column: "user_id", Clause: Where},
+				},
+			},
+		},
+		{
+			name:     "reserved on postgres",
+			dialect:  Postgres,
+			reserved: map[string]struct{}{"desc": {}},
+			stmt:     Update{Table: "memos", Set: []string{"desc"}, Where: []string{"id"}},
+			expected: Query{
+				SQL:  `UPDATE memos SET "desc" = $1 WHERE id = $2`,
+				Args: []Arg{{Column: "desc", Clause: Set}, {Column: "id", Clause: Where}},
+			},
+		},
+		{
+			name:     "reserved on sqlite",
+			dialect:  Sqlite,
+			reserved: map[string]struct{}{"desc": {}},
+			stmt:     Update{Table: "memos", Set: []string{"desc"}, Where: []string{"id"}},
+			expected: Query{
+				SQL:  `UPDATE memos SET "desc" = ? WHERE id = ?`,
+				Args: []Arg{{Column: "desc", Clause: Set}, {Column: "id", Clause: Where}},
+			},
+		},
+		{
+			name:    "version on postgres",
+			dialect: Postgres,
+			stmt:    Update{Table: "memos", Set: []string{"body"}, Where: []string{"id"}, Version: &Version{Column: "version"}},
+			expected: Query{
+				SQL: "UPDATE memos SET body = $1, version = version + 1 WHERE id = $2 AND version = $3",
+				Args: []Arg{
+					{Column: "body", Clause: Set},
+					{Column: "id", Clause: Where},
+					{Column: "version", Clause: Check},
+				},
+			},
+		},
+		{
+			name:     "timestamp version on mysql",
+			dialect:  Mysql,
+			reserved: map[string]struct{}{"timestamp": {}},
+			stmt:     Update{Table: "memos", Set: []string{"body"}, Where: []string{"id"}, Version: &Version{Column: "timestamp", Timestamp: true}},
+			expected: Query{
+				SQL: "UPDATE memos SET body = ?, `timestamp` = CURRENT_TIMESTAMP WHERE id = ? AND `timestamp` = ?",
+				Args: []Arg{
+					{Column: "body", Clause: Set},
+					{Column: "id", Clause: Where},
+					{Column: "timestamp", Clause: Check},
+				},
+			},
+		},
+		{
+			name:    "timestamp version on postgres",
+			dialect: Postgres,
+			stmt:    Update{Table: "memos", Set: []string{"body"}, Where: []string{"id"}, Version: &Version{Column: "updated_at", Timestamp: true}},
+			expected: Query{
+				SQL: "UPDATE memos SET body = $1, updated_at = clock_timestamp() WHERE id = $2 AND updated_at = $3",
+				Args: []Arg{
+					{Column: "body", Clause: Set},
+					{Column: "id", Clause: Where},
+					{Column: "updated_at", Clause: Check},
+				},
+			},
+		},
+		{
+			name:    "only the version",
+			dialect: Postgres,
+			stmt:    Update{Table: "memos", Where: []string{"id"}, Version: &Version{Column: "version"}},
+			expected: Query{
+				SQL: "UPDATE memos SET version = version + 1 WHERE id = $1 AND version = $2",
+				Args: []Arg{
+					{Column: "id", Clause: Where},
+					{Column: "version", Clause: Check},
+				},
+			},
+		},
+		{
+			name:    "soft delete with version",
+			dialect: Postgres,
+			stmt:    Update{Table: "memos", Touch: []string{"deleted_at"}, Where: []string{"id"}, IsNull: []string{"deleted_at"}, Version: &Version{Column: "version"}},
+			expected: Query{
+				SQL: "UPDATE memos SET deleted_at = CURRENT_TIMESTAMP, version = version + 1 WHERE id = $1 AND deleted_at IS NULL AND version = $2",
+				Args: []Arg{
+					{Column: "id", Clause: Where},
+					{Column: "version", Clause: Check},
+				},
+			},
+		},
+		{
+			name:    "restore",
+			dialect: Postgres,
+			stmt:    Update{Table: "memos", Clear: []string{"deleted_at"}, Where: []string{"id"}, NotNull: []string{"deleted_at"}},
+			expected: Query{
+				SQL:  "UPDATE memos SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL",
+				Args: []Arg{{Column: "id", Clause: Where}},
+			},
+		},
+	}
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewBuilder(test.dialect, test.reserved).Build(test.stmt)
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestBuildDelete(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		dialect  Dialect
+		reserved map[string]struct{}
+		stmt     Delete
+		expected Query
+		err      error
+	}{
+		{
+			name:    "table is empty",
+			dialect: Postgres,
+			stmt:    Delete{Where: []string{"id"}},
+			err:     ErrNoTable,
+		},
+		{
+			name:    "where is empty",
+			dialect: Postgres,
+			stmt:    Delete{Table: "users"},
+			err:     ErrNoCondition,
+		},
+		{
+			name:    "postgres with composite key",
+			dialect: Postgres,
+			stmt:    Delete{Table: "members", Where: []string{"group_id", "user_id"}},
+			expected: Query{
+				SQL:  "DELETE FROM members WHERE group_id = $1 AND user_id = $2",
+				Args: []Arg{{Column: "group_id", Clause: Where}, {Column: "user_id", Clause: Where}},
+			},
+		},
+		{
+			name:    "mysql with composite key",
+			dialect: Mysql,
+			stmt:    Delete{Table: "members", Where: []string{"group_id", "user_id"}},
+			expected: Query{
+				SQL:  "DELETE FROM members WHERE group_id = ? AND user_id = ?",
+				Args: []Arg{{Column: "group_id", Clause: Where}, {Column: "user_id", Clause: Where}},
+			},
+		},
+		{
+			name:     "reserved on mysql",
+			dialect:  Mysql,
+			reserved: map[string]struct{}{"key": {}},
+			stmt:     Delete{Table: "settings", Where: []string{"key"}},
+			expected: Query{
+				SQL:  "DELETE FROM settings WHERE `key` = ?",
+				Args: []Arg{{Column: "key", Clause: Where}},
+			},
+		},
+		{
+			name:    "purge only deleted rows",
+			dialect: Postgres,
+			stmt:    Delete{Table: "memos", Where: []string{"id"}, NotNull: []string{"deleted_at"}},
+			expected: Query{
+				SQL:  "DELETE FROM memos WHERE id = $1 AND deleted_at IS NOT NULL",
+				Args: []Arg{{Column: "id", Clause: Where}},
+			},
+		},
+	}
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewBuilder(test.dialect, test.reserved).Build(test.stmt)
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestBuildSelect(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		dialect  Dialect
+		reserved map[string]struct{}
+		stmt     Select
+		expected Query
+		err      error
+	}{
+		{
+			name:    "table is empty",
+			dialect: Postgres,
+			stmt:    Select{Columns: []string{"id"}},
+			err:     ErrNoTable,
+		},
+		{
+			name:    "columns are empty",
+			dialect: Postgres,
+			stmt:    Select{Table: "users"},
+			err:     ErrNoColumns,
+		},
+		{
+			name:     "without where",
+			dialect:  Postgres,
+			stmt:     Select{Table: "users", Columns: []string{"id", "name"}},
+			expected: Query{SQL: "SELECT id, name FROM users"},
+		},
+		{
+			name:    "postgres with composite key",
+			dialect: Postgres,
+			stmt:    Select{Table: "members", Columns: []string{"group_id", "user_id", "name"}, Where: []string{"group_id", "user_id"}},
+			expected: Query{
+				SQL:  "SELECT group_id, user_id, name FROM members WHERE group_id = $1 AND user_id = $2",
+				Args: []Arg{{Column: "group_id", Clause: Where}, {Column: "user_id", Clause: Where}},
+			},
+		},
+		{
+			name:    "sqlite with composite key",
+			dialect: Sqlite,
+			stmt:    Select{Table: "members", Columns: []string{"group_id", "user_id", "name"}, Where: []string{"group_id", "user_id"}},
+			expected: Query{
+				SQL:  "SELECT group_id, user_id, name FROM members WHERE group_id = ? AND user_id = ?",
+				Args: []Arg{{Column: "group_id", Clause: Where}, {Column: "user_id", Clause: Where}},
+			},
+		},
+		{
+			name:     "reserved on postgres",
+			dialect:  Postgres,
+			reserved: map[string]struct{}{"user": {}, "select": {}},
+			stmt:     Select{Table: "user", Columns: []string{"id", "select"}, Where: []string{"select"}},
+			expected: Query{
+				SQL:  `SELECT id, "select" FROM "user" WHERE "select" = $1`,
+				Args: []Arg{{Column: "select", Clause: Where}},
+			},
+		},
+		{
+			name:     "quote in identifier",
+			dialect:  Postgres,
+			reserved: map[string]struct{}{`a"b`: {}},
+			stmt:     Select{Table: "users", Columns: []string{`a"b`}},
+			expected: Query{SQL: `SELECT "a""b" FROM users`},
+		},
+		{
+			name:     "only null condition",
+			dialect:  Postgres,
+			reserved: map[string]struct{}{"deleted": {}},
+			stmt:     Select{Table: "users", Columns: []string{"id"}, IsNull: []string{"deleted"}},
+			expected: Query{SQL: `SELECT id FROM users WHERE "deleted" IS NULL`},
+		},
+		{
+			name:    "key with null condition",
+			dialect: Mysql,
+			stmt:    Select{Table: "users", Columns: []string{"id"}, Where: []string{"id"}, IsNull: []string{"deleted_at"}},
+			expected: Query{
+				SQL:  "SELECT id FROM users WHERE id = ? AND deleted_at IS NULL",
+				Args: []Arg{{Column: "id", Clause: Where}},
+			},
+		},
+	}
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewBuilder(test.dialect, test.reserved).Build(test.stmt)
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestBuildCount(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		dialect  Dialect
+		reserved map[string]struct{}
+		stmt     Count
+		expected Query
+		err      error
+	}{
+		{
+			name:    "table is empty",
+			dialect: Postgres,
+			stmt:    Count{},
+			err:     ErrNoTable,
+		},
+		{
+			name:     "without where",
+			dialect:  Postgres,
+			stmt:     Count{Table: "users"},
+			expected: Query{SQL: "SELECT COUNT(*) FROM users"},
+		},
+		{
+			name:    "mysql with composite key",
+			dialect: Mysql,
+			stmt:    Count{Table: "members", Where: []string{"group_id", "user_id"}},
+			expected: Query{
+				SQL:  "SELECT COUNT(*) FROM members WHERE group_id = ? AND user_id = ?",
+				Args: []Arg{{Column: "group_id", Clause: Where}, {Column: "user_id", Clause: Where}},
+			},
+		},
+		{
+			name:     "reserved on postgres",
+			dialect:  Postgres,
+			reserved: map[string]struct{}{"user": {}},
+			stmt:     Count{Table: "user"},
+			expected: Query{SQL: `SELECT COUNT(*) FROM "user"`},
+		},
+		{
+			name:     "null condition",
+			dialect:  Postgres,
+			stmt:     Count{Table: "users", IsNull: []string{"deleted_at"}},
+			expected: Query{SQL: "SELECT COUNT(*) FROM users WHERE deleted_at IS NULL"},
+		},
+	}
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewBuilder(test.dialect, test.reserved).Build(test.stmt)
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestBuildExists(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		dialect  Dialect
+		reserved map[string]struct{}
+		stmt     Exists
+		expected Query
+		err      error
+	}{
+		{
+			name:    "table is empty",
+			dialect: Postgres,
+			stmt:    Exists{Where: []string{"id"}},
+			err:     ErrNoTable,
+		},
+		{
+			name:    "where is empty",
+			dialect: Postgres,
+			stmt:    Exists{Table: "users"},
+			err:     ErrNoCondition,
+		},
+		{
+			name:    "postgres with composite key",
+			dialect: Postgres,
+			stmt:    Exists{Table: "members", Where: []string{"group_id", "user_id"}},
+			expected: Query{
+				SQL:  "SELECT EXISTS (SELECT 1 FROM members WHERE group_id = $1 AND user_id = $2)",
+				Args: []Arg{{Column: "group_id", Clause: Where}, {Column: "user_id", Clause: Where}},
+			},
+		},
+		{
+			name:     "reserved on mysql",
+			dialect:  Mysql,
+			reserved: map[string]struct{}{"order": {}},
+			stmt:     Exists{Table: "order", Where: []string{"id"}},
+			expected: Query{
+				SQL:  "SELECT EXISTS (SELECT 1 FROM `order` WHERE id = ?)",
+				Args: []Arg{{Column: "id", Clause: Where}},
+			},
+		},
+		{
+			name:    "null condition",
+			dialect: Postgres,
+			stmt:    Exists{Table: "users", Where: []string{"id"}, IsNull: []string{"deleted_at"}},
+			expected: Query{
+				SQL:  "SELECT EXISTS (SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)",
+				Args: []Arg{{Column: "id", Clause: Where}},
+			},
+		},
+	}
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewBuilder(test.dialect, test.reserved).Build(test.stmt)
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestBuildNamed(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		dialect  Dialect
+		stmt     Statement
+		expected string
+	}{
+		{
+			name:     "insert",
+			dialect:  Postgres,
+			stmt:     Insert{Table: "users", Columns: []string{"id", "name"}},
+			expected: "INSERT INTO users (id, name) VALUES (:id, :name)",
+		},
+		{
+			name:     "update",
+			dialect:  Postgres,
+			stmt:     Update{Table: "members", Set: []string{"name"}, Where: []string{"group_id", "user_id"}},
+			expected: "UPDATE members SET name = :name WHERE group_id = :group_id AND user_id = :user_id",
+		},
+		{
+			name:     "named ignores dialect placeholders",
+			dialect:  Mysql,
+			stmt:     Delete{Table: "users", Where: []string{"id"}},
+			expected: "DELETE FROM users WHERE id = :id",
+		},
+	}
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewBuilder(test.dialect, nil).Named().Build(test.stmt)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual.SQL)
+		})
+	}
+}
+
+func TestBuildUpsert(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		dialect  Dialect
+		reserved map[string]struct{}
+		stmt     Insert
+		expected Query
+		err      error
+	}{
+		{
+			name:    "conflict columns are empty",
+			dialect: Postgres,
+			stmt:    Insert{Table: "users", Columns: []string{"id"}, OnConflict: &Conflict{}},
+			err:     ErrNoCondition,
+		},
+		{
+			name:    "do update on postgres",
+			dialect: Postgres,
+			stmt: Insert{
+				Table:      "users",
+				Columns:    []string{"id", "email", "name"},
+				OnConflict: &Conflict{Columns: []string{"id"}, Update: []string{"email", "name"}},
+			},
+			expected: Query{
+				SQL: "INSERT INTO users (id, email, name) VALUES ($1, $2, $3) ON CONFLICT (id) DO UPDATE SET email = EXCLUDED.email, name = EXCLUDED.name",
+				Args: []Arg{
+					{Column: "id", Clause: Values},
+					{Column: "email", Clause: Values},
+					{Column: "name", Clause: Values},
+				},
+			},
+		},
+		{
+			name:    "do nothing on postgres",
+			dialect: Postgres,
+			stmt: Insert{
+				Table:      "members",
+				Columns:    []string{"group_id", "user_id"},
+				OnConflict: &Conflict{Columns: []string{"group_id", "user_id"}},
+			},
+			expected: Query{
+				SQL:  "INSERT INTO members (group_id, user_id) VALUES ($1, $2) ON CONFLICT (group_id, user_id) DO NOTHING",
+				Args: []Arg{{Column: "group_id", Clause: Values}, {Column: "user_id", Clause: Values}},
+			},
+		},
+		{
+			name:     "do update on sqlite with reserved",
+			dialect:  Sqlite,
+			reserved: map[string]struct{}{"key": {}},
+			stmt: Insert{
+				Table:      "settings",
+				Columns:    []string{"key", "value"},
+				OnConflict: &Conflict{Columns: []string{"key"}, Update: []string{"value"}},
+			},
+			expected: Query{
+				SQL:  `INSERT INTO settings ("key", value) VALUES (?, ?) ON CONFLICT ("key") DO UPDATE SET value = EXCLUDED.value`,
+				Args: []Arg{{Column: "key", Clause: Values}, {Column: "value", Clause: Values}},
+			},
+		},
+		{
+			name:    "do update on mysql",
+			dialect: Mysql,
+			stmt: Insert{
+				Table:      "users",
+				Columns:    []string{"id", "email", "name"},
+				OnConflict: &Conflict{Columns: []string{"email"}, Update: []string{"name"}},
+			},
+			expected: Query{
+				SQL: "INSERT INTO users (id, email, name) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE name = VALUES(name)",
+				Args: []Arg{
+					{Column: "id", Clause: Values},
+					{Column: "email", Clause: Values},
+					{Column: "name", Clause: Values},
+				},
+			},
+		},
+		{
+			name:    "do nothing on mysql",
+			dialect: Mysql,
+			stmt: Insert{
+				Table:      "users",
+				Columns:    []string{"id", "name"},
+				OnConflict: &Conflict{Columns: []string{"id"}},
+			},
+			expected: Query{
+				SQL:  "INSERT INTO users (id, name) VALUES (?, ?) ON DUPLICATE KEY UPDATE id = id",
+				Args: []Arg{{Column: "id", Clause: Values}, {Column: "name", Clause: Values}},
+			},
+		},
+	}
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewBuilder(test.dialect, test.reserved).Build(test.stmt)
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestIdent(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		dialect  Dialect
+		reserved map[string]struct{}
+		ident    string
+		expected string
+	}{
+		{
+			name:     "not reserved",
+			dialect:  Postgres,
+			reserved: map[string]struct{}{"user": {}},
+			ident:    "name",
+			expected: "name",
+		},
+		{
+			name:     "reserved on postgres",
+			dialect:  Postgres,
+			reserved: map[string]struct{}{"user": {}},
+			ident:    "user",
+			expected: `"user"`,
+		},
+		{
+			name:     "reserved on mysql",
+			dialect:  Mysql,
+			reserved: map[string]struct{}{"order": {}},
+			ident:    "order",
+			expected: "`order`",
+		},
+	}
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, test.expected, NewBuilder(test.dialect, test.reserved).Ident(test.ident))
+		})
+	}
+}
+
+func TestBuildCreateTable(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		reserved map[string]struct{}
+		stmt     Statement
+		expected Query
+		err      error
+	}{
+		{
+			name: "table is empty",
+			stmt: CreateTable{Columns: []ColumnDef{{Name: "id", Type: "integer"}}},
+			err:  ErrNoTable,
+		},
+		{
+			name: "columns are empty",
+			stmt: CreateTable{Table: "users"},
+			err:  ErrNoColumns,
+		},
+		{
+			name:     "keys and checks",
+			reserved: map[string]struct{}{"user": {}, "order": {}},
+			stmt: CreateTable{
+				Table: "user",
+				Columns: []ColumnDef{
+					{Name: "id", Type: "serial", NotNull: true},
+					{Name: "name", Type: "varchar(20)", NotNull: true, Default: "'anon'::text"},
+					{Name: "order", Type: "integer"},
+				},
+				PrimaryKey: []string{"id"},
+				Unique:     [][]string{{"name", "order"}},
+				Checks:     []string{`"order" >= 0`},
+			},
+			expected: Query{
+				SQL: `CREATE TABLE "user" (id serial NOT NULL, name varchar(20) NOT NULL DEFAULT 'anon'::text, "order" integer, PRIMARY KEY (id), UNIQUE (name, "order"), CHECK ("order" >= 0))`,
+			},
+		},
+		{
+			name: "foreign key",
+			stmt: AddForeignKey{Table: "memos", Columns: []string{"user_id"}, References: "users", RefColumns: []string{"id"}},
+			expected: Query{
+				SQL: "ALTER TABLE memos ADD FOREIGN KEY (user_id) REFERENCES users (id)",
+			},
+		},
+		{
+			name: "foreign key columns do not match",
+			stmt: AddForeignKey{Table: "memos", Columns: []string{"user_id"}, References: "users"},
+			err:  ErrNoColumns,
+		},
+	}
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := NewBuilder(Postgres, test.reserved).Build(test.stmt)
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
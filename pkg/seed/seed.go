@@ -0,0 +1,501 @@
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/naonao2323/testgen/pkg/common"
+	"github.com/naonao2323/testgen/pkg/extractor"
+	"github.com/naonao2323/testgen/pkg/generator"
+	"github.com/naonao2323/testgen/pkg/query"
+)
+
+var (
+	ErrCycle    = errors.New("seed: tables reference each other in a cycle")
+	ErrNoParent = errors.New("seed: no parent row to reference")
+)
+
+// maxParams is the most bind parameters Postgres accepts in one statement.
+const maxParams = 65535
+
+// Plan sizes the seeded tables. A table takes its rows from Ratios, then Rows, then Default.
+type Plan struct {
+	Rows    map[string]int
+	Ratios  map[string]Ratio
+	Default int
+	// Batch is the number of rows of each INSERT statement.
+	Batch int
+	// Parallel bounds the tables loaded at once.
+	Parallel int
+	// Seed makes the generated values, and the parents rows are attached to, repeatable.
+	Seed int64
+}
+
+// Ratio gives a table Rows rows per row of its parent table Per.
+type Ratio struct {
+	Per  string
+	Rows int
+}
+
+// Seeder fills tables with generated rows for local development and load tests.
+type Seeder struct {
+	extractor extractor.Extractor
+	db        *sql.DB
+}
+
+func NewSeeder(extractor extractor.Extractor, db *sql.DB) Seeder {
+	return Seeder{
+		extractor: extractor,
+		db:        db,
+	}
+}
+
+// Seed inserts the rows of plan into tables and returns the number inserted by table.
+// Tables are loaded level by level of the foreign key graph; the tables of one level
+// only reference earlier ones and load concurrently, each in its own transaction.
+// Foreign keys to tables outside tables reference the rows already in the database, and
+// generated keys skip the keys already there, so seeding again with the same seed adds rows.
+// Seeding is not atomic across tables: on an error the tables committed before stay
+// loaded, as the returned counts tell.
+func (s Seeder) Seed(ctx context.Context, tables []string, plan Plan) (map[string]int, error) {
+	tables = append([]string(nil), tables...)
+	sort.Strings(tables)
+	if err := s.validate(tables, plan); err != nil {
+		return nil, err
+	}
+	levels, err := s.levels(tables)
+	if err != nil {
+		return nil, err
+	}
+	builder := query.NewBuilder(query.Postgres, reserved(s.extractor))
+	keys, err := s.existingKeys(ctx, builder, tables)
+	if err != nil {
+		return nil, err
+	}
+	returning := s.referenced(tables)
+	seeds := make(map[string]int64, len(tables))
+	for i, table := range tables {
+		seeds[table] = plan.Seed + int64(i)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	inserted := make(map[string]int, len(tables))
+	var mutex sync.Mutex
+	slots := make(chan struct{}, max(plan.Parallel, 1))
+	for _, level := range levels {
+		// the tables of a level read the keys of earlier levels only.
+		parents := make(map[string][]any, len(keys))
+		for table, values := range keys {
+			parents[table] = values
+		}
+		var wg sync.WaitGroup
+		errs := make([]error, len(level))
+		for i, table := range level {
+			count := plan.Default
+			if n, ok := plan.Rows[table]; ok {
+				count = n
+			}
+			if ratio, ok := plan.Ratios[table]; ok {
+				count = ratio.Rows * len(parents[ratio.Per])
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				slots <- struct{}{}
+				defer func() { <-slots }()
+				if ctx.Err() != nil {
+					// another table failed and reports the error.
+					return
+				}
+				values, err := s.seedTable(ctx, builder, table, count, plan, seeds[table], returning[table], parents)
+				if err != nil {
+					errs[i] = fmt.Errorf("seed: %s: %w", table, err)
+					cancel()
+					return
+				}
+				mutex.Lock()
+				defer mutex.Unlock()
+				inserted[table] = count
+				if returning[table] != "" {
+					keys[table] = values
+				}
+			}()
+		}
+		wg.Wait()
+		if err := errors.Join(errs...); err != nil {
+			return inserted, err
+		}
+	}
+	return inserted, nil
+}
+
+// validate checks the tables exist and every ratio is taken from a parent table.
+func (s Seeder) validate(tables []string, plan Plan) error {
+	known := make(map[string]struct{})
+	for _, table := range s.extractor.ListTableNames() {
+		known[table] = struct{}{}
+	}
+	var errs []error
+	for _, table := range tables {
+		if _, ok := known[table]; !ok {
+			errs = append(errs, fmt.Errorf("seed: unknown table %q", table))
+		}
+	}
+	for _, table := range sortedKeys(plan.Ratios) {
+		ratio := plan.Ratios[table]
+		isParent := false
+		for _, fk := range s.extractor.GetForeignKeys(table) {
+			if fk.Table == ratio.Per && fk.Table != table {
+				isParent = true
+			}
+		}
+		if !isParent {
+			errs = append(errs, fmt.Errorf("seed: %s has no foreign key to %s to take its ratio from", table, ratio.Per))
+		} else if len(s.extractor.GetPk(ratio.Per)) != 1 {
+			errs = append(errs, fmt.Errorf("seed: %s has no single column primary key to take the ratio of %s from", ratio.Per, table))
+		}
+		if ratio.Rows < 0 {
+			errs = append(errs, fmt.Errorf("seed: %s: negative ratio %d", table, ratio.Rows))
+		}
+	}
+	for _, table := range sortedKeys(plan.Rows) {
+		if plan.Rows[table] < 0 {
+			errs = append(errs, fmt.Errorf("seed: %s: negative row count %d", table, plan.Rows[table]))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// levels groups tables so each level only references tables of earlier levels or
+// outside tables. A table referencing itself does not depend on itself.
+func (s Seeder) levels(tables []string) ([][]string, error) {
+	seeded := make(map[string]struct{}, len(tables))
+	for _, table := range tables {
+		seeded[table] = struct{}{}
+	}
+	parents := make(map[string]map[string]struct{}, len(tables))
+	for _, table := range tables {
+		parents[table] = make(map[string]struct{})
+		for _, fk := range s.extractor.GetForeignKeys(table) {
+			if _, ok := seeded[fk.Table]; ok && fk.Table != table {
+				parents[table][fk.Table] = struct{}{}
+			}
+		}
+	}
+	placed := make(map[string]struct{}, len(tables))
+	levels := make([][]string, 0)
+	for len(placed) < len(tables) {
+		level := make([]string, 0)
+		for _, table := range tables {
+			if _, ok := placed[table]; ok {
+				continue
+			}
+			ready := true
+			for parent := range parents[table] {
+				if _, ok := placed[parent]; !ok {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				level = append(level, table)
+			}
+		}
+		if len(level) == 0 {
+			left := make([]string, 0)
+			for _, table := range tables {
+				if _, ok := placed[table]; !ok {
+					left = append(left, table)
+				}
+			}
+			return nil, fmt.Errorf("%w: %s", ErrCycle, strings.Join(left, ", "))
+		}
+		for _, table := range level {
+			placed[table] = struct{}{}
+		}
+		levels = append(levels, level)
+	}
+	return levels, nil
+}
+
+// referenced returns the key column foreign keys read from each seeded table, empty
+// when no seeded table references it. Only single column primary keys can be referenced.
+func (s Seeder) referenced(tables []string) map[string]string {
+	seeded := make(map[string]struct{}, len(tables))
+	for _, table := range tables {
+		seeded[table] = struct{}{}
+	}
+	returning := make(map[string]string)
+	for _, table := range tables {
+		for _, fk := range s.extractor.GetForeignKeys(table) {
+			if _, ok := seeded[fk.Table]; !ok || fk.Table == table {
+				continue
+			}
+			if pk := s.extractor.GetPk(fk.Table); len(pk) == 1 {
+				returning[fk.Table] = pk[0]
+			}
+		}
+	}
+	return returning
+}
+
+// existingKeys reads the keys of the tables outside tables that seeded rows reference.
+func (s Seeder) existingKeys(ctx context.Context, builder query.Builder, tables []string) (map[string][]any, error) {
+	seeded := make(map[string]struct{}, len(tables))
+	for _, table := range tables {
+		seeded[table] = struct{}{}
+	}
+	keys := make(map[string][]any)
+	for _, table := range tables {
+		for _, fk := range s.extractor.GetForeignKeys(table) {
+			if _, ok := seeded[fk.Table]; ok {
+				continue
+			}
+			if _, ok := keys[fk.Table]; ok {
+				continue
+			}
+			pk := s.extractor.GetPk(fk.Table)
+			if len(pk) != 1 {
+				continue
+			}
+			q, err := builder.Build(query.Select{Table: fk.Table, Columns: pk})
+			if err != nil {
+				return nil, err
+			}
+			values, err := scanColumn(func() (*sql.Rows, error) { return s.db.QueryContext(ctx, q.SQL) })
+			if err != nil {
+				return nil, fmt.Errorf("seed: read keys of %s: %w", fk.Table, err)
+			}
+			keys[fk.Table] = values
+		}
+	}
+	return keys, nil
+}
+
+// seedTable inserts count rows in batches and returns the returning column of each.
+func (s Seeder) seedTable(ctx context.Context, builder query.Builder, table string, count int, plan Plan, seed int64, returning string, keys map[string][]any) ([]any, error) {
+	if count == 0 {
+		return nil, nil
+	}
+	g := generator.NewGenerator(s.extractor, seed)
+	r := rand.New(rand.NewSource(seed))
+	ratio, hasRatio := plan.Ratios[table]
+	unique := s.uniqueColumns(table)
+	if err := s.takeKeys(ctx, builder, table, sortedKeys(unique), g); err != nil {
+		return nil, fmt.Errorf("read keys: %w", err)
+	}
+	rows := make([]map[string]any, 0, count)
+	for i := 0; i < count; i++ {
+		fixed := make(map[string]any)
+		for _, fk := range s.extractor.GetForeignKeys(table) {
+			parents, ok := keys[fk.Table]
+			if fk.Table == table || !ok || len(parents) == 0 {
+				// rows of the same table, or of a table without a single column key, are not referenced.
+				if !fk.IsNull {
+					return nil, fmt.Errorf("%w: %s references %s", ErrNoParent, fk.Column, fk.Table)
+				}
+				fixed[fk.Column] = nil
+				continue
+			}
+			fixed[fk.Column] = pick(i, parents, r, hasRatio && fk.Table == ratio.Per, ratio.Rows, unique[fk.Column])
+		}
+		row, err := g.Row(table, fixed)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	columns := sortedKeys(rows[0])
+	batch := max(plan.Batch, 1)
+	if len(columns) > 0 {
+		batch = min(batch, maxParams/len(columns))
+	} else {
+		// DEFAULT VALUES inserts a single row.
+		batch = 1
+	}
+	var resp []any
+	if returning != "" {
+		resp = make([]any, 0, count)
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	for start := 0; start < len(rows); start += batch {
+		end := min(start+batch, len(rows))
+		stmt := query.Insert{Table: table, Columns: columns, Rows: end - start}
+		if returning != "" {
+			stmt.Returning = []string{returning}
+		}
+		q, err := builder.Build(stmt)
+		if err != nil {
+			return nil, err
+		}
+		args := make([]any, 0, (end-start)*len(columns))
+		for _, row := range rows[start:end] {
+			for _, column := range columns {
+				args = append(args, toArg(row[column]))
+			}
+		}
+		if returning == "" {
+			if _, err := tx.ExecContext(ctx, q.SQL, args...); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		values, err := scanColumn(func() (*sql.Rows, error) { return tx.QueryContext(ctx, q.SQL, args...) })
+		if err != nil {
+			return nil, err
+		}
+		resp = append(resp, values...)
+	}
+	return resp, tx.Commit()
+}
+
+// takeKeys marks the keys of the rows already in table as taken by g, so the
+// generated rows do not collide with them.
+func (s Seeder) takeKeys(ctx context.Context, builder query.Builder, table string, columns []string, g *generator.Generator) error {
+	if len(columns) == 0 {
+		return nil
+	}
+	q, err := builder.Build(query.Select{Table: table, Columns: columns})
+	if err != nil {
+		return err
+	}
+	rows, err := s.db.QueryContext(ctx, q.SQL)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	dataTypes := s.extractor.GetColumns(table)
+	databaseTypes := s.extractor.GetDatabaseTypes(table)
+	values := make([]any, len(columns))
+	targets := make([]any, len(columns))
+	for i := range values {
+		targets[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(targets...); err != nil {
+			return err
+		}
+		row := make(map[string]any, len(columns))
+		for i, column := range columns {
+			row[column] = fromDatabase(values[i], dataTypes[column], databaseTypes[column])
+		}
+		g.Taken(table, row)
+	}
+	return rows.Err()
+}
+
+// fromDatabase brings a value scanned from the database to the form the generator draws,
+// so equal keys compare equal.
+func fromDatabase(value any, dataType common.GoDataType, databaseType string) any {
+	if b, ok := value.([]byte); ok {
+		value = string(b)
+	}
+	switch v := value.(type) {
+	case string:
+		if dataType == common.Float64 {
+			// numeric comes back as text.
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return f
+			}
+		}
+	case time.Time:
+		switch databaseType {
+		case "date":
+			return v.Format(time.DateOnly)
+		case "time":
+			return v.Format(time.TimeOnly)
+		}
+		return v.UTC().Format(time.RFC3339)
+	}
+	return value
+}
+
+// uniqueColumns lists the columns of the primary and unique keys of table.
+func (s Seeder) uniqueColumns(table string) map[string]bool {
+	unique := make(map[string]bool)
+	for _, column := range s.extractor.GetPk(table) {
+		unique[column] = true
+	}
+	for _, columns := range s.extractor.GetUniqueKeys(table) {
+		for _, column := range columns {
+			unique[column] = true
+		}
+	}
+	return unique
+}
+
+// pick chooses the parent of the i-th row. A ratio gives each parent perParent rows in
+// turn; keys spread rows over the parents in turn so they collide as late as possible;
+// other columns take a random parent.
+func pick(i int, parents []any, r *rand.Rand, isRatio bool, perParent int, isKey bool) any {
+	switch {
+	case isRatio && perParent > 0:
+		return parents[(i/perParent)%len(parents)]
+	case isKey:
+		return parents[i%len(parents)]
+	default:
+		return parents[r.Intn(len(parents))]
+	}
+}
+
+func toArg(value any) any {
+	switch v := value.(type) {
+	case []int64:
+		return pq.Array(v)
+	case []string:
+		return pq.Array(v)
+	}
+	return value
+}
+
+func scanColumn(run func() (*sql.Rows, error)) ([]any, error) {
+	result, err := run()
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+	values := make([]any, 0)
+	for result.Next() {
+		var value any
+		if err := result.Scan(&value); err != nil {
+			return nil, err
+		}
+		// text comes back as bytes, which would be sent as bytea when referenced.
+		if b, ok := value.([]byte); ok {
+			value = string(b)
+		}
+		values = append(values, value)
+	}
+	return values, result.Err()
+}
+
+func reserved(extractor extractor.Extractor) map[string]struct{} {
+	words := extractor.ListReservedWord()
+	reserved := make(map[string]struct{}, len(words))
+	for i := range words {
+		reserved[words[i]] = struct{}{}
+	}
+	return reserved
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
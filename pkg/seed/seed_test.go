@@ -0,0 +1,213 @@
+package seed
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/naonao2323/testgen/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeExtractor struct{}
+
+var schema = map[string]struct {
+	pk          []string
+	foreignKeys []common.ForeignKey
+}{
+	"users":    {pk: []string{"id"}},
+	"tags":     {pk: []string{"id"}},
+	"memos":    {pk: []string{"id"}, foreignKeys: []common.ForeignKey{{Column: "user_id", Table: "users"}, {Column: "parent_id", Table: "memos", IsNull: true}}},
+	"comments": {pk: []string{"id"}, foreignKeys: []common.ForeignKey{{Column: "memo_id", Table: "memos"}, {Column: "user_id", Table: "users"}}},
+	"memo_tags": {
+		pk:          []string{"memo_id", "tag_id"},
+		foreignKeys: []common.ForeignKey{{Column: "memo_id", Table: "memos"}, {Column: "tag_id", Table: "tags"}},
+	},
+	"eggs":     {pk: []string{"id"}, foreignKeys: []common.ForeignKey{{Column: "hen_id", Table: "hens"}}},
+	"hens":     {pk: []string{"id"}, foreignKeys: []common.ForeignKey{{Column: "egg_id", Table: "eggs"}}},
+	"settings": {foreignKeys: []common.ForeignKey{{Column: "memo_tag_id", Table: "memo_tags"}}},
+}
+
+func (fakeExtractor) GetPk(table string) []string { return schema[table].pk }
+
+func (fakeExtractor) GetNullable(table string) []string { return nil }
+
+func (fakeExtractor) GetDefaults(table string) map[string]string { return nil }
+
+func (fakeExtractor) GetColumns(table string) map[string]common.GoDataType { return nil }
+
+func (fakeExtractor) GetDatabaseTypes(table string) map[string]string { return nil }
+
+func (fakeExtractor) GetForeignKeys(table string) []common.ForeignKey {
+	return schema[table].foreignKeys
+}
+
+func (fakeExtractor) GetUniqueKeys(table string) map[string][]string { return nil }
+
+func (fakeExtractor) GetConstraints(table string) map[string][]string { return nil }
+
+func (fakeExtractor) GetLimits(table string) map[string]common.Limit { return nil }
+
+func (fakeExtractor) ListTableNames() []string {
+	return []string{"comments", "eggs", "hens", "memo_tags", "memos", "settings", "tags", "users"}
+}
+
+func (fakeExtractor) ListReservedWord() []string { return nil }
+
+func TestLevels(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		tables   []string
+		expected [][]string
+		err      error
+	}{
+		{
+			name:     "independent tables share a level",
+			tables:   []string{"comments", "memo_tags", "memos", "tags", "users"},
+			expected: [][]string{{"tags", "users"}, {"memos"}, {"comments", "memo_tags"}},
+		},
+		{
+			name:     "tables outside the seed do not hold tables back",
+			tables:   []string{"comments", "memos"},
+			expected: [][]string{{"memos"}, {"comments"}},
+		},
+		{
+			name:   "cycle",
+			tables: []string{"eggs", "hens", "users"},
+			err:    ErrCycle,
+		},
+	}
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			levels, err := NewSeeder(fakeExtractor{}, nil).levels(test.tables)
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				assert.ErrorContains(t, err, "eggs, hens")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, levels)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		tables []string
+		plan   Plan
+		errs   []string
+	}{
+		{
+			name:   "valid",
+			tables: []string{"comments", "memos", "users"},
+			plan: Plan{
+				Rows:   map[string]int{"users": 10},
+				Ratios: map[string]Ratio{"memos": {Per: "users", Rows: 3}, "comments": {Per: "memos", Rows: 10}},
+			},
+		},
+		{
+			name:   "invalid",
+			tables: []string{"memos", "nothing", "settings"},
+			plan: Plan{
+				Rows:   map[string]int{"memos": -1},
+				Ratios: map[string]Ratio{"memos": {Per: "tags", Rows: 1}, "settings": {Per: "memo_tags", Rows: 1}},
+			},
+			errs: []string{
+				`seed: unknown table "nothing"`,
+				"seed: memos has no foreign key to tags to take its ratio from",
+				"seed: memo_tags has no single column primary key to take the ratio of settings from",
+				"seed: memos: negative row count -1",
+			},
+		},
+	}
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			err := NewSeeder(fakeExtractor{}, nil).validate(test.tables, test.plan)
+			if len(test.errs) == 0 {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			for _, expected := range test.errs {
+				assert.ErrorContains(t, err, expected)
+			}
+		})
+	}
+}
+
+func TestReferenced(t *testing.T) {
+	t.Parallel()
+	returning := NewSeeder(fakeExtractor{}, nil).referenced([]string{"memo_tags", "memos", "settings", "users"})
+	// memo_tags has a composite key and memos only references itself besides users.
+	assert.Equal(t, map[string]string{"memos": "id", "users": "id"}, returning)
+}
+
+func TestPick(t *testing.T) {
+	t.Parallel()
+	parents := []any{int64(1), int64(2), int64(3)}
+	tests := []struct {
+		name      string
+		isRatio   bool
+		perParent int
+		isKey     bool
+		expected  []any
+	}{
+		{
+			name:      "ratio fills each parent in turn",
+			isRatio:   true,
+			perParent: 2,
+			expected:  []any{int64(1), int64(1), int64(2), int64(2), int64(3), int64(3)},
+		},
+		{
+			name:     "keys spread over the parents",
+			isKey:    true,
+			expected: []any{int64(1), int64(2), int64(3), int64(1), int64(2), int64(3)},
+		},
+	}
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			r := rand.New(rand.NewSource(1))
+			picked := make([]any, 0, len(test.expected))
+			for i := range test.expected {
+				picked = append(picked, pick(i, parents, r, test.isRatio, test.perParent, test.isKey))
+			}
+			assert.Equal(t, test.expected, picked)
+		})
+	}
+}
+
+func TestFromDatabase(t *testing.T) {
+	t.Parallel()
+	at := time.Date(2001, 2, 3, 4, 5, 6, 0, time.FixedZone("", 3600))
+	tests := []struct {
+		name         string
+		value        any
+		dataType     common.GoDataType
+		databaseType string
+		expected     any
+	}{
+		{name: "int", value: int64(5), dataType: common.Int, databaseType: "integer", expected: int64(5)},
+		{name: "text", value: []byte("amber-1"), dataType: common.String, databaseType: "text", expected: "amber-1"},
+		{name: "numeric", value: []byte("12.50"), dataType: common.Float64, databaseType: "numeric", expected: 12.5},
+		{name: "date", value: at, dataType: common.String, databaseType: "date", expected: "2001-02-03"},
+		{name: "timestamp", value: at, dataType: common.String, databaseType: "timestamp", expected: "2001-02-03T03:05:06Z"},
+		{name: "null", value: nil, dataType: common.String, databaseType: "text", expected: nil},
+	}
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, test.expected, fromDatabase(test.value, test.dataType, test.databaseType))
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package postgres
+
+const CapturePostgresTemplate = `package dao
+
+import (
+	"testing"
+)
+
+// Load{{ field $.Capture.Name }} inserts the rows captured from {{ $.Capture.Source }} through the
+// generated fixtures, parents first. Captured keys are kept as they were.
+func Load{{ field $.Capture.Name }}(t testing.TB, db DBTX) {
+	t.Helper()
+{{- range $.Capture.Rows }}
+	{{ if .Var }}{{ .Var }} := {{ end }}New{{ field .Table }}Fixture().
+{{- range .Values }}
+{{- if .Parent }}
+		With{{ association .Column }}({{ .Parent }}).
+{{- else }}
+		With{{ field .Column }}({{ .Literal }}).
+{{- end }}
+{{- end }}
+		Insert(t, db)
+{{- end }}
+}
+`
@@ -4,9 +4,14 @@ package postgres
 const DaoPostgresTemplate = `package dao
 
 import (
+	"context"
 	"database/sql"
-
-	_ "github.com/lib/pq"
+	"fmt"
+	"iter"
+	"strings"
+{{ range imports $.DataTypes "github.com/lib/pq" }}
+	"{{ . }}"
+{{- end }}
 )
 
 type {{ .TableName }} struct {
@@ -17,53 +22,337 @@ type {{ .TableName }} struct {
 
 type {{ .TableName }}Dao struct {}
 
-func (d {{.TableName }}Dao) Create(db *sql.DB, target {{ .TableName }}) (int64, error) {
-	m, err := db.Exec({{ backQuote }}{{ insert $.TableName $.Columns $.Reserved }}{{ backQuote }}, {{- withTarget "target" $.Columns }})
+func (d {{.TableName }}Dao) Create(ctx context.Context, db DBTX, target {{ .TableName }}) (int64, error) {
+	{{- $insert := insert $.TableName $.Columns $.Reserved }}
+	m, err := db.ExecContext(ctx, {{ backQuote }}{{ $insert.SQL }}{{ backQuote }}, {{ args $insert "target" }})
+	if err != nil {
+		return 0, translate(err)
+	}
+	c, err := m.RowsAffected()
 	if err != nil {
 		return 0, err
 	}
+	return c, nil
+}
+{{ if $.Columns }}
+func (d {{.TableName }}Dao) CreateMany(ctx context.Context, db DBTX, targets []{{ .TableName }}) (int64, error) {
+	{{- $insert := insert $.TableName $.Columns $.Reserved }}
+	const columns = {{ len $.Columns }}
+	size := maxPlaceholders / columns
+	var total int64
+	for start := 0; start < len(targets); start += size {
+		chunk := targets[start:min(start+size, len(targets))]
+		args := make([]any, 0, len(chunk)*columns)
+		for _, target := range chunk {
+			args = append(args, {{ args $insert "target" }})
+		}
+		m, err := db.ExecContext(ctx, {{ backQuote }}{{ insertValues $.TableName $.Columns $.Reserved }}{{ backQuote }}+values(len(chunk), columns), args...)
+		if err != nil {
+			return total, translate(err)
+		}
+		c, err := m.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += c
+	}
+	return total, nil
+}
+
+// CopyFrom bulk loads targets with COPY, which lib/pq only supports inside a transaction.
+func (d {{.TableName }}Dao) CopyFrom(ctx context.Context, tx *sql.Tx, targets []{{ .TableName }}) (int64, error) {
+	{{- $insert := insert $.TableName $.Columns $.Reserved }}
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn({{ printf "%q" $.TableName }}, {{ range $i, $c := $.Columns }}{{ if $i }}, {{ end }}{{ printf "%q" $c }}{{ end }}))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+	for _, target := range targets {
+		if _, err := stmt.ExecContext(ctx, {{ args $insert "target" }}); err != nil {
+			return 0, translate(err)
+		}
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return 0, translate(err)
+	}
+	return int64(len(targets)), nil
+}
+{{ end }}
+{{- range $c := conflicts $.Pk $.UniqueKeys }}
+{{- if nonPk (nonPk $.Columns $c.Columns) $.Pk }}
+func (d {{ $.TableName }}Dao) Upsert{{ $c.Name }}(ctx context.Context, db DBTX, target {{ $.TableName }}) (int64, error) {
+	{{- $upsert := upsert $.TableName $.Columns $c.Columns $.Pk $.Reserved false }}
+	m, err := db.ExecContext(ctx, {{ backQuote }}{{ $upsert.SQL }}{{ backQuote }}, {{ args $upsert "target" }})
+	if err != nil {
+		return 0, translate(err)
+	}
 	c, err := m.RowsAffected()
 	if err != nil {
 		return 0, err
 	}
 	return c, nil
 }
-{{ if isPrimaryKeyOnly $.Pk $.DataTypes }}
-{{ else }}
-func (d {{.TableName }}Dao) Update(db *sql.DB, {{ range $pk := .Pk}}{{ $pk }} {{ pkType $pk $.DataTypes }},{{- end}} target {{ .TableName }}) (int64, error) {
-	m, err := db.Exec({{ backQuote }}{{ update $.TableName $.Columns $.Pk $.Reserved }}{{ backQuote }}, {{ withPk "target" $.Columns $.Pk}})
+{{ end }}
+func (d {{ $.TableName }}Dao) Upsert{{ $c.Name }}DoNothing(ctx context.Context, db DBTX, target {{ $.TableName }}) (int64, error) {
+	{{- $upsert := upsert $.TableName $.Columns $c.Columns $.Pk $.Reserved true }}
+	m, err := db.ExecContext(ctx, {{ backQuote }}{{ $upsert.SQL }}{{ backQuote }}, {{ args $upsert "target" }})
+	if err != nil {
+		return 0, translate(err)
+	}
+	c, err := m.RowsAffected()
 	if err != nil {
 		return 0, err
 	}
+	return c, nil
+}
+{{ end }}
+{{- $gone := "no row has the primary key" }}
+{{- if $.SoftDelete }}{{ $gone = "no live row has the primary key" }}{{ end }}
+{{- $updatable := nonPk $.Columns (list $.SoftDelete) }}
+{{- if and $.Pk (nonPk (nonPk $updatable $.Pk) (list $.Version)) }}
+{{- if $.Version }}
+// Update applies target only while the stored {{ $.Version }} still equals target.{{ $.Version }} and advances it.
+// It returns ErrConflict when the row changed in the meantime and ErrNotFound when {{ $gone }}.
+func (d {{.TableName }}Dao) Update(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}, target {{ .TableName }}) (int64, error) {
+	{{- $update := lockedUpdate $.TableName $updatable $.Pk $.Version (index $.DataTypes $.Version) $.Reserved $.SoftDelete }}
+	m, err := db.ExecContext(ctx, {{ backQuote }}{{ $update.SQL }}{{ backQuote }}, {{ args $update "target" }})
+	if err != nil {
+		return 0, translate(err)
+	}
 	c, err := m.RowsAffected()
 	if err != nil {
 		return 0, err
 	}
+	if c == 0 {
+		return 0, d.conflict(ctx, db, {{ pkLiner $.Pk }})
+	}
 	return c, nil
 }
+{{- else }}
+// Update returns ErrNotFound when {{ $gone }}.
+func (d {{.TableName }}Dao) Update(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}, target {{ .TableName }}) (int64, error) {
+	{{- $update := update $.TableName $updatable $.Pk $.Reserved $.SoftDelete }}
+	m, err := db.ExecContext(ctx, {{ backQuote }}{{ $update.SQL }}{{ backQuote }}, {{ args $update "target" }})
+	if err != nil {
+		return 0, translate(err)
+	}
+	c, err := m.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if c == 0 {
+		return 0, ErrNotFound
+	}
+	return c, nil
+}
+{{- end }}
 {{ end }}
+{{- if $.Pk }}
+{{- if $.SoftDelete }}
+// Delete marks the row deleted by setting {{ $.SoftDelete }}; use HardDelete to remove it.
+{{- if $.Version }}
+// It applies only while the stored {{ $.Version }} still equals {{ $.Version }} and returns ErrConflict otherwise.
+{{- end }}
+// It returns ErrNotFound when {{ $gone }}.
+func (d {{.TableName }}Dao) Delete(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}{{ if $.Version }}, {{ argumentPk (list $.Version) $.DataTypes }}{{ end }}) (int64, error) {
+	{{- $delete := softDelete $.TableName $.Pk $.SoftDelete $.Version (index $.DataTypes $.Version) $.Reserved }}
+	m, err := db.ExecContext(ctx, {{ backQuote }}{{ $delete.SQL }}{{ backQuote }}, {{ args $delete "" }})
+	if err != nil {
+		return 0, translate(err)
+	}
+	c, err := m.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if c == 0 {
+{{- if $.Version }}
+		return 0, d.conflict(ctx, db, {{ pkLiner $.Pk }})
+{{- else }}
+		return 0, ErrNotFound
+{{- end }}
+	}
+	return c, nil
+}
 
-func (d {{.TableName }}Dao) Delete(db *sql.DB, {{ argumentPk $.Pk $.DataTypes }}) (int64, error) {
-	m, err := db.Exec({{ backQuote }}{{ delete $.TableName $.Pk }}{{ backQuote }}, {{ pkLiner $.Pk }})
+// HardDelete removes the row whether or not it is soft deleted.
+func (d {{.TableName }}Dao) HardDelete(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (int64, error) {
+	{{- $delete := delete $.TableName $.Pk $.Reserved }}
+	m, err := db.ExecContext(ctx, {{ backQuote }}{{ $delete.SQL }}{{ backQuote }}, {{ args $delete "" }})
+	if err != nil {
+		return 0, translate(err)
+	}
+	c, err := m.RowsAffected()
 	if err != nil {
 		return 0, err
 	}
+	if c == 0 {
+		return 0, ErrNotFound
+	}
+	return c, nil
+}
+
+// Restore clears {{ $.SoftDelete }}; it returns ErrNotFound when no soft deleted row has the primary key.
+func (d {{.TableName }}Dao) Restore(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (int64, error) {
+	{{- $restore := restore $.TableName $.Pk $.SoftDelete $.Reserved }}
+	m, err := db.ExecContext(ctx, {{ backQuote }}{{ $restore.SQL }}{{ backQuote }}, {{ args $restore "" }})
+	if err != nil {
+		return 0, translate(err)
+	}
 	c, err := m.RowsAffected()
 	if err != nil {
 		return 0, err
 	}
+	if c == 0 {
+		return 0, ErrNotFound
+	}
 	return c, nil
 }
+{{- else if $.Version }}
+// Delete removes the row only while the stored {{ $.Version }} still equals {{ $.Version }}.
+// It returns ErrConflict when the row changed in the meantime and ErrNotFound when it is gone.
+func (d {{.TableName }}Dao) Delete(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}, {{ argumentPk (list $.Version) $.DataTypes }}) (int64, error) {
+	{{- $delete := lockedDelete $.TableName $.Pk $.Version $.Reserved }}
+	m, err := db.ExecContext(ctx, {{ backQuote }}{{ $delete.SQL }}{{ backQuote }}, {{ args $delete "" }})
+	if err != nil {
+		return 0, translate(err)
+	}
+	c, err := m.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if c == 0 {
+		return 0, d.conflict(ctx, db, {{ pkLiner $.Pk }})
+	}
+	return c, nil
+}
+{{- else }}
+// Delete returns ErrNotFound when no row has the primary key.
+func (d {{.TableName }}Dao) Delete(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (int64, error) {
+	{{- $delete := delete $.TableName $.Pk $.Reserved }}
+	m, err := db.ExecContext(ctx, {{ backQuote }}{{ $delete.SQL }}{{ backQuote }}, {{ args $delete "" }})
+	if err != nil {
+		return 0, translate(err)
+	}
+	c, err := m.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if c == 0 {
+		return 0, ErrNotFound
+	}
+	return c, nil
+}
+{{- end }}
+{{- if $.Version }}
+
+// conflict explains why a versioned write matched no row.
+func (d {{.TableName }}Dao) conflict(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) error {
+	exists, err := d.Exists(ctx, db, {{ pkLiner $.Pk }})
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrConflict
+	}
+	return ErrNotFound
+}
+{{- end }}
 
-func (d {{.TableName }}Dao) Get(db *sql.DB, {{ argumentPk $.Pk $.DataTypes }}) (*{{.TableName}}, error) {
-	m := db.QueryRow({{ backQuote }}{{ select $.TableName $.Columns $.Pk $.Reserved }}{{ backQuote }}, {{ listLiner $.Pk }})
-	if err := m.Err(); err != nil {
-		return nil, err
+// Get returns ErrNotFound when {{ $gone }}.
+func (d {{.TableName }}Dao) Get(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (*{{.TableName}}, error) {
+	{{- $select := select $.TableName $.Columns $.Pk $.Reserved $.SoftDelete }}
+	m := db.QueryRowContext(ctx, {{ backQuote }}{{ $select.SQL }}{{ backQuote }}, {{ args $select "" }})
+	var resp {{.TableName}}
+	if err := m.Scan({{ scan $.Columns "resp" }}); err != nil {
+		return nil, translate(err)
 	}
+	return &resp, nil
+}
+{{- if $.SoftDelete }}
+
+// GetIncludeDeleted is Get that also returns soft deleted rows.
+func (d {{.TableName }}Dao) GetIncludeDeleted(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (*{{.TableName}}, error) {
+	{{- $select := select $.TableName $.Columns $.Pk $.Reserved }}
+	m := db.QueryRowContext(ctx, {{ backQuote }}{{ $select.SQL }}{{ backQuote }}, {{ args $select "" }})
 	var resp {{.TableName}}
 	if err := m.Scan({{ scan $.Columns "resp" }}); err != nil {
-		return nil, err
+		return nil, translate(err)
 	}
 	return &resp, nil
 }
-`
+{{- end }}
+
+// Exists reports whether {{ if $.SoftDelete }}a live{{ else }}a{{ end }} row has the primary key.
+func (d {{.TableName }}Dao) Exists(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (bool, error) {
+	{{- $exists := exists $.TableName $.Pk $.Reserved $.SoftDelete }}
+	var exists bool
+	if err := db.QueryRowContext(ctx, {{ backQuote }}{{ $exists.SQL }}{{ backQuote }}, {{ args $exists "" }}).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+{{ end }}
+{{- if $.Columns }}
+{{ template "PostgresList" $ }}
+{{- if $.Pk }}
+
+func (t {{ $.TableName }}) Cursor() {{ $.TableName }}Cursor {
+	return {{ $.TableName }}Cursor{
+{{- range $.Pk }}
+		{{ field . }}: t.{{ . }},
+{{- end }}
+	}
+}
+{{- end }}
+
+func (d {{ $.TableName }}Dao) List(ctx context.Context, db DBTX, opts {{ $.TableName }}ListOptions) ([]{{ $.TableName }}, error) {
+	resp := make([]{{ $.TableName }}, 0)
+	for row, err := range d.Iter(ctx, db, opts) {
+		if err != nil {
+			return nil, err
+		}
+		resp = append(resp, row)
+	}
+	return resp, nil
+}
+
+// Iter streams the rows List would return; breaking out of the loop closes the result set.
+func (d {{ $.TableName }}Dao) Iter(ctx context.Context, db DBTX, opts {{ $.TableName }}ListOptions) iter.Seq2[{{ $.TableName }}, error] {
+	return func(yield func({{ $.TableName }}, error) bool) {
+		query, args, err := opts.query()
+		if err != nil {
+			yield({{ $.TableName }}{}, err)
+			return
+		}
+		rows, err := db.QueryContext(ctx, query, args...)
+		if err != nil {
+			yield({{ $.TableName }}{}, err)
+			return
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var row {{ $.TableName }}
+			if err := rows.Scan({{ scan $.Columns "row" }}); err != nil {
+				yield({{ $.TableName }}{}, err)
+				return
+			}
+			if !yield(row, nil) {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			yield({{ $.TableName }}{}, err)
+		}
+	}
+}
+
+func (d {{ $.TableName }}Dao) Count(ctx context.Context, db DBTX, filter {{ $.TableName }}Filter) (int64, error) {
+	{{- $count := count $.TableName $.Reserved }}
+	c := filter.conditions()
+	var count int64
+	if err := db.QueryRowContext(ctx, {{ backQuote }}{{ $count.SQL }}{{ backQuote }}+c.String(), c.args...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+{{ end }}`
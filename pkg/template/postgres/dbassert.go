@@ -0,0 +1,405 @@
+package postgres
+
+const DBAssertPostgresTemplate = `package dbassert
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"gopkg.in/yaml.v3"
+)
+
+// update rewrites golden files instead of comparing with them. The flag is named after
+// the package so it does not clash with an -update flag of the tests importing it.
+var update = flag.Bool("dbassert.update", false, "rewrite the golden files of dbassert")
+
+// Updating reports whether golden files are being rewritten.
+func Updating() bool {
+	return *update
+}
+
+// Querier is satisfied by *sql.DB, *sql.Tx, *sql.Conn and the DBTX of the DAOs.
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Row is a table row keyed by column.
+type Row = map[string]any
+
+type table struct {
+	pk []string
+	// types names the database type of each column, such as "timestamp".
+	types map[string]string
+}
+
+var tables = map[string]table{
+{{- range $table := $.Tables }}
+	{{ printf "%q" $table.TableName }}: {
+		pk: []string{ {{- range $i, $column := $table.Pk }}{{ if $i }}, {{ end }}{{ printf "%q" $column }}{{ end -}} },
+		types: map[string]string{
+{{- range $column := $table.Columns }}
+			{{ printf "%q" $column }}: {{ printf "%q" (index $table.DatabaseTypes $column) }},
+{{- end }}
+		},
+	},
+{{- end }}
+}
+
+// Rows asserts the rows of name are expected, in any order. Only the columns set in
+// the expected rows are compared, so generated keys and timestamps can be left out;
+// values are compared as the column type holds them, so an int matches a bigint.
+func Rows(t testing.TB, db Querier, name string, expected []Row) {
+	t.Helper()
+	tb, ok := tables[name]
+	if !ok {
+		t.Fatalf("dbassert: unknown table %q", name)
+	}
+	compared := make(map[string]struct{})
+	for _, row := range expected {
+		for column := range row {
+			if _, ok := tb.types[column]; !ok {
+				t.Fatalf("dbassert: unknown column %s.%s", name, column)
+			}
+			compared[column] = struct{}{}
+		}
+	}
+	columns := sortedKeys(compared)
+	want := make([]string, 0, len(expected))
+	for i, row := range expected {
+		normalized := make(Row, len(row))
+		for _, column := range columns {
+			value, ok := row[column]
+			if !ok {
+				t.Fatalf("dbassert: expected row %d of %s lacks column %q set in other rows", i, name, column)
+			}
+			v, err := normalize(tb.types[column], value)
+			if err != nil {
+				t.Fatalf("dbassert: expected row %d of %s: %s: %v", i, name, column, err)
+			}
+			normalized[column] = v
+		}
+		want = append(want, render(columns, normalized))
+	}
+	rows, err := selectRows(db, name, tb)
+	if err != nil {
+		t.Fatalf("dbassert: %v", err)
+	}
+	got := make([]string, 0, len(rows))
+	for _, row := range rows {
+		got = append(got, render(columns, row))
+	}
+	sort.Strings(want)
+	sort.Strings(got)
+	if diff := diffLines(want, got); diff != "" {
+		t.Errorf("dbassert: rows of %s differ (-expected +actual):\n%s", name, diff)
+	}
+}
+
+// Golden asserts the rows of names match the golden file of the test, which
+// -dbassert.update writes from the database. Golden files are YAML under testdata,
+// named after the test.
+func Golden(t testing.TB, db Querier, names ...string) {
+	t.Helper()
+	dump := make(map[string][]Row, len(names))
+	for _, name := range names {
+		tb, ok := tables[name]
+		if !ok {
+			t.Fatalf("dbassert: unknown table %q", name)
+		}
+		rows, err := selectRows(db, name, tb)
+		if err != nil {
+			t.Fatalf("dbassert: %v", err)
+		}
+		dump[name] = rows
+	}
+	got, err := yaml.Marshal(dump)
+	if err != nil {
+		t.Fatalf("dbassert: %v", err)
+	}
+	path := filepath.Join("testdata", strings.NewReplacer("/", "__", " ", "_").Replace(t.Name())+".yaml")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("dbassert: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("dbassert: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("dbassert: %s is missing; run the test with -dbassert.update to write it", path)
+	}
+	if err != nil {
+		t.Fatalf("dbassert: %v", err)
+	}
+	if diff := diffLines(lines(string(want)), lines(string(got))); diff != "" {
+		t.Errorf("dbassert: tables differ from %s (-golden +actual):\n%s", path, diff)
+	}
+}
+
+// selectRows reads every row of name, by primary key or else by content.
+func selectRows(db Querier, name string, tb table) ([]Row, error) {
+	columns := sortedKeys(tb.types)
+	quoted := make([]string, 0, len(columns))
+	for _, column := range columns {
+		quoted = append(quoted, pq.QuoteIdentifier(column))
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(quoted, ", "), pq.QuoteIdentifier(name))
+	if len(tb.pk) > 0 {
+		order := make([]string, 0, len(tb.pk))
+		for _, column := range tb.pk {
+			order = append(order, pq.QuoteIdentifier(column))
+		}
+		query += " ORDER BY " + strings.Join(order, ", ")
+	}
+	result, err := db.QueryContext(context.Background(), query)
+	if err != nil {
+		return nil, fmt.Errorf("select %s: %w", name, err)
+	}
+	defer result.Close()
+	rows := make([]Row, 0)
+	for result.Next() {
+		values := make([]any, len(columns))
+		dest := make([]any, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := result.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("select %s: %w", name, err)
+		}
+		row := make(Row, len(columns))
+		for i, column := range columns {
+			v, err := normalize(tb.types[column], values[i])
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", name, column, err)
+			}
+			row[column] = v
+		}
+		rows = append(rows, row)
+	}
+	if err := result.Err(); err != nil {
+		return nil, fmt.Errorf("select %s: %w", name, err)
+	}
+	if len(tb.pk) == 0 {
+		sort.Slice(rows, func(i, j int) bool {
+			return render(columns, rows[i]) < render(columns, rows[j])
+		})
+	}
+	return rows, nil
+}
+
+// normalize converts a value read from the database, or expected by a test, to what
+// the column type holds: int64, float64, bool, string, []int64, []string or decoded json.
+// Dates, times, intervals and timestamps become strings, timestamps in UTC and
+// durations as the hours, minutes and seconds Postgres prints.
+func normalize(databaseType string, value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil, nil
+		}
+		value = v.Elem().Interface()
+	}
+	if b, ok := value.([]byte); ok && databaseType != "json" && databaseType != "jsonb" {
+		value = string(b)
+	}
+	v := reflect.ValueOf(value)
+	switch databaseType {
+	case "integer", "bigint", "smallint":
+		switch {
+		case v.CanInt():
+			return v.Int(), nil
+		case v.CanUint():
+			return int64(v.Uint()), nil
+		case v.Kind() == reflect.String:
+			return strconv.ParseInt(v.String(), 10, 64)
+		}
+	case "numeric", "decimal", "real", "double", "double precision":
+		switch {
+		case v.CanFloat():
+			return v.Float(), nil
+		case v.CanInt():
+			return float64(v.Int()), nil
+		case v.CanUint():
+			return float64(v.Uint()), nil
+		case v.Kind() == reflect.String:
+			return strconv.ParseFloat(v.String(), 64)
+		}
+	case "boolean":
+		if v.Kind() == reflect.Bool {
+			return v.Bool(), nil
+		}
+	case "integer[]":
+		if s, ok := value.(string); ok {
+			var array pq.Int64Array
+			if err := array.Scan([]byte(s)); err != nil {
+				return nil, err
+			}
+			value, v = []int64(array), reflect.ValueOf([]int64(array))
+		}
+		if v.Kind() == reflect.Slice {
+			ints := make([]int64, 0, v.Len())
+			for i := 0; i < v.Len(); i++ {
+				n, err := normalize("bigint", v.Index(i).Interface())
+				if err != nil {
+					return nil, err
+				}
+				ints = append(ints, n.(int64))
+			}
+			return ints, nil
+		}
+	case "text[]":
+		if s, ok := value.(string); ok {
+			var array pq.StringArray
+			if err := array.Scan([]byte(s)); err != nil {
+				return nil, err
+			}
+			return []string(array), nil
+		}
+		if v.Kind() == reflect.Slice {
+			strs := make([]string, 0, v.Len())
+			for i := 0; i < v.Len(); i++ {
+				strs = append(strs, fmt.Sprint(v.Index(i).Interface()))
+			}
+			return strs, nil
+		}
+	case "json", "jsonb":
+		encoded, ok := value.([]byte)
+		if s, isString := value.(string); isString {
+			encoded, ok = []byte(s), true
+		}
+		if !ok {
+			var err error
+			if encoded, err = json.Marshal(value); err != nil {
+				return nil, err
+			}
+		}
+		var decoded any
+		if err := json.Unmarshal(encoded, &decoded); err != nil {
+			return nil, err
+		}
+		return decoded, nil
+	case "date":
+		if tm, ok := value.(time.Time); ok {
+			return tm.Format("2006-01-02"), nil
+		}
+		if s, ok := value.(string); ok {
+			return strings.SplitN(s, "T", 2)[0], nil
+		}
+	case "time":
+		if tm, ok := value.(time.Time); ok {
+			return tm.Format("15:04:05.999999"), nil
+		}
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case "interval":
+		// intervals compare in the output of Postgres, such as "1 day 02:00:00" or "26:00:00".
+		if d, ok := value.(time.Duration); ok {
+			sign := ""
+			if d < 0 {
+				sign, d = "-", -d
+			}
+			clock := time.Time{}.Add(d % time.Hour).Format("04:05.999999")
+			return fmt.Sprintf("%s%02d:%s", sign, int64(d/time.Hour), clock), nil
+		}
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case "timestamp":
+		if s, ok := value.(string); ok {
+			parsed, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return nil, err
+			}
+			value = parsed
+		}
+		if tm, ok := value.(time.Time); ok {
+			return tm.UTC().Format(time.RFC3339Nano), nil
+		}
+	default:
+		return fmt.Sprint(value), nil
+	}
+	return nil, fmt.Errorf("%v (%T) does not fit a %s column", value, value, databaseType)
+}
+
+// render writes the columns of row on one line, such as {"body": "root", "id": 10}.
+func render(columns []string, row Row) string {
+	parts := make([]string, 0, len(columns))
+	for _, column := range columns {
+		encoded, err := json.Marshal(row[column])
+		if err != nil {
+			encoded = []byte(fmt.Sprint(row[column]))
+		}
+		parts = append(parts, strconv.Quote(column)+": "+string(encoded))
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+func lines(text string) []string {
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines returns the lines of want and got, marking those only in want with "-" and
+// those only in got with "+"; it is empty when they are equal.
+func diffLines(want, got []string) string {
+	// common[i][j] is the length of the longest common sequence of want[i:] and got[j:].
+	common := make([][]int, len(want)+1)
+	for i := range common {
+		common[i] = make([]int, len(got)+1)
+	}
+	for i := len(want) - 1; i >= 0; i-- {
+		for j := len(got) - 1; j >= 0; j-- {
+			if want[i] == got[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+	if common[0][0] == len(want) && len(want) == len(got) {
+		return ""
+	}
+	var builder strings.Builder
+	i, j := 0, 0
+	for i < len(want) || j < len(got) {
+		switch {
+		case i < len(want) && j < len(got) && want[i] == got[j]:
+			builder.WriteString("  " + want[i] + "\n")
+			i, j = i+1, j+1
+		case j == len(got) || (i < len(want) && common[i+1][j] >= common[i][j+1]):
+			builder.WriteString("- " + want[i] + "\n")
+			i++
+		default:
+			builder.WriteString("+ " + got[j] + "\n")
+			j++
+		}
+	}
+	return builder.String()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+`
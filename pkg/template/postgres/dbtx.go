@@ -0,0 +1,88 @@
+package postgres
+
+const DbtxPostgresTemplate = `package dao
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "github.com/lib/pq"
+)
+
+// DBTX is satisfied by *sql.DB, *sql.Tx and *sql.Conn.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+var (
+	_ DBTX = (*sql.DB)(nil)
+	_ DBTX = (*sql.Tx)(nil)
+	_ DBTX = (*sql.Conn)(nil)
+)
+
+// maxPlaceholders is the bind parameter limit of the Postgres protocol.
+const maxPlaceholders = 65535
+
+// values renders rows of numbered placeholders such as ($1, $2), ($3, $4).
+func values(rows, columns int) string {
+	var builder strings.Builder
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString("(")
+		for j := 0; j < columns; j++ {
+			if j > 0 {
+				builder.WriteString(", ")
+			}
+			fmt.Fprintf(&builder, "$%d", i*columns+j+1)
+		}
+		builder.WriteString(")")
+	}
+	return builder.String()
+}
+
+{{ template "PostgresConditions" }}`
+
+// ConditionsPostgresTemplate renders the WHERE clause builder of List, shared by the modes.
+const ConditionsPostgresTemplate = `// conditions accumulates a WHERE clause and its numbered arguments.
+type conditions struct {
+	clauses []string
+	args    []any
+}
+
+// bind appends arg and returns its placeholder.
+func (c *conditions) bind(arg any) string {
+	c.args = append(c.args, arg)
+	return fmt.Sprintf("$%d", len(c.args))
+}
+
+func (c *conditions) add(clause string) {
+	c.clauses = append(c.clauses, clause)
+}
+
+func (c *conditions) String() string {
+	if len(c.clauses) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(c.clauses, " AND ")
+}
+
+// inList matches column against values; a non-nil empty list matches nothing.
+func inList[T any](c *conditions, column string, values []T) {
+	if len(values) == 0 {
+		c.add("FALSE")
+		return
+	}
+	placeholders := make([]string, 0, len(values))
+	for i := range values {
+		placeholders = append(placeholders, c.bind(values[i]))
+	}
+	c.add(column + " IN (" + strings.Join(placeholders, ", ") + ")")
+}
+`
@@ -0,0 +1,111 @@
+package postgres
+
+const ErrorsPostgresTemplate = `package dao
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// ErrNotFound is returned when no row matches the primary key; it wraps sql.ErrNoRows.
+var ErrNotFound = fmt.Errorf("dao: not found: %w", sql.ErrNoRows)
+
+// ErrConflict is returned when a versioned row changed since it was read.
+var ErrConflict = errors.New("dao: version conflict")
+
+// UniqueViolation is returned when a row collides with a primary key or unique constraint.
+type UniqueViolation struct {
+	Table      string
+	Constraint string
+	Columns    []string
+	Err        *pq.Error
+}
+
+func (e *UniqueViolation) Error() string {
+	return fmt.Sprintf("dao: unique violation on %s %v: %v", e.Table, e.Columns, e.Err)
+}
+
+func (e *UniqueViolation) Unwrap() error {
+	return e.Err
+}
+
+// ForeignKeyViolation is returned when a row references a missing parent or is still referenced.
+type ForeignKeyViolation struct {
+	Table      string
+	Constraint string
+	Columns    []string
+	Err        *pq.Error
+}
+
+func (e *ForeignKeyViolation) Error() string {
+	return fmt.Sprintf("dao: foreign key violation on %s %v: %v", e.Table, e.Columns, e.Err)
+}
+
+func (e *ForeignKeyViolation) Unwrap() error {
+	return e.Err
+}
+
+type NotNullViolation struct {
+	Table  string
+	Column string
+	Err    *pq.Error
+}
+
+func (e *NotNullViolation) Error() string {
+	return fmt.Sprintf("dao: not null violation on %s.%s: %v", e.Table, e.Column, e.Err)
+}
+
+func (e *NotNullViolation) Unwrap() error {
+	return e.Err
+}
+
+// constraints holds the columns of each key constraint by table and constraint name.
+var constraints = map[string]map[string][]string{
+{{- range $.Tables }}
+{{- if .Constraints }}
+	{{ printf "%q" .TableName }}: {
+{{- range $name, $columns := .Constraints }}
+		{{ printf "%q" $name }}: { {{- range $i, $c := $columns }}{{ if $i }}, {{ end }}{{ printf "%q" $c }}{{ end -}} },
+{{- end }}
+	},
+{{- end }}
+{{- end }}
+}
+
+// translate converts sql.ErrNoRows and constraint violations into the errors above.
+func translate(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	switch pqErr.Code.Name() {
+	case "unique_violation":
+		return &UniqueViolation{
+			Table:      pqErr.Table,
+			Constraint: pqErr.Constraint,
+			Columns:    constraints[pqErr.Table][pqErr.Constraint],
+			Err:        pqErr,
+		}
+	case "foreign_key_violation":
+		return &ForeignKeyViolation{
+			Table:      pqErr.Table,
+			Constraint: pqErr.Constraint,
+			Columns:    constraints[pqErr.Table][pqErr.Constraint],
+			Err:        pqErr,
+		}
+	case "not_null_violation":
+		return &NotNullViolation{
+			Table:  pqErr.Table,
+			Column: pqErr.Column,
+			Err:    pqErr,
+		}
+	}
+	return err
+}
+`
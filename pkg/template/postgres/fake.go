@@ -0,0 +1,507 @@
+package postgres
+
+// FakeSharedPostgresTemplate holds the helpers every generated fake repository uses.
+const FakeSharedPostgresTemplate = `package dao
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// fakeUniqueViolation reports a duplicate key the way the database does.
+func fakeUniqueViolation(table, constraint string) error {
+	return translate(&pq.Error{Code: "23505", Table: table, Constraint: constraint})
+}
+
+// fakeEqualPtr matches like a unique constraint, where NULL equals nothing.
+func fakeEqualPtr[T comparable](a, b *T) bool {
+	return a != nil && b != nil && *a == *b
+}
+
+func fakeEqualSlice[S ~[]E, E comparable](a, b S) bool {
+	return a != nil && b != nil && slices.Equal(a, b)
+}
+
+// fakeInPtr matches like IN, where NULL is never in the list.
+func fakeInPtr[T comparable](values []T, v *T) bool {
+	return v != nil && slices.Contains(values, *v)
+}
+
+func fakeCompare[T cmp.Ordered](a, b T) int {
+	return cmp.Compare(a, b)
+}
+
+func fakeCompareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case !a:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// fakeComparePtr sorts NULL after every value as Postgres does in ascending order.
+func fakeComparePtr[T any](a, b *T, compare func(T, T) int) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	default:
+		return compare(*a, *b)
+	}
+}
+
+// fakeNow stands in for CURRENT_TIMESTAMP.
+func fakeNow() string {
+	return time.Now().UTC().Format(time.RFC3339Nano)
+}
+`
+
+const FakePostgresTemplate = `package dao
+
+import (
+	"context"
+	"database/sql"
+	"iter"
+	"slices"
+	"sync"
+)
+{{ $T := $.TableName }}
+{{- $R := printf "%sRepository" (field $.TableName) }}
+{{- $F := printf "Fake%s" $R }}
+{{- $updatable := nonPk $.Columns (list $.SoftDelete) }}
+{{- $live := "" }}{{ if $.SoftDelete }}{{ $live = ", false" }}{{ end }}
+{{- $all := "" }}{{ if $.SoftDelete }}{{ $all = ", true" }}{{ end }}
+// {{ $R }} is implemented by {{ $T }}Dao and {{ $F }}.
+type {{ $R }} interface {
+	Create(ctx context.Context, db DBTX, target {{ $T }}) (int64, error)
+	CreateMany(ctx context.Context, db DBTX, targets []{{ $T }}) (int64, error)
+	CopyFrom(ctx context.Context, tx *sql.Tx, targets []{{ $T }}) (int64, error)
+{{- range $c := conflicts $.Pk $.UniqueKeys }}
+{{- if nonPk (nonPk $.Columns $c.Columns) $.Pk }}
+	Upsert{{ $c.Name }}(ctx context.Context, db DBTX, target {{ $T }}) (int64, error)
+{{- end }}
+	Upsert{{ $c.Name }}DoNothing(ctx context.Context, db DBTX, target {{ $T }}) (int64, error)
+{{- end }}
+{{- if and $.Pk (nonPk (nonPk $updatable $.Pk) (list $.Version)) }}
+	Update(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}, target {{ $T }}) (int64, error)
+{{- end }}
+{{- if $.Pk }}
+	Delete(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}{{ if $.Version }}, {{ argumentPk (list $.Version) $.DataTypes }}{{ end }}) (int64, error)
+{{- if $.SoftDelete }}
+	HardDelete(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (int64, error)
+	Restore(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (int64, error)
+{{- end }}
+	Get(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (*{{ $T }}, error)
+{{- if $.SoftDelete }}
+	GetIncludeDeleted(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (*{{ $T }}, error)
+{{- end }}
+	Exists(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (bool, error)
+{{- end }}
+	List(ctx context.Context, db DBTX, opts {{ $T }}ListOptions) ([]{{ $T }}, error)
+	Iter(ctx context.Context, db DBTX, opts {{ $T }}ListOptions) iter.Seq2[{{ $T }}, error]
+	Count(ctx context.Context, db DBTX, filter {{ $T }}Filter) (int64, error)
+}
+
+var (
+	_ {{ $R }} = {{ $T }}Dao{}
+	_ {{ $R }} = (*{{ $F }})(nil)
+)
+
+// {{ $F }} keeps rows in memory for unit tests. It enforces the primary key and unique
+// constraints like the database and ignores db, foreign keys and other constraints.
+type {{ $F }} struct {
+	mu   sync.RWMutex
+	rows []{{ $T }}
+}
+
+func New{{ $F }}() *{{ $F }} {
+	return &{{ $F }}{}
+}
+
+// clone copies pointers and slices so callers never alias stored rows.
+func (f *{{ $F }}) clone(row {{ $T }}) {{ $T }} {
+{{- range $c := $.Columns }}
+{{- $t := index $.DataTypes $c }}
+{{- if hasPrefix $t "*" }}
+	if row.{{ $c }} != nil {
+		v := *row.{{ $c }}
+		row.{{ $c }} = &v
+	}
+{{- else if or (hasPrefix $t "[]") (hasPrefix $t "pq.") }}
+	row.{{ $c }} = slices.Clone(row.{{ $c }})
+{{- end }}
+{{- end }}
+	return row
+}
+
+// violation returns the error the database raises when row breaks a key held by any row but skip.
+func (f *{{ $F }}) violation(row {{ $T }}, skip int) error {
+	for i := range f.rows {
+		if i == skip {
+			continue
+		}
+{{- if $.Pk }}
+		if {{ range $i, $c := $.Pk }}{{ if $i }} && {{ end }}{{ fakeEqual (index $.DataTypes $c) (printf "f.rows[i].%s" $c) (printf "row.%s" $c) }}{{ end }} {
+			return fakeUniqueViolation({{ printf "%q" $T }}, {{ printf "%q" (primaryKeyName $.Constraints $.UniqueKeys $.Pk) }})
+		}
+{{- end }}
+{{- range $.UniqueKeys }}
+		if {{ range $i, $c := .Columns }}{{ if $i }} && {{ end }}{{ fakeEqual (index $.DataTypes $c) (printf "f.rows[i].%s" $c) (printf "row.%s" $c) }}{{ end }} {
+			return fakeUniqueViolation({{ printf "%q" $T }}, {{ printf "%q" .Name }})
+		}
+{{- end }}
+	}
+	return nil
+}
+
+func (f *{{ $F }}) Create(ctx context.Context, db DBTX, target {{ $T }}) (int64, error) {
+	return f.CreateMany(ctx, db, []{{ $T }}{target})
+}
+
+// CreateMany inserts all targets or, when one breaks a key, none of them.
+func (f *{{ $F }}) CreateMany(ctx context.Context, db DBTX, targets []{{ $T }}) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	size := len(f.rows)
+	for _, target := range targets {
+		if err := f.violation(target, -1); err != nil {
+			f.rows = f.rows[:size]
+			return 0, err
+		}
+		f.rows = append(f.rows, f.clone(target))
+	}
+	return int64(len(targets)), nil
+}
+
+func (f *{{ $F }}) CopyFrom(ctx context.Context, tx *sql.Tx, targets []{{ $T }}) (int64, error) {
+	return f.CreateMany(ctx, nil, targets)
+}
+{{- range $c := conflicts $.Pk $.UniqueKeys }}
+{{- $update := nonPk (nonPk $.Columns $c.Columns) $.Pk }}
+{{- if $update }}
+
+func (f *{{ $F }}) Upsert{{ $c.Name }}(ctx context.Context, db DBTX, target {{ $T }}) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.rows {
+		if {{ range $i, $k := $c.Columns }}{{ if $i }} && {{ end }}{{ fakeEqual (index $.DataTypes $k) (printf "f.rows[i].%s" $k) (printf "target.%s" $k) }}{{ end }} {
+			row := f.rows[i]
+{{- range $update }}
+			row.{{ . }} = target.{{ . }}
+{{- end }}
+			if err := f.violation(row, i); err != nil {
+				return 0, err
+			}
+			f.rows[i] = f.clone(row)
+			return 1, nil
+		}
+	}
+	if err := f.violation(target, -1); err != nil {
+		return 0, err
+	}
+	f.rows = append(f.rows, f.clone(target))
+	return 1, nil
+}
+{{- end }}
+
+func (f *{{ $F }}) Upsert{{ $c.Name }}DoNothing(ctx context.Context, db DBTX, target {{ $T }}) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.rows {
+		if {{ range $i, $k := $c.Columns }}{{ if $i }} && {{ end }}{{ fakeEqual (index $.DataTypes $k) (printf "f.rows[i].%s" $k) (printf "target.%s" $k) }}{{ end }} {
+			return 0, nil
+		}
+	}
+	if err := f.violation(target, -1); err != nil {
+		return 0, err
+	}
+	f.rows = append(f.rows, f.clone(target))
+	return 1, nil
+}
+{{- end }}
+{{- if $.Pk }}
+
+// find returns the index of the row with the primary key, or -1.
+func (f *{{ $F }}) find({{ argumentPk $.Pk $.DataTypes }}{{ if $.SoftDelete }}, includeDeleted bool{{ end }}) int {
+	for i := range f.rows {
+		if {{ range $i, $c := $.Pk }}{{ if $i }} && {{ end }}f.rows[i].{{ $c }} == {{ $c }}{{ end }}{{ if $.SoftDelete }} && (includeDeleted || f.rows[i].{{ $.SoftDelete }} == nil){{ end }} {
+			return i
+		}
+	}
+	return -1
+}
+{{- end }}
+{{- if $.Version }}
+{{- $vt := index $.DataTypes $.Version }}
+
+// bump advances the version of row the way the generated Update does.
+func (f *{{ $F }}) bump(row *{{ $T }}) {
+{{- if eq $vt "int" }}
+	row.{{ $.Version }}++
+{{- else if eq $vt "*int" }}
+	*row.{{ $.Version }}++
+{{- else if hasPrefix $vt "*" }}
+	now := fakeNow()
+	row.{{ $.Version }} = &now
+{{- else }}
+	row.{{ $.Version }} = fakeNow()
+{{- end }}
+}
+{{- end }}
+{{- if and $.Pk (nonPk (nonPk $updatable $.Pk) (list $.Version)) }}
+
+func (f *{{ $F }}) Update(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}, target {{ $T }}) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := f.find({{ pkLiner $.Pk }}{{ $live }})
+	if i < 0 {
+		return 0, ErrNotFound
+	}
+	row := f.rows[i]
+{{- if $.Version }}
+	if !({{ fakeEqual (index $.DataTypes $.Version) (printf "row.%s" $.Version) (printf "target.%s" $.Version) }}) {
+		return 0, ErrConflict
+	}
+{{- end }}
+{{- range nonPk (nonPk $updatable $.Pk) (list $.Version) }}
+	row.{{ . }} = target.{{ . }}
+{{- end }}
+{{- if $.Version }}
+	f.bump(&row)
+{{- end }}
+	if err := f.violation(row, i); err != nil {
+		return 0, err
+	}
+	f.rows[i] = f.clone(row)
+	return 1, nil
+}
+{{- end }}
+{{- if $.Pk }}
+
+func (f *{{ $F }}) Delete(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}{{ if $.Version }}, {{ argumentPk (list $.Version) $.DataTypes }}{{ end }}) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := f.find({{ pkLiner $.Pk }}{{ $live }})
+	if i < 0 {
+		return 0, ErrNotFound
+	}
+{{- if $.Version }}
+	if !({{ fakeEqual (index $.DataTypes $.Version) (printf "f.rows[i].%s" $.Version) (printf "%s" $.Version) }}) {
+		return 0, ErrConflict
+	}
+{{- end }}
+{{- if $.SoftDelete }}
+	now := fakeNow()
+	f.rows[i].{{ $.SoftDelete }} = &now
+{{- if $.Version }}
+	f.bump(&f.rows[i])
+{{- end }}
+{{- else }}
+	f.rows = slices.Delete(f.rows, i, i+1)
+{{- end }}
+	return 1, nil
+}
+{{- if $.SoftDelete }}
+
+func (f *{{ $F }}) HardDelete(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := f.find({{ pkLiner $.Pk }}{{ $all }})
+	if i < 0 {
+		return 0, ErrNotFound
+	}
+	f.rows = slices.Delete(f.rows, i, i+1)
+	return 1, nil
+}
+
+func (f *{{ $F }}) Restore(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := f.find({{ pkLiner $.Pk }}{{ $all }})
+	if i < 0 || f.rows[i].{{ $.SoftDelete }} == nil {
+		return 0, ErrNotFound
+	}
+	f.rows[i].{{ $.SoftDelete }} = nil
+	return 1, nil
+}
+{{- end }}
+
+func (f *{{ $F }}) Get(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (*{{ $T }}, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	i := f.find({{ pkLiner $.Pk }}{{ $live }})
+	if i < 0 {
+		return nil, ErrNotFound
+	}
+	resp := f.clone(f.rows[i])
+	return &resp, nil
+}
+{{- if $.SoftDelete }}
+
+func (f *{{ $F }}) GetIncludeDeleted(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (*{{ $T }}, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	i := f.find({{ pkLiner $.Pk }}{{ $all }})
+	if i < 0 {
+		return nil, ErrNotFound
+	}
+	resp := f.clone(f.rows[i])
+	return &resp, nil
+}
+{{- end }}
+
+func (f *{{ $F }}) Exists(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (bool, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.find({{ pkLiner $.Pk }}{{ $live }}) >= 0, nil
+}
+{{- end }}
+
+// match applies the filter the way its generated WHERE clause does.
+func (f {{ $T }}Filter) match(row {{ $T }}) bool {
+{{- if $.SoftDelete }}
+	if !f.IncludeDeleted && row.{{ $.SoftDelete }} != nil {
+		return false
+	}
+{{- end }}
+{{- range $c := $.Columns }}
+{{- $type := index $.DataTypes $c }}
+{{- $t := trimPrefix $type "*" }}
+{{- if not (hasPrefix $t "pq.") }}
+{{- if hasPrefix $type "*" }}
+	if f.{{ field $c }} != nil && !fakeInPtr(f.{{ field $c }}, row.{{ $c }}) {
+		return false
+	}
+{{- if ne $t "bool" }}
+	if f.{{ field $c }}Gte != nil && (row.{{ $c }} == nil || fakeCompare(*row.{{ $c }}, *f.{{ field $c }}Gte) < 0) {
+		return false
+	}
+	if f.{{ field $c }}Lte != nil && (row.{{ $c }} == nil || fakeCompare(*row.{{ $c }}, *f.{{ field $c }}Lte) > 0) {
+		return false
+	}
+{{- end }}
+{{- else }}
+	if f.{{ field $c }} != nil && !slices.Contains(f.{{ field $c }}, row.{{ $c }}) {
+		return false
+	}
+{{- if ne $t "bool" }}
+	if f.{{ field $c }}Gte != nil && fakeCompare(row.{{ $c }}, *f.{{ field $c }}Gte) < 0 {
+		return false
+	}
+	if f.{{ field $c }}Lte != nil && fakeCompare(row.{{ $c }}, *f.{{ field $c }}Lte) > 0 {
+		return false
+	}
+{{- end }}
+{{- end }}
+{{- end }}
+{{- if hasPrefix $type "*" }}
+	if f.{{ field $c }}IsNull != nil && (row.{{ $c }} == nil) != *f.{{ field $c }}IsNull {
+		return false
+	}
+{{- end }}
+{{- end }}
+	return true
+}
+
+func (o {{ $T }}OrderBy) compare(a, b {{ $T }}) int {
+	switch o {
+{{- range $c := $.Columns }}
+{{- $type := index $.DataTypes $c }}
+{{- if not (hasPrefix $type "pq.") }}
+	case {{ $T }}OrderBy{{ field $c }}Asc:
+		return {{ fakeCompare $type (printf "a.%s" $c) (printf "b.%s" $c) }}
+	case {{ $T }}OrderBy{{ field $c }}Desc:
+		return -{{ fakeCompare $type (printf "a.%s" $c) (printf "b.%s" $c) }}
+{{- end }}
+{{- end }}
+	}
+	return 0
+}
+
+func (f *{{ $F }}) List(ctx context.Context, db DBTX, opts {{ $T }}ListOptions) ([]{{ $T }}, error) {
+	if _, _, err := opts.query(); err != nil {
+		return nil, err
+	}
+	orderBy := opts.OrderBy
+{{- if $.Pk }}
+	if opts.After != nil {
+		orderBy = []{{ $T }}OrderBy{ {{- range $i, $c := $.Pk }}{{ if $i }}, {{ end }}{{ $T }}OrderBy{{ field $c }}Asc{{ end -}} }
+	}
+{{- end }}
+	f.mu.RLock()
+	resp := make([]{{ $T }}, 0, len(f.rows))
+	for _, row := range f.rows {
+		if !opts.Filter.match(row) {
+			continue
+		}
+{{- if $.Pk }}
+		if opts.After != nil && !row.Cursor().after(*opts.After) {
+			continue
+		}
+{{- end }}
+		resp = append(resp, f.clone(row))
+	}
+	f.mu.RUnlock()
+	slices.SortStableFunc(resp, func(a, b {{ $T }}) int {
+		for _, by := range orderBy {
+			if n := by.compare(a, b); n != 0 {
+				return n
+			}
+		}
+		return 0
+	})
+	resp = resp[min(opts.Offset, len(resp)):]
+	if opts.Limit > 0 {
+		resp = resp[:min(opts.Limit, len(resp))]
+	}
+	return resp, nil
+}
+{{- if $.Pk }}
+
+// after reports whether c sorts after cursor in primary key order.
+func (c {{ $T }}Cursor) after(cursor {{ $T }}Cursor) bool {
+{{- range $.Pk }}
+	if n := {{ fakeCompare (index $.DataTypes .) (printf "c.%s" (field .)) (printf "cursor.%s" (field .)) }}; n != 0 {
+		return n > 0
+	}
+{{- end }}
+	return false
+}
+{{- end }}
+
+func (f *{{ $F }}) Iter(ctx context.Context, db DBTX, opts {{ $T }}ListOptions) iter.Seq2[{{ $T }}, error] {
+	return func(yield func({{ $T }}, error) bool) {
+		rows, err := f.List(ctx, db, opts)
+		if err != nil {
+			yield({{ $T }}{}, err)
+			return
+		}
+		for _, row := range rows {
+			if !yield(row, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (f *{{ $F }}) Count(ctx context.Context, db DBTX, filter {{ $T }}Filter) (int64, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	var count int64
+	for _, row := range f.rows {
+		if filter.match(row) {
+			count++
+		}
+	}
+	return count, nil
+}
+`
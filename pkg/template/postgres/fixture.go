@@ -0,0 +1,108 @@
+package postgres
+
+const FixturePostgresTemplate = `package dao
+
+import (
+	"context"
+	"testing"
+{{ range imports $.DataTypes }}
+	"{{ . }}"
+{{- end }}
+)
+{{ $T := $.TableName }}
+{{- $F := printf "%sFixture" (field $.TableName) }}
+{{- $required := false }}
+{{- range $.Columns }}{{ if not (hasPrefix (index $.DataTypes .) "*") }}{{ $required = true }}{{ end }}{{ end }}
+// {{ $F }} builds {{ $T }} rows for the tests of this package.
+type {{ $F }} struct {
+	row {{ $T }}
+{{- range .ForeignKeys }}
+{{- if .References }}
+	// parent{{ association .Column }} inserts the {{ .Table }} row {{ .Column }} references; nil keeps the column as set.
+	parent{{ association .Column }} func(t testing.TB, db DBTX) {{ .Table }}
+{{- end }}
+{{- end }}
+}
+
+// New{{ $F }} fills every NOT NULL column with a fresh valid value and leaves nullable columns NULL.
+{{- range $.ForeignKeys }}
+{{- if not .IsNull }}
+{{- if and .References (ne .Table $T) }}
+// Insert creates the {{ .Table }} row {{ .Column }} references unless one is given.
+{{- else }}
+// {{ .Column }} must be set to an existing {{ .Table }} row before Insert.
+{{- end }}
+{{- end }}
+{{- end }}
+func New{{ $F }}() *{{ $F }} {
+{{- if $required }}
+	n := testSeq()
+{{- end }}
+	return &{{ $F }}{
+		row: {{ $T }}{
+{{- range $c := $.Columns }}
+{{- if not (hasPrefix (index $.DataTypes $c) "*") }}
+			{{ $c }}: {{ testValue (index $.DataTypes $c) (index $.DatabaseTypes $c) (index $.Limits $c) "n" }},
+{{- end }}
+{{- end }}
+		},
+{{- range $.ForeignKeys }}
+{{- if and (not .IsNull) .References (ne .Table $T) }}
+		parent{{ association .Column }}: func(t testing.TB, db DBTX) {{ .Table }} {
+			return New{{ field .Table }}Fixture().Insert(t, db)
+		},
+{{- end }}
+{{- end }}
+	}
+}
+{{ range $c := $.Columns }}
+func (f *{{ $F }}) With{{ field $c }}(v {{ index $.DataTypes $c }}) *{{ $F }} {
+	f.row.{{ $c }} = v
+{{- range $.ForeignKeys }}
+{{- if and .References (eq .Column $c) }}
+	f.parent{{ association .Column }} = nil
+{{- end }}
+{{- end }}
+	return f
+}
+{{ end }}
+{{- range $.ForeignKeys }}
+{{- if .References }}
+{{- $parent := printf "parent.%s" .References }}
+{{- if hasPrefix (index $.DataTypes .Column) "*" }}{{ $parent = printf "testPtr(%s)" $parent }}{{ end }}
+// With{{ association .Column }} references an existing {{ .Table }} row.
+func (f *{{ $F }}) With{{ association .Column }}(parent {{ .Table }}) *{{ $F }} {
+	f.row.{{ .Column }} = {{ $parent }}
+	f.parent{{ association .Column }} = nil
+	return f
+}
+
+// With{{ association .Column }}Fixture inserts parent on Insert and references it.
+func (f *{{ $F }}) With{{ association .Column }}Fixture(parent *{{ field .Table }}Fixture) *{{ $F }} {
+	f.parent{{ association .Column }} = parent.Insert
+	return f
+}
+{{ end }}
+{{- end }}
+// Build returns the row without inserting it or its parents.
+func (f *{{ $F }}) Build() {{ $T }} {
+	return f.row
+}
+
+// Insert creates the parents still missing and then the row with the generated DAO,
+// failing t when it cannot.
+func (f *{{ $F }}) Insert(t testing.TB, db DBTX) {{ $T }} {
+	t.Helper()
+{{- range $.ForeignKeys }}
+{{- if .References }}
+	if f.parent{{ association .Column }} != nil {
+		f.With{{ association .Column }}(f.parent{{ association .Column }}(t, db))
+	}
+{{- end }}
+{{- end }}
+	if _, err := ({{ $T }}Dao{}).Create(context.Background(), db, f.row); err != nil {
+		t.Fatalf("insert {{ $T }} fixture: %v", err)
+	}
+	return f.row
+}
+`
@@ -0,0 +1,26 @@
+package postgres
+
+const ModelPostgresGormTemplate = `package dao
+{{ with imports $.DataTypes }}
+import (
+{{- range . }}
+	"{{ . }}"
+{{- end }}
+)
+{{ end }}
+type {{ .TableName }} struct {
+	{{- range $key, $value := .DataTypes }}
+	{{ field $key }} {{ $value }} {{ backQuote }}gorm:"column:{{ $key }}
+		{{- if in $key $.Pk }};primaryKey{{ end }}
+		{{- if hasPrefix $value "pq." }}{{ with index $.DatabaseTypes $key }};type:{{ . }}{{ end }}{{ end }}
+		{{- with index $.Defaults $key }}{{ if hasPrefix . "nextval(" }};autoIncrement{{ else }}{{ with gormDefault . (index $.DataTypes $key) }};default:{{ . }}{{ end }}{{ end }}{{ end }}"{{ backQuote }}
+	{{- end }}
+	{{- range $.ForeignKeys }}
+	{{ association .Column }} *{{ .Table }} {{ backQuote }}gorm:"foreignKey:{{ field .Column }}"{{ backQuote }}
+	{{- end }}
+}
+
+func ({{ .TableName }}) TableName() string {
+	return "{{ .TableName }}"
+}
+`
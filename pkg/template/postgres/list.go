@@ -0,0 +1,134 @@
+package postgres
+
+// ListPostgresTemplate renders the filter, order and options of List, shared by the modes;
+// each mode runs the query of the options with its own driver.
+const ListPostgresTemplate = `// {{ $.TableName }}Filter narrows List; nil fields are ignored.
+type {{ $.TableName }}Filter struct {
+{{- if $.SoftDelete }}
+	// IncludeDeleted also matches rows whose {{ $.SoftDelete }} is set.
+	IncludeDeleted bool
+{{- end }}
+{{- range $c := $.Columns }}
+{{- $t := filterType (index $.DataTypes $c) }}
+{{- if $t }}
+	{{ field $c }} []{{ $t }}
+{{- if ne $t "bool" }}
+	{{ field $c }}Gte *{{ $t }}
+	{{ field $c }}Lte *{{ $t }}
+{{- end }}
+{{- end }}
+{{- if nullable (index $.DataTypes $c) }}
+	{{ field $c }}IsNull *bool
+{{- end }}
+{{- end }}
+}
+
+func (f {{ $.TableName }}Filter) conditions() *conditions {
+	c := &conditions{}
+{{- if $.SoftDelete }}
+	if !f.IncludeDeleted {
+		c.add({{ printf "%q" (printf "%s IS NULL" (ident $.SoftDelete $.Reserved)) }})
+	}
+{{- end }}
+{{- range $c := $.Columns }}
+{{- $t := filterType (index $.DataTypes $c) }}
+{{- if $t }}
+	if f.{{ field $c }} != nil {
+		inList(c, {{ printf "%q" (ident $c $.Reserved) }}, f.{{ field $c }})
+	}
+{{- if ne $t "bool" }}
+	if f.{{ field $c }}Gte != nil {
+		c.add({{ printf "%q" (printf "%s >= " (ident $c $.Reserved)) }} + c.bind(*f.{{ field $c }}Gte))
+	}
+	if f.{{ field $c }}Lte != nil {
+		c.add({{ printf "%q" (printf "%s <= " (ident $c $.Reserved)) }} + c.bind(*f.{{ field $c }}Lte))
+	}
+{{- end }}
+{{- end }}
+{{- if nullable (index $.DataTypes $c) }}
+	if f.{{ field $c }}IsNull != nil {
+		if *f.{{ field $c }}IsNull {
+			c.add({{ printf "%q" (printf "%s IS NULL" (ident $c $.Reserved)) }})
+		} else {
+			c.add({{ printf "%q" (printf "%s IS NOT NULL" (ident $c $.Reserved)) }})
+		}
+	}
+{{- end }}
+{{- end }}
+	return c
+}
+
+type {{ $.TableName }}OrderBy string
+
+const (
+{{- range $c := $.Columns }}
+{{- if filterType (index $.DataTypes $c) }}
+	{{ $.TableName }}OrderBy{{ field $c }}Asc  {{ $.TableName }}OrderBy = {{ printf "%q" (printf "%s ASC" (ident $c $.Reserved)) }}
+	{{ $.TableName }}OrderBy{{ field $c }}Desc {{ $.TableName }}OrderBy = {{ printf "%q" (printf "%s DESC" (ident $c $.Reserved)) }}
+{{- end }}
+{{- end }}
+)
+
+func (o {{ $.TableName }}OrderBy) valid() bool {
+	switch o {
+{{- range $c := $.Columns }}
+{{- if filterType (index $.DataTypes $c) }}
+	case {{ $.TableName }}OrderBy{{ field $c }}Asc, {{ $.TableName }}OrderBy{{ field $c }}Desc:
+		return true
+{{- end }}
+{{- end }}
+	}
+	return false
+}
+{{ if $.Pk }}
+// {{ $.TableName }}Cursor is the primary key List resumes after.
+type {{ $.TableName }}Cursor struct {
+{{- range $.Pk }}
+	{{ field . }} {{ index $.DataTypes . }}
+{{- end }}
+}
+{{ end }}
+type {{ $.TableName }}ListOptions struct {
+	Filter  {{ $.TableName }}Filter
+	OrderBy []{{ $.TableName }}OrderBy
+	Limit   int
+	Offset  int
+{{- if $.Pk }}
+	// After pages in primary key order past the cursor and cannot be combined with OrderBy.
+	After *{{ $.TableName }}Cursor
+{{- end }}
+}
+
+func (o {{ $.TableName }}ListOptions) query() (string, []any, error) {
+	{{- $select := select $.TableName $.Columns nil $.Reserved }}
+	c := o.Filter.conditions()
+	orderBy := make([]string, 0, len(o.OrderBy))
+	for _, by := range o.OrderBy {
+		if !by.valid() {
+			return "", nil, fmt.Errorf("unknown order: %q", by)
+		}
+		orderBy = append(orderBy, string(by))
+	}
+{{- if $.Pk }}
+	if o.After != nil {
+		if len(orderBy) > 0 {
+			return "", nil, fmt.Errorf("keyset pagination cannot be combined with order: %v", o.OrderBy)
+		}
+		c.add({{ printf "%q" (printf "(%s) > (" (join (idents $.Pk $.Reserved) ", ")) }}{{ range $i, $c := $.Pk }}{{ if $i }} + ", "{{ end }} + c.bind(o.After.{{ field $c }}){{ end }} + ")")
+{{- range $.Pk }}
+		orderBy = append(orderBy, {{ printf "%q" (printf "%s ASC" (ident . $.Reserved)) }})
+{{- end }}
+	}
+{{- end }}
+	query := {{ backQuote }}{{ $select.SQL }}{{ backQuote }} + c.String()
+	if len(orderBy) > 0 {
+		query += " ORDER BY " + strings.Join(orderBy, ", ")
+	}
+	if o.Limit > 0 {
+		query += " LIMIT " + c.bind(o.Limit)
+	}
+	if o.Offset > 0 {
+		query += " OFFSET " + c.bind(o.Offset)
+	}
+	return query, c.args, nil
+}`
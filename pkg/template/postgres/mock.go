@@ -0,0 +1,341 @@
+package postgres
+
+// MockSharedPostgresTemplate holds the expectation bookkeeping every generated mock uses.
+const MockSharedPostgresTemplate = `package dao
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// TestingT is the part of *testing.T mocks report through.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// Matcher decides whether a mock accepts an argument.
+type Matcher interface {
+	Match(arg any) bool
+	String() string
+}
+
+type anyMatcher struct{}
+
+func (anyMatcher) Match(any) bool { return true }
+
+func (anyMatcher) String() string { return "any" }
+
+// Any accepts every argument.
+func Any() Matcher {
+	return anyMatcher{}
+}
+
+type eqMatcher struct {
+	expected any
+}
+
+func (m eqMatcher) Match(arg any) bool { return reflect.DeepEqual(m.expected, arg) }
+
+func (m eqMatcher) String() string { return fmt.Sprintf("%+v", m.expected) }
+
+// Eq accepts arguments deeply equal to expected. Expect methods wrap plain values with it.
+func Eq(expected any) Matcher {
+	return eqMatcher{expected: expected}
+}
+
+type funcMatcher[T any] struct {
+	match func(T) bool
+}
+
+func (m funcMatcher[T]) Match(arg any) bool {
+	v, ok := arg.(T)
+	return ok && m.match(v)
+}
+
+func (m funcMatcher[T]) String() string { return fmt.Sprintf("matching %T", *new(T)) }
+
+// Match accepts arguments of type T for which match returns true.
+func Match[T any](match func(T) bool) Matcher {
+	return funcMatcher[T]{match: match}
+}
+
+// mockExpectation is one expected call and what it returns.
+type mockExpectation struct {
+	method  string
+	args    []Matcher
+	returns any
+	// times is how often the call is expected; a negative value allows any number.
+	times int
+	calls int
+}
+
+func (e *mockExpectation) match(method string, args []any) bool {
+	if e.method != method || len(e.args) != len(args) || (e.times >= 0 && e.calls >= e.times) {
+		return false
+	}
+	for i := range args {
+		if !e.args[i].Match(args[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *mockExpectation) String() string {
+	args := make([]string, 0, len(e.args))
+	for i := range e.args {
+		args = append(args, e.args[i].String())
+	}
+	return fmt.Sprintf("%s(%s)", e.method, strings.Join(args, ", "))
+}
+
+type mockReturn[R any] struct {
+	value R
+	err   error
+}
+
+// MockCall configures an expectation registered by an Expect method.
+type MockCall[R any] struct {
+	mock        *mock
+	expectation *mockExpectation
+}
+
+// Return sets the results of the expected call.
+func (c *MockCall[R]) Return(value R, err error) *MockCall[R] {
+	c.mock.mu.Lock()
+	defer c.mock.mu.Unlock()
+	c.expectation.returns = mockReturn[R]{value: value, err: err}
+	return c
+}
+
+// Times expects the call n times instead of once.
+func (c *MockCall[R]) Times(n int) *MockCall[R] {
+	c.mock.mu.Lock()
+	defer c.mock.mu.Unlock()
+	c.expectation.times = n
+	return c
+}
+
+// AnyTimes allows the call any number of times, including none.
+func (c *MockCall[R]) AnyTimes() *MockCall[R] {
+	return c.Times(-1)
+}
+
+// mock records expectations and the calls that did not match any of them.
+type mock struct {
+	mu           sync.Mutex
+	expectations []*mockExpectation
+	unexpected   []string
+}
+
+func expect[R any](m *mock, method string, args ...any) *MockCall[R] {
+	matchers := make([]Matcher, 0, len(args))
+	for i := range args {
+		matcher, ok := args[i].(Matcher)
+		if !ok {
+			matcher = Eq(args[i])
+		}
+		matchers = append(matchers, matcher)
+	}
+	expectation := &mockExpectation{method: method, args: matchers, times: 1}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expectations = append(m.expectations, expectation)
+	return &MockCall[R]{mock: m, expectation: expectation}
+}
+
+// called returns the results of the first expectation matching the call, in the order expected.
+func called[R any](m *mock, method string, args ...any) (R, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, expectation := range m.expectations {
+		if !expectation.match(method, args) {
+			continue
+		}
+		expectation.calls++
+		returns, _ := expectation.returns.(mockReturn[R])
+		return returns.value, returns.err
+	}
+	values := make([]string, 0, len(args))
+	for i := range args {
+		values = append(values, fmt.Sprintf("%+v", args[i]))
+	}
+	call := fmt.Sprintf("%s(%s)", method, strings.Join(values, ", "))
+	m.unexpected = append(m.unexpected, call)
+	var zero R
+	return zero, fmt.Errorf("dao: unexpected call %s", call)
+}
+
+func (m *mock) assertExpectations(t TestingT) {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, expectation := range m.expectations {
+		if expectation.times >= 0 && expectation.calls != expectation.times {
+			t.Errorf("dao: expected call %s %d times, got %d", expectation, expectation.times, expectation.calls)
+		}
+	}
+	for _, call := range m.unexpected {
+		t.Errorf("dao: unexpected call %s", call)
+	}
+}
+`
+
+const MockPostgresTemplate = `package dao
+
+import (
+	"context"
+	"database/sql"
+	"iter"
+)
+{{ $T := $.TableName }}
+{{- $M := printf "Mock%sRepository" (field $.TableName) }}
+{{- $updatable := nonPk $.Columns (list $.SoftDelete) }}
+// {{ $M }} answers calls with the results of matching expectations. Expect methods take a
+// value or a Matcher per argument; ctx and db are not matched.
+type {{ $M }} struct {
+	mock mock
+}
+
+var _ {{ field $T }}Repository = (*{{ $M }})(nil)
+
+func New{{ $M }}() *{{ $M }} {
+	return &{{ $M }}{}
+}
+
+// AssertExpectations fails t for every expected call not made and every unexpected call.
+func (m *{{ $M }}) AssertExpectations(t TestingT) {
+	t.Helper()
+	m.mock.assertExpectations(t)
+}
+
+func (m *{{ $M }}) ExpectCreate(target any) *MockCall[int64] {
+	return expect[int64](&m.mock, "Create", target)
+}
+
+func (m *{{ $M }}) Create(ctx context.Context, db DBTX, target {{ $T }}) (int64, error) {
+	return called[int64](&m.mock, "Create", target)
+}
+
+func (m *{{ $M }}) ExpectCreateMany(targets any) *MockCall[int64] {
+	return expect[int64](&m.mock, "CreateMany", targets)
+}
+
+func (m *{{ $M }}) CreateMany(ctx context.Context, db DBTX, targets []{{ $T }}) (int64, error) {
+	return called[int64](&m.mock, "CreateMany", targets)
+}
+
+func (m *{{ $M }}) ExpectCopyFrom(targets any) *MockCall[int64] {
+	return expect[int64](&m.mock, "CopyFrom", targets)
+}
+
+func (m *{{ $M }}) CopyFrom(ctx context.Context, tx *sql.Tx, targets []{{ $T }}) (int64, error) {
+	return called[int64](&m.mock, "CopyFrom", targets)
+}
+{{- range $c := conflicts $.Pk $.UniqueKeys }}
+{{- range $suffix := list "" "DoNothing" }}
+{{- if or $suffix (nonPk (nonPk $.Columns $c.Columns) $.Pk) }}
+{{- $name := printf "Upsert%s%s" $c.Name $suffix }}
+
+func (m *{{ $M }}) Expect{{ $name }}(target any) *MockCall[int64] {
+	return expect[int64](&m.mock, {{ printf "%q" $name }}, target)
+}
+
+func (m *{{ $M }}) {{ $name }}(ctx context.Context, db DBTX, target {{ $T }}) (int64, error) {
+	return called[int64](&m.mock, {{ printf "%q" $name }}, target)
+}
+{{- end }}
+{{- end }}
+{{- end }}
+{{- if and $.Pk (nonPk (nonPk $updatable $.Pk) (list $.Version)) }}
+
+func (m *{{ $M }}) ExpectUpdate({{ pkLiner $.Pk }}, target any) *MockCall[int64] {
+	return expect[int64](&m.mock, "Update", {{ pkLiner $.Pk }}, target)
+}
+
+func (m *{{ $M }}) Update(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}, target {{ $T }}) (int64, error) {
+	return called[int64](&m.mock, "Update", {{ pkLiner $.Pk }}, target)
+}
+{{- end }}
+{{- if $.Pk }}
+{{- $delete := pkLiner $.Pk }}{{ if $.Version }}{{ $delete = printf "%s, %s" $delete $.Version }}{{ end }}
+
+func (m *{{ $M }}) ExpectDelete({{ $delete }} any) *MockCall[int64] {
+	return expect[int64](&m.mock, "Delete", {{ $delete }})
+}
+
+func (m *{{ $M }}) Delete(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}{{ if $.Version }}, {{ argumentPk (list $.Version) $.DataTypes }}{{ end }}) (int64, error) {
+	return called[int64](&m.mock, "Delete", {{ $delete }})
+}
+{{- if $.SoftDelete }}
+{{- range $name := list "HardDelete" "Restore" }}
+
+func (m *{{ $M }}) Expect{{ $name }}({{ pkLiner $.Pk }} any) *MockCall[int64] {
+	return expect[int64](&m.mock, {{ printf "%q" $name }}, {{ pkLiner $.Pk }})
+}
+
+func (m *{{ $M }}) {{ $name }}(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (int64, error) {
+	return called[int64](&m.mock, {{ printf "%q" $name }}, {{ pkLiner $.Pk }})
+}
+{{- end }}
+{{- end }}
+{{- range $name := list "Get" (and $.SoftDelete "GetIncludeDeleted") }}
+{{- if $name }}
+
+func (m *{{ $M }}) Expect{{ $name }}({{ pkLiner $.Pk }} any) *MockCall[*{{ $T }}] {
+	return expect[*{{ $T }}](&m.mock, {{ printf "%q" $name }}, {{ pkLiner $.Pk }})
+}
+
+func (m *{{ $M }}) {{ $name }}(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (*{{ $T }}, error) {
+	return called[*{{ $T }}](&m.mock, {{ printf "%q" $name }}, {{ pkLiner $.Pk }})
+}
+{{- end }}
+{{- end }}
+
+func (m *{{ $M }}) ExpectExists({{ pkLiner $.Pk }} any) *MockCall[bool] {
+	return expect[bool](&m.mock, "Exists", {{ pkLiner $.Pk }})
+}
+
+func (m *{{ $M }}) Exists(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (bool, error) {
+	return called[bool](&m.mock, "Exists", {{ pkLiner $.Pk }})
+}
+{{- end }}
+
+func (m *{{ $M }}) ExpectList(opts any) *MockCall[[]{{ $T }}] {
+	return expect[[]{{ $T }}](&m.mock, "List", opts)
+}
+
+func (m *{{ $M }}) List(ctx context.Context, db DBTX, opts {{ $T }}ListOptions) ([]{{ $T }}, error) {
+	return called[[]{{ $T }}](&m.mock, "List", opts)
+}
+
+// ExpectIter returns the rows Iter yields, followed by err when it is set.
+func (m *{{ $M }}) ExpectIter(opts any) *MockCall[[]{{ $T }}] {
+	return expect[[]{{ $T }}](&m.mock, "Iter", opts)
+}
+
+func (m *{{ $M }}) Iter(ctx context.Context, db DBTX, opts {{ $T }}ListOptions) iter.Seq2[{{ $T }}, error] {
+	return func(yield func({{ $T }}, error) bool) {
+		rows, err := called[[]{{ $T }}](&m.mock, "Iter", opts)
+		for _, row := range rows {
+			if !yield(row, nil) {
+				return
+			}
+		}
+		if err != nil {
+			yield({{ $T }}{}, err)
+		}
+	}
+}
+
+func (m *{{ $M }}) ExpectCount(filter any) *MockCall[int64] {
+	return expect[int64](&m.mock, "Count", filter)
+}
+
+func (m *{{ $M }}) Count(ctx context.Context, db DBTX, filter {{ $T }}Filter) (int64, error) {
+	return called[int64](&m.mock, "Count", filter)
+}
+`
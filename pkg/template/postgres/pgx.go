@@ -0,0 +1,474 @@
+package postgres
+
+const DaoPostgresPgxTemplate = `package dao
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+{{- range imports $.DataTypes }}
+	"{{ . }}"
+{{- end }}
+)
+
+type {{ .TableName }} struct {
+	{{- range $key, $value := .DataTypes }}
+	{{ $key }} {{ $value }}
+	{{- end }}
+}
+
+type {{ .TableName }}Dao struct {}
+
+func (d {{.TableName }}Dao) scan(row pgx.CollectableRow) ({{ .TableName }}, error) {
+	var resp {{ .TableName }}
+	err := row.Scan({{ scan $.Columns "resp" }})
+	return resp, err
+}
+
+func (d {{.TableName }}Dao) Create(ctx context.Context, db DBTX, target {{ .TableName }}) (int64, error) {
+	{{- $insert := insert $.TableName $.Columns $.Reserved }}
+	tag, err := db.Exec(ctx, {{ backQuote }}{{ $insert.SQL }}{{ backQuote }}, {{ args $insert "target" }})
+	if err != nil {
+		return 0, translate(err)
+	}
+	return tag.RowsAffected(), nil
+}
+{{ if $.Columns }}
+func (d {{.TableName }}Dao) CreateMany(ctx context.Context, db DBTX, targets []{{ .TableName }}) (int64, error) {
+	{{- $insert := insert $.TableName $.Columns $.Reserved }}
+	const columns = {{ len $.Columns }}
+	size := maxPlaceholders / columns
+	var total int64
+	for start := 0; start < len(targets); start += size {
+		chunk := targets[start:min(start+size, len(targets))]
+		args := make([]any, 0, len(chunk)*columns)
+		for _, target := range chunk {
+			args = append(args, {{ args $insert "target" }})
+		}
+		tag, err := db.Exec(ctx, {{ backQuote }}{{ insertValues $.TableName $.Columns $.Reserved }}{{ backQuote }}+values(len(chunk), columns), args...)
+		if err != nil {
+			return total, translate(err)
+		}
+		total += tag.RowsAffected()
+	}
+	return total, nil
+}
+{{ end }}
+func (d {{.TableName }}Dao) CopyFrom(ctx context.Context, db DBTX, targets []{{ .TableName }}) (int64, error) {
+	{{- $insert := insert $.TableName $.Columns $.Reserved }}
+	c, err := db.CopyFrom(ctx, pgx.Identifier{ {{- printf "%q" $.TableName -}} }, {{ printf "%#v" $.Columns }}, pgx.CopyFromSlice(len(targets), func(i int) ([]any, error) {
+		return []any{ {{- args $insert "targets[i]" -}} }, nil
+	}))
+	if err != nil {
+		return c, translate(err)
+	}
+	return c, nil
+}
+{{- range $c := conflicts $.Pk $.UniqueKeys }}
+{{- if nonPk (nonPk $.Columns $c.Columns) $.Pk }}
+func (d {{ $.TableName }}Dao) Upsert{{ $c.Name }}(ctx context.Context, db DBTX, target {{ $.TableName }}) (int64, error) {
+	{{- $upsert := upsert $.TableName $.Columns $c.Columns $.Pk $.Reserved false }}
+	tag, err := db.Exec(ctx, {{ backQuote }}{{ $upsert.SQL }}{{ backQuote }}, {{ args $upsert "target" }})
+	if err != nil {
+		return 0, translate(err)
+	}
+	return tag.RowsAffected(), nil
+}
+{{ end }}
+func (d {{ $.TableName }}Dao) Upsert{{ $c.Name }}DoNothing(ctx context.Context, db DBTX, target {{ $.TableName }}) (int64, error) {
+	{{- $upsert := upsert $.TableName $.Columns $c.Columns $.Pk $.Reserved true }}
+	tag, err := db.Exec(ctx, {{ backQuote }}{{ $upsert.SQL }}{{ backQuote }}, {{ args $upsert "target" }})
+	if err != nil {
+		return 0, translate(err)
+	}
+	return tag.RowsAffected(), nil
+}
+{{ end }}
+{{- $gone := "no row has the primary key" }}
+{{- if $.SoftDelete }}{{ $gone = "no live row has the primary key" }}{{ end }}
+{{- $updatable := nonPk $.Columns (list $.SoftDelete) }}
+{{- if and $.Pk (nonPk (nonPk $updatable $.Pk) (list $.Version)) }}
+{{- if $.Version }}
+// Update applies target only while the stored {{ $.Version }} still equals target.{{ $.Version }} and advances it.
+// It returns ErrConflict when the row changed in the meantime and ErrNotFound when {{ $gone }}.
+func (d {{.TableName }}Dao) Update(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}, target {{ .TableName }}) (int64, error) {
+	{{- $update := lockedUpdate $.TableName $updatable $.Pk $.Version (index $.DataTypes $.Version) $.Reserved $.SoftDelete }}
+	tag, err := db.Exec(ctx, {{ backQuote }}{{ $update.SQL }}{{ backQuote }}, {{ args $update "target" }})
+	if err != nil {
+		return 0, translate(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return 0, d.conflict(ctx, db, {{ pkLiner $.Pk }})
+	}
+	return tag.RowsAffected(), nil
+}
+{{- else }}
+// Update returns ErrNotFound when {{ $gone }}.
+func (d {{.TableName }}Dao) Update(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}, target {{ .TableName }}) (int64, error) {
+	{{- $update := update $.TableName $updatable $.Pk $.Reserved $.SoftDelete }}
+	tag, err := db.Exec(ctx, {{ backQuote }}{{ $update.SQL }}{{ backQuote }}, {{ args $update "target" }})
+	if err != nil {
+		return 0, translate(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return 0, ErrNotFound
+	}
+	return tag.RowsAffected(), nil
+}
+{{- end }}
+{{ end }}
+{{- if $.Pk }}
+{{- if $.SoftDelete }}
+// Delete marks the row deleted by setting {{ $.SoftDelete }}; use HardDelete to remove it.
+{{- if $.Version }}
+// It applies only while the stored {{ $.Version }} still equals {{ $.Version }} and returns ErrConflict otherwise.
+{{- end }}
+// It returns ErrNotFound when {{ $gone }}.
+func (d {{.TableName }}Dao) Delete(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}{{ if $.Version }}, {{ argumentPk (list $.Version) $.DataTypes }}{{ end }}) (int64, error) {
+	{{- $delete := softDelete $.TableName $.Pk $.SoftDelete $.Version (index $.DataTypes $.Version) $.Reserved }}
+	tag, err := db.Exec(ctx, {{ backQuote }}{{ $delete.SQL }}{{ backQuote }}, {{ args $delete "" }})
+	if err != nil {
+		return 0, translate(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return 0, {{ if $.Version }}d.conflict(ctx, db, {{ pkLiner $.Pk }}){{ else }}ErrNotFound{{ end }}
+	}
+	return tag.RowsAffected(), nil
+}
+
+// HardDelete removes the row whether or not it is soft deleted.
+func (d {{.TableName }}Dao) HardDelete(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (int64, error) {
+	{{- $delete := delete $.TableName $.Pk $.Reserved }}
+	tag, err := db.Exec(ctx, {{ backQuote }}{{ $delete.SQL }}{{ backQuote }}, {{ args $delete "" }})
+	if err != nil {
+		return 0, translate(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return 0, ErrNotFound
+	}
+	return tag.RowsAffected(), nil
+}
+
+// Restore clears {{ $.SoftDelete }}; it returns ErrNotFound when no soft deleted row has the primary key.
+func (d {{.TableName }}Dao) Restore(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (int64, error) {
+	{{- $restore := restore $.TableName $.Pk $.SoftDelete $.Reserved }}
+	tag, err := db.Exec(ctx, {{ backQuote }}{{ $restore.SQL }}{{ backQuote }}, {{ args $restore "" }})
+	if err != nil {
+		return 0, translate(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return 0, ErrNotFound
+	}
+	return tag.RowsAffected(), nil
+}
+{{- else if $.Version }}
+// Delete removes the row only while the stored {{ $.Version }} still equals {{ $.Version }}.
+// It returns ErrConflict when the row changed in the meantime and ErrNotFound when it is gone.
+func (d {{.TableName }}Dao) Delete(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}, {{ argumentPk (list $.Version) $.DataTypes }}) (int64, error) {
+	{{- $delete := lockedDelete $.TableName $.Pk $.Version $.Reserved }}
+	tag, err := db.Exec(ctx, {{ backQuote }}{{ $delete.SQL }}{{ backQuote }}, {{ args $delete "" }})
+	if err != nil {
+		return 0, translate(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return 0, d.conflict(ctx, db, {{ pkLiner $.Pk }})
+	}
+	return tag.RowsAffected(), nil
+}
+{{- else }}
+// Delete returns ErrNotFound when no row has the primary key.
+func (d {{.TableName }}Dao) Delete(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (int64, error) {
+	{{- $delete := delete $.TableName $.Pk $.Reserved }}
+	tag, err := db.Exec(ctx, {{ backQuote }}{{ $delete.SQL }}{{ backQuote }}, {{ args $delete "" }})
+	if err != nil {
+		return 0, translate(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return 0, ErrNotFound
+	}
+	return tag.RowsAffected(), nil
+}
+{{- end }}
+{{- if $.Version }}
+
+// conflict explains why a versioned write matched no row.
+func (d {{.TableName }}Dao) conflict(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) error {
+	exists, err := d.Exists(ctx, db, {{ pkLiner $.Pk }})
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrConflict
+	}
+	return ErrNotFound
+}
+{{- end }}
+
+// Get returns ErrNotFound when {{ $gone }}.
+func (d {{.TableName }}Dao) Get(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (*{{.TableName}}, error) {
+	{{- $select := select $.TableName $.Columns $.Pk $.Reserved $.SoftDelete }}
+	rows, err := db.Query(ctx, {{ backQuote }}{{ $select.SQL }}{{ backQuote }}, {{ args $select "" }})
+	if err != nil {
+		return nil, translate(err)
+	}
+	resp, err := pgx.CollectExactlyOneRow(rows, d.scan)
+	if err != nil {
+		return nil, translate(err)
+	}
+	return &resp, nil
+}
+{{- if $.SoftDelete }}
+
+// GetIncludeDeleted is Get that also returns soft deleted rows.
+func (d {{.TableName }}Dao) GetIncludeDeleted(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (*{{.TableName}}, error) {
+	{{- $select := select $.TableName $.Columns $.Pk $.Reserved }}
+	rows, err := db.Query(ctx, {{ backQuote }}{{ $select.SQL }}{{ backQuote }}, {{ args $select "" }})
+	if err != nil {
+		return nil, translate(err)
+	}
+	resp, err := pgx.CollectExactlyOneRow(rows, d.scan)
+	if err != nil {
+		return nil, translate(err)
+	}
+	return &resp, nil
+}
+{{- end }}
+
+// Exists reports whether {{ if $.SoftDelete }}a live{{ else }}a{{ end }} row has the primary key.
+func (d {{.TableName }}Dao) Exists(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (bool, error) {
+	{{- $exists := exists $.TableName $.Pk $.Reserved $.SoftDelete }}
+	var exists bool
+	if err := db.QueryRow(ctx, {{ backQuote }}{{ $exists.SQL }}{{ backQuote }}, {{ args $exists "" }}).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+{{ end }}
+{{- if $.Columns }}
+{{ template "PostgresList" $ }}
+{{- if $.Pk }}
+
+func (t {{ $.TableName }}) Cursor() {{ $.TableName }}Cursor {
+	return {{ $.TableName }}Cursor{
+{{- range $.Pk }}
+		{{ field . }}: t.{{ . }},
+{{- end }}
+	}
+}
+{{- end }}
+
+func (d {{ $.TableName }}Dao) List(ctx context.Context, db DBTX, opts {{ $.TableName }}ListOptions) ([]{{ $.TableName }}, error) {
+	query, args, err := opts.query()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, d.scan)
+}
+
+// Iter streams the rows List would return; breaking out of the loop closes the result set.
+func (d {{ $.TableName }}Dao) Iter(ctx context.Context, db DBTX, opts {{ $.TableName }}ListOptions) iter.Seq2[{{ $.TableName }}, error] {
+	return func(yield func({{ $.TableName }}, error) bool) {
+		query, args, err := opts.query()
+		if err != nil {
+			yield({{ $.TableName }}{}, err)
+			return
+		}
+		rows, err := db.Query(ctx, query, args...)
+		if err != nil {
+			yield({{ $.TableName }}{}, err)
+			return
+		}
+		defer rows.Close()
+		for rows.Next() {
+			row, err := d.scan(rows)
+			if err != nil {
+				yield({{ $.TableName }}{}, err)
+				return
+			}
+			if !yield(row, nil) {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			yield({{ $.TableName }}{}, err)
+		}
+	}
+}
+
+func (d {{ $.TableName }}Dao) Count(ctx context.Context, db DBTX, filter {{ $.TableName }}Filter) (int64, error) {
+	{{- $count := count $.TableName $.Reserved }}
+	c := filter.conditions()
+	var count int64
+	if err := db.QueryRow(ctx, {{ backQuote }}{{ $count.SQL }}{{ backQuote }}+c.String(), c.args...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+{{ end }}`
+
+const DbtxPostgresPgxTemplate = `package dao
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DBTX is satisfied by *pgx.Conn, pgx.Tx and *pgxpool.Pool.
+type DBTX interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+var (
+	_ DBTX = (*pgx.Conn)(nil)
+	_ DBTX = (pgx.Tx)(nil)
+	_ DBTX = (*pgxpool.Pool)(nil)
+)
+
+// maxPlaceholders is the bind parameter limit of the Postgres protocol.
+const maxPlaceholders = 65535
+
+// values renders rows of numbered placeholders such as ($1, $2), ($3, $4).
+func values(rows, columns int) string {
+	var builder strings.Builder
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString("(")
+		for j := 0; j < columns; j++ {
+			if j > 0 {
+				builder.WriteString(", ")
+			}
+			fmt.Fprintf(&builder, "$%d", i*columns+j+1)
+		}
+		builder.WriteString(")")
+	}
+	return builder.String()
+}
+
+{{ template "PostgresConditions" }}`
+
+const ErrorsPostgresPgxTemplate = `package dao
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrNotFound is returned when no row matches the primary key; it wraps pgx.ErrNoRows.
+var ErrNotFound = fmt.Errorf("dao: not found: %w", pgx.ErrNoRows)
+
+// ErrConflict is returned when a versioned row changed since it was read.
+var ErrConflict = errors.New("dao: version conflict")
+
+// UniqueViolation is returned when a row collides with a primary key or unique constraint.
+type UniqueViolation struct {
+	Table      string
+	Constraint string
+	Columns    []string
+	Err        *pgconn.PgError
+}
+
+func (e *UniqueViolation) Error() string {
+	return fmt.Sprintf("dao: unique violation on %s %v: %v", e.Table, e.Columns, e.Err)
+}
+
+func (e *UniqueViolation) Unwrap() error {
+	return e.Err
+}
+
+// ForeignKeyViolation is returned when a row references a missing parent or is still referenced.
+type ForeignKeyViolation struct {
+	Table      string
+	Constraint string
+	Columns    []string
+	Err        *pgconn.PgError
+}
+
+func (e *ForeignKeyViolation) Error() string {
+	return fmt.Sprintf("dao: foreign key violation on %s %v: %v", e.Table, e.Columns, e.Err)
+}
+
+func (e *ForeignKeyViolation) Unwrap() error {
+	return e.Err
+}
+
+type NotNullViolation struct {
+	Table  string
+	Column string
+	Err    *pgconn.PgError
+}
+
+func (e *NotNullViolation) Error() string {
+	return fmt.Sprintf("dao: not null violation on %s.%s: %v", e.Table, e.Column, e.Err)
+}
+
+func (e *NotNullViolation) Unwrap() error {
+	return e.Err
+}
+
+// constraints holds the columns of each key constraint by table and constraint name.
+var constraints = map[string]map[string][]string{
+{{- range $.Tables }}
+{{- if .Constraints }}
+	{{ printf "%q" .TableName }}: {
+{{- range $name, $columns := .Constraints }}
+		{{ printf "%q" $name }}: { {{- range $i, $c := $columns }}{{ if $i }}, {{ end }}{{ printf "%q" $c }}{{ end -}} },
+{{- end }}
+	},
+{{- end }}
+{{- end }}
+}
+
+// translate converts pgx.ErrNoRows and constraint violations into the errors above.
+func translate(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	switch pgErr.Code {
+	case "23505": // unique_violation
+		return &UniqueViolation{
+			Table:      pgErr.TableName,
+			Constraint: pgErr.ConstraintName,
+			Columns:    constraints[pgErr.TableName][pgErr.ConstraintName],
+			Err:        pgErr,
+		}
+	case "23503": // foreign_key_violation
+		return &ForeignKeyViolation{
+			Table:      pgErr.TableName,
+			Constraint: pgErr.ConstraintName,
+			Columns:    constraints[pgErr.TableName][pgErr.ConstraintName],
+			Err:        pgErr,
+		}
+	case "23502": // not_null_violation
+		return &NotNullViolation{
+			Table:  pgErr.TableName,
+			Column: pgErr.ColumnName,
+			Err:    pgErr,
+		}
+	}
+	return err
+}
+`
@@ -0,0 +1,265 @@
+package postgres
+
+// TestHelpersPostgresTemplate holds the value generators and database access of the round-trip tests.
+const TestHelpersPostgresTemplate = `package dao
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testDBURL names the environment variable holding the database the round-trip tests run against.
+const testDBURL = "GENGO_TEST_DB_URL"
+
+// testCounter starts at a random offset so reruns against one database do not collide.
+var testCounter = func() *atomic.Int64 {
+	counter := new(atomic.Int64)
+	counter.Store(rand.Int63n(1e9) + 1e6)
+	return counter
+}()
+
+// testSeq returns a number no other call returned.
+func testSeq() int {
+	return int(testCounter.Add(1))
+}
+
+// testTx begins a transaction that is rolled back when the test ends.
+func testTx(t *testing.T) *sql.Tx {
+	t.Helper()
+	url := os.Getenv(testDBURL)
+	if url == "" {
+		t.Skipf("%s is not set", testDBURL)
+	}
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := db.BeginTx(context.Background(), nil)
+	if err != nil {
+		db.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		tx.Rollback()
+		db.Close()
+	})
+	return tx
+}
+
+func testPtr[T any](v T) *T {
+	return &v
+}
+
+var testEpoch = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func testText(n int) string {
+	return fmt.Sprintf("t%d", n)
+}
+
+// testClip keeps the last length characters of s, where texts differ.
+func testClip(s string, length int) string {
+	if len(s) <= length {
+		return s
+	}
+	return s[len(s)-length:]
+}
+
+func testTimestamp(n int) string {
+	return testEpoch.Add(time.Duration(n) * time.Second).Format(time.RFC3339)
+}
+
+func testDate(n int) string {
+	return testEpoch.AddDate(0, 0, n%36500).Format(time.DateOnly)
+}
+
+func testTime(n int) string {
+	return testEpoch.Add(time.Duration(n%86400) * time.Second).Format(time.TimeOnly)
+}
+
+func testInterval(n int) string {
+	return testTime(n)
+}
+
+func testJSON(n int) string {
+	return fmt.Sprintf("{\"n\": %d}", n)
+}
+
+func testUUID(n int) string {
+	return fmt.Sprintf("00000000-0000-4000-8000-%012d", n)
+}
+
+// testNormalize brings a value read back from the database to the form it was written in.
+func testNormalize(databaseType string, value string) string {
+	switch databaseType {
+	case "timestamp", "date", "time":
+		for _, layout := range []string{time.RFC3339Nano, time.DateTime, time.DateOnly, time.TimeOnly} {
+			parsed, err := time.Parse(layout, value)
+			if err != nil {
+				continue
+			}
+			switch databaseType {
+			case "date":
+				return parsed.Format(time.DateOnly)
+			case "time":
+				return parsed.Format(time.TimeOnly)
+			default:
+				return parsed.UTC().Format(time.RFC3339Nano)
+			}
+		}
+	case "json", "jsonb":
+		var decoded any
+		if err := json.Unmarshal([]byte(value), &decoded); err == nil {
+			encoded, _ := json.Marshal(decoded)
+			return string(encoded)
+		}
+	}
+	return value
+}
+
+func testNormalizePtr(databaseType string, value *string) *string {
+	if value == nil {
+		return nil
+	}
+	return testPtr(testNormalize(databaseType, *value))
+}
+`
+
+const RoundTripPostgresTemplate = `package dao
+
+import (
+	"context"
+{{- if $.Pk }}
+	"errors"
+{{- end }}
+	"reflect"
+	"testing"
+{{ range imports $.DataTypes }}
+	"{{ . }}"
+{{- end }}
+)
+{{ $T := $.TableName }}
+{{- $F := field $.TableName }}
+{{- $skip := "" }}
+{{- range $.ForeignKeys }}
+{{- if and (not .IsNull) (or (not .References) (eq .Table $T)) }}
+{{- $skip = printf "%s.%s references %s through a key the tests cannot build" $T .Column .Table }}
+{{- end }}
+{{- end }}
+// test{{ $F }}Row returns a valid {{ $T }} row whose required parents exist in db.
+func test{{ $F }}Row(t *testing.T, ctx context.Context, db DBTX) {{ $T }} {
+	t.Helper()
+{{- if $skip }}
+	t.Skip({{ printf "%q" $skip }})
+{{- end }}
+	n := testSeq()
+	row := {{ $T }}{
+{{- range $c := $.Columns }}
+		{{ $c }}: {{ testValue (index $.DataTypes $c) (index $.DatabaseTypes $c) (index $.Limits $c) "n" }},
+{{- end }}
+	}
+{{- range $.ForeignKeys }}
+{{- if .IsNull }}
+{{- if hasPrefix (index $.DataTypes .Column) "*" }}
+	row.{{ .Column }} = nil
+{{- end }}
+{{- else if and .References (ne .Table $T) }}
+	row.{{ .Column }} = testInsert{{ field .Table }}(t, ctx, db).{{ .References }}
+{{- end }}
+{{- end }}
+{{- if $.SoftDelete }}
+	row.{{ $.SoftDelete }} = nil
+{{- end }}
+	return row
+}
+
+// testInsert{{ $F }} creates a valid {{ $T }} row and its required parents.
+func testInsert{{ $F }}(t *testing.T, ctx context.Context, db DBTX) {{ $T }} {
+	t.Helper()
+	row := test{{ $F }}Row(t, ctx, db)
+	if _, err := ({{ $T }}Dao{}).Create(ctx, db, row); err != nil {
+		t.Fatal(err)
+	}
+	return row
+}
+
+// testEqual{{ $F }} compares rows after undoing the formatting the database applies.
+func testEqual{{ $F }}(t *testing.T, actual, expected {{ $T }}) {
+	t.Helper()
+	normalize := func(row {{ $T }}) {{ $T }} {
+{{- range $c := $.Columns }}
+{{- $type := index $.DataTypes $c }}
+{{- if eq $type "string" }}
+		row.{{ $c }} = testNormalize({{ printf "%q" (index $.DatabaseTypes $c) }}, row.{{ $c }})
+{{- else if eq $type "*string" }}
+		row.{{ $c }} = testNormalizePtr({{ printf "%q" (index $.DatabaseTypes $c) }}, row.{{ $c }})
+{{- end }}
+{{- end }}
+		return row
+	}
+	if !reflect.DeepEqual(normalize(actual), normalize(expected)) {
+		t.Fatalf("does match resp actual: %+v, expected: %+v", actual, expected)
+	}
+}
+{{- if $.Pk }}
+{{- $updatable := nonPk $.Columns (list $.SoftDelete) }}
+{{- $changed := nonPk (nonPk (nonPk $updatable $.Pk) (list $.Version)) (fkColumns $.ForeignKeys) }}
+{{- $pk := "" }}{{ range $.Pk }}{{ $pk = printf "%s, expected.%s" $pk . }}{{ end }}
+
+func Test{{ $F }}RoundTrip(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	tx := testTx(t)
+	dao := {{ $T }}Dao{}
+	expected := testInsert{{ $F }}(t, ctx, tx)
+	actual, err := dao.Get(ctx, tx{{ $pk }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testEqual{{ $F }}(t, *actual, expected)
+{{- if nonPk (nonPk $updatable $.Pk) (list $.Version) }}
+{{- if $changed }}
+	n := testSeq()
+{{- range $c := $changed }}
+	expected.{{ $c }} = {{ testValue (index $.DataTypes $c) (index $.DatabaseTypes $c) (index $.Limits $c) "n" }}
+{{- end }}
+{{- end }}
+	if _, err := dao.Update(ctx, tx{{ $pk }}, expected); err != nil {
+		t.Fatal(err)
+	}
+	actual, err = dao.Get(ctx, tx{{ $pk }})
+	if err != nil {
+		t.Fatal(err)
+	}
+{{- if $.Version }}
+{{- if eq (index $.DataTypes $.Version) "int" }}
+	expected.{{ $.Version }}++
+{{- else }}
+	expected.{{ $.Version }} = actual.{{ $.Version }}
+{{- end }}
+{{- end }}
+	testEqual{{ $F }}(t, *actual, expected)
+{{- end }}
+	if _, err := dao.Delete(ctx, tx{{ $pk }}{{ if $.Version }}, expected.{{ $.Version }}{{ end }}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dao.Get(ctx, tx{{ $pk }}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("does match resp actual: %v, expected: %v", err, ErrNotFound)
+	}
+{{- if $.SoftDelete }}
+	if _, err := dao.HardDelete(ctx, tx{{ $pk }}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dao.GetIncludeDeleted(ctx, tx{{ $pk }}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("does match resp actual: %v, expected: %v", err, ErrNotFound)
+	}
+{{- end }}
+}
+{{- end }}
+`
@@ -0,0 +1,382 @@
+package postgres
+
+const DaoPostgresSqlxTemplate = `package dao
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+{{- range imports $.DataTypes "github.com/lib/pq" }}
+	"{{ . }}"
+{{- end }}
+)
+
+type {{ .TableName }} struct {
+	{{- range $key, $value := .DataTypes }}
+	{{ field $key }} {{ $value }} {{ backQuote }}db:"{{ $key }}"{{ backQuote }}
+	{{- end }}
+}
+
+type {{ .TableName }}Dao struct {}
+
+func (d {{.TableName }}Dao) Create(ctx context.Context, db DBTX, target {{ .TableName }}) (int64, error) {
+	{{- $insert := namedInsert $.TableName $.Columns $.Reserved }}
+	m, err := sqlx.NamedExecContext(ctx, db, {{ backQuote }}{{ $insert.SQL }}{{ backQuote }}, target)
+	if err != nil {
+		return 0, translate(err)
+	}
+	c, err := m.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return c, nil
+}
+{{ if $.Columns }}
+func (d {{.TableName }}Dao) CreateMany(ctx context.Context, db DBTX, targets []{{ .TableName }}) (int64, error) {
+	{{- $insert := namedInsert $.TableName $.Columns $.Reserved }}
+	size := maxPlaceholders / {{ len $.Columns }}
+	var total int64
+	for start := 0; start < len(targets); start += size {
+		m, err := sqlx.NamedExecContext(ctx, db, {{ backQuote }}{{ $insert.SQL }}{{ backQuote }}, targets[start:min(start+size, len(targets))])
+		if err != nil {
+			return total, translate(err)
+		}
+		c, err := m.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += c
+	}
+	return total, nil
+}
+
+// CopyFrom bulk loads targets with COPY, which lib/pq only supports inside a transaction.
+func (d {{.TableName }}Dao) CopyFrom(ctx context.Context, tx *sqlx.Tx, targets []{{ .TableName }}) (int64, error) {
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn({{ printf "%q" $.TableName }}, {{ range $i, $c := $.Columns }}{{ if $i }}, {{ end }}{{ printf "%q" $c }}{{ end }}))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+	for _, target := range targets {
+		if _, err := stmt.ExecContext(ctx, {{ range $i, $c := $.Columns }}{{ if $i }}, {{ end }}target.{{ field $c }}{{ end }}); err != nil {
+			return 0, translate(err)
+		}
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return 0, translate(err)
+	}
+	return int64(len(targets)), nil
+}
+{{ end }}
+{{- range $c := conflicts $.Pk $.UniqueKeys }}
+{{- if nonPk (nonPk $.Columns $c.Columns) $.Pk }}
+func (d {{ $.TableName }}Dao) Upsert{{ $c.Name }}(ctx context.Context, db DBTX, target {{ $.TableName }}) (int64, error) {
+	{{- $upsert := namedUpsert $.TableName $.Columns $c.Columns $.Pk $.Reserved false }}
+	m, err := sqlx.NamedExecContext(ctx, db, {{ backQuote }}{{ $upsert.SQL }}{{ backQuote }}, target)
+	if err != nil {
+		return 0, translate(err)
+	}
+	c, err := m.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return c, nil
+}
+{{ end }}
+func (d {{ $.TableName }}Dao) Upsert{{ $c.Name }}DoNothing(ctx context.Context, db DBTX, target {{ $.TableName }}) (int64, error) {
+	{{- $upsert := namedUpsert $.TableName $.Columns $c.Columns $.Pk $.Reserved true }}
+	m, err := sqlx.NamedExecContext(ctx, db, {{ backQuote }}{{ $upsert.SQL }}{{ backQuote }}, target)
+	if err != nil {
+		return 0, translate(err)
+	}
+	c, err := m.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return c, nil
+}
+{{ end }}
+{{- if $.SoftDelete }}
+// All returns the rows not soft deleted.
+{{- end }}
+func (d {{.TableName }}Dao) All(ctx context.Context, db DBTX) ([]{{ .TableName }}, error) {
+	{{- $all := select $.TableName $.Columns nil $.Reserved $.SoftDelete }}
+	var resp []{{ .TableName }}
+	if err := sqlx.SelectContext(ctx, db, &resp, {{ backQuote }}{{ $all.SQL }}{{ backQuote }}); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+{{ $gone := "no row has the primary key" }}
+{{- if $.SoftDelete }}{{ $gone = "no live row has the primary key" }}{{ end }}
+{{- $updatable := nonPk $.Columns (list $.SoftDelete) }}
+{{- if and $.Pk (nonPk (nonPk $updatable $.Pk) (list $.Version)) }}
+{{- if $.Version }}
+// Update applies target only while the stored {{ $.Version }} still equals target.{{ field $.Version }} and advances it.
+// It returns ErrConflict when the row changed in the meantime and ErrNotFound when {{ $gone }} of target.
+func (d {{.TableName }}Dao) Update(ctx context.Context, db DBTX, target {{ .TableName }}) (int64, error) {
+	{{- $update := namedLockedUpdate $.TableName $updatable $.Pk $.Version (index $.DataTypes $.Version) $.Reserved $.SoftDelete }}
+	m, err := sqlx.NamedExecContext(ctx, db, {{ backQuote }}{{ $update.SQL }}{{ backQuote }}, target)
+	if err != nil {
+		return 0, translate(err)
+	}
+	c, err := m.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if c == 0 {
+		return 0, d.conflict(ctx, db, {{ range $i, $c := $.Pk }}{{ if $i }}, {{ end }}target.{{ field $c }}{{ end }})
+	}
+	return c, nil
+}
+{{- else }}
+// Update returns ErrNotFound when {{ $gone }} of target.
+func (d {{.TableName }}Dao) Update(ctx context.Context, db DBTX, target {{ .TableName }}) (int64, error) {
+	{{- $update := namedUpdate $.TableName $updatable $.Pk $.Reserved $.SoftDelete }}
+	m, err := sqlx.NamedExecContext(ctx, db, {{ backQuote }}{{ $update.SQL }}{{ backQuote }}, target)
+	if err != nil {
+		return 0, translate(err)
+	}
+	c, err := m.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if c == 0 {
+		return 0, ErrNotFound
+	}
+	return c, nil
+}
+{{- end }}
+{{ end }}
+{{- if $.Pk }}
+{{- if $.SoftDelete }}
+// Delete marks the row deleted by setting {{ $.SoftDelete }}; use HardDelete to remove it.
+{{- if $.Version }}
+// It applies only while the stored {{ $.Version }} still equals {{ $.Version }} and returns ErrConflict otherwise.
+{{- end }}
+// It returns ErrNotFound when {{ $gone }}.
+func (d {{.TableName }}Dao) Delete(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}{{ if $.Version }}, {{ argumentPk (list $.Version) $.DataTypes }}{{ end }}) (int64, error) {
+	{{- $delete := softDelete $.TableName $.Pk $.SoftDelete $.Version (index $.DataTypes $.Version) $.Reserved }}
+	m, err := db.ExecContext(ctx, {{ backQuote }}{{ $delete.SQL }}{{ backQuote }}, {{ args $delete "" }})
+	if err != nil {
+		return 0, translate(err)
+	}
+	c, err := m.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if c == 0 {
+		return 0, {{ if $.Version }}d.conflict(ctx, db, {{ pkLiner $.Pk }}){{ else }}ErrNotFound{{ end }}
+	}
+	return c, nil
+}
+
+// HardDelete removes the row whether or not it is soft deleted.
+func (d {{.TableName }}Dao) HardDelete(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (int64, error) {
+	{{- $delete := delete $.TableName $.Pk $.Reserved }}
+	m, err := db.ExecContext(ctx, {{ backQuote }}{{ $delete.SQL }}{{ backQuote }}, {{ args $delete "" }})
+	if err != nil {
+		return 0, translate(err)
+	}
+	c, err := m.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if c == 0 {
+		return 0, ErrNotFound
+	}
+	return c, nil
+}
+
+// Restore clears {{ $.SoftDelete }}; it returns ErrNotFound when no soft deleted row has the primary key.
+func (d {{.TableName }}Dao) Restore(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (int64, error) {
+	{{- $restore := restore $.TableName $.Pk $.SoftDelete $.Reserved }}
+	m, err := db.ExecContext(ctx, {{ backQuote }}{{ $restore.SQL }}{{ backQuote }}, {{ args $restore "" }})
+	if err != nil {
+		return 0, translate(err)
+	}
+	c, err := m.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if c == 0 {
+		return 0, ErrNotFound
+	}
+	return c, nil
+}
+{{- else if $.Version }}
+// Delete removes the row only while the stored {{ $.Version }} still equals {{ $.Version }}.
+// It returns ErrConflict when the row changed in the meantime and ErrNotFound when it is gone.
+func (d {{.TableName }}Dao) Delete(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}, {{ argumentPk (list $.Version) $.DataTypes }}) (int64, error) {
+	{{- $delete := lockedDelete $.TableName $.Pk $.Version $.Reserved }}
+	m, err := db.ExecContext(ctx, {{ backQuote }}{{ $delete.SQL }}{{ backQuote }}, {{ args $delete "" }})
+	if err != nil {
+		return 0, translate(err)
+	}
+	c, err := m.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if c == 0 {
+		return 0, d.conflict(ctx, db, {{ pkLiner $.Pk }})
+	}
+	return c, nil
+}
+{{- else }}
+// Delete returns ErrNotFound when no row has the primary key.
+func (d {{.TableName }}Dao) Delete(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (int64, error) {
+	{{- $delete := delete $.TableName $.Pk $.Reserved }}
+	m, err := db.ExecContext(ctx, {{ backQuote }}{{ $delete.SQL }}{{ backQuote }}, {{ args $delete "" }})
+	if err != nil {
+		return 0, translate(err)
+	}
+	c, err := m.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if c == 0 {
+		return 0, ErrNotFound
+	}
+	return c, nil
+}
+{{- end }}
+{{- if $.Version }}
+
+// conflict explains why a versioned write matched no row.
+func (d {{.TableName }}Dao) conflict(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) error {
+	exists, err := d.Exists(ctx, db, {{ pkLiner $.Pk }})
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrConflict
+	}
+	return ErrNotFound
+}
+{{- end }}
+
+// Get returns ErrNotFound when {{ $gone }}.
+func (d {{.TableName }}Dao) Get(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (*{{.TableName}}, error) {
+	{{- $select := select $.TableName $.Columns $.Pk $.Reserved $.SoftDelete }}
+	var resp {{.TableName}}
+	if err := sqlx.GetContext(ctx, db, &resp, {{ backQuote }}{{ $select.SQL }}{{ backQuote }}, {{ args $select "" }}); err != nil {
+		return nil, translate(err)
+	}
+	return &resp, nil
+}
+{{- if $.SoftDelete }}
+
+// GetIncludeDeleted is Get that also returns soft deleted rows.
+func (d {{.TableName }}Dao) GetIncludeDeleted(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (*{{.TableName}}, error) {
+	{{- $select := select $.TableName $.Columns $.Pk $.Reserved }}
+	var resp {{.TableName}}
+	if err := sqlx.GetContext(ctx, db, &resp, {{ backQuote }}{{ $select.SQL }}{{ backQuote }}, {{ args $select "" }}); err != nil {
+		return nil, translate(err)
+	}
+	return &resp, nil
+}
+{{- end }}
+
+// Exists reports whether {{ if $.SoftDelete }}a live{{ else }}a{{ end }} row has the primary key.
+func (d {{.TableName }}Dao) Exists(ctx context.Context, db DBTX, {{ argumentPk $.Pk $.DataTypes }}) (bool, error) {
+	{{- $exists := exists $.TableName $.Pk $.Reserved $.SoftDelete }}
+	var exists bool
+	if err := db.QueryRowxContext(ctx, {{ backQuote }}{{ $exists.SQL }}{{ backQuote }}, {{ args $exists "" }}).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+{{ end }}
+{{- if $.Columns }}
+{{ template "PostgresList" $ }}
+{{- if $.Pk }}
+
+func (t {{ $.TableName }}) Cursor() {{ $.TableName }}Cursor {
+	return {{ $.TableName }}Cursor{
+{{- range $.Pk }}
+		{{ field . }}: t.{{ field . }},
+{{- end }}
+	}
+}
+{{- end }}
+
+func (d {{ $.TableName }}Dao) List(ctx context.Context, db DBTX, opts {{ $.TableName }}ListOptions) ([]{{ $.TableName }}, error) {
+	query, args, err := opts.query()
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]{{ $.TableName }}, 0)
+	if err := sqlx.SelectContext(ctx, db, &resp, query, args...); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Iter streams the rows List would return; breaking out of the loop closes the result set.
+func (d {{ $.TableName }}Dao) Iter(ctx context.Context, db DBTX, opts {{ $.TableName }}ListOptions) iter.Seq2[{{ $.TableName }}, error] {
+	return func(yield func({{ $.TableName }}, error) bool) {
+		query, args, err := opts.query()
+		if err != nil {
+			yield({{ $.TableName }}{}, err)
+			return
+		}
+		rows, err := db.QueryxContext(ctx, query, args...)
+		if err != nil {
+			yield({{ $.TableName }}{}, err)
+			return
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var row {{ $.TableName }}
+			if err := rows.StructScan(&row); err != nil {
+				yield({{ $.TableName }}{}, err)
+				return
+			}
+			if !yield(row, nil) {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			yield({{ $.TableName }}{}, err)
+		}
+	}
+}
+
+func (d {{ $.TableName }}Dao) Count(ctx context.Context, db DBTX, filter {{ $.TableName }}Filter) (int64, error) {
+	{{- $count := count $.TableName $.Reserved }}
+	c := filter.conditions()
+	var count int64
+	if err := db.QueryRowxContext(ctx, {{ backQuote }}{{ $count.SQL }}{{ backQuote }}+c.String(), c.args...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+{{ end }}`
+
+const DbtxPostgresSqlxTemplate = `package dao
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+// DBTX is satisfied by *sqlx.DB and *sqlx.Tx.
+type DBTX interface {
+	sqlx.ExtContext
+}
+
+var (
+	_ DBTX = (*sqlx.DB)(nil)
+	_ DBTX = (*sqlx.Tx)(nil)
+)
+
+// maxPlaceholders is the bind parameter limit of the Postgres protocol.
+const maxPlaceholders = 65535
+
+{{ template "PostgresConditions" }}`
@@ -3,9 +3,15 @@ package template
 import (
 	"fmt"
 	"io"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"text/template"
+	"unicode"
 
+	"github.com/naonao2323/testgen/pkg/common"
+	"github.com/naonao2323/testgen/pkg/query"
 	"github.com/naonao2323/testgen/pkg/template/postgres"
 )
 
@@ -15,11 +21,64 @@ type (
 	DataType         = string
 	DataTypeByColumn = map[Column]DataType
 	Data             struct {
-		TableName string
-		Pk        []Column
-		DataTypes DataTypeByColumn
-		Columns   []Column
-		Reserved  map[string]struct{}
+		TableName   string
+		Pk          []Column
+		DataTypes   DataTypeByColumn
+		Columns     []Column
+		Reserved    map[string]struct{}
+		Defaults    map[Column]Value
+		ForeignKeys []ForeignKey
+		UniqueKeys  []UniqueKey
+		Constraints map[string][]Column
+		// DatabaseTypes names the database type behind each column, such as "timestamp".
+		DatabaseTypes map[Column]string
+		// Limits holds the length, precision, enum values and check bounds of the columns having one.
+		Limits map[Column]common.Limit
+		// Version is the optimistic locking column, empty when the table has none.
+		Version Column
+		// SoftDelete is the nullable column marking deleted rows, empty when the table has none.
+		SoftDelete Column
+		// Tables is the whole schema, set only for files shared by a package.
+		Tables []Data
+		// Capture holds rows read from a database, set only for captured fixtures.
+		Capture *Capture
+		// Schema holds the statements creating the tables, set only for the test database.
+		Schema []string
+	}
+	ForeignKey struct {
+		Column Column
+		Table  string
+		IsNull bool
+		// References is the single column primary key of Table, empty when it has another shape.
+		References Column
+	}
+	UniqueKey struct {
+		Name    string
+		Columns []Column
+	}
+	// Capture is a named slice of database rows in insertion order.
+	Capture struct {
+		Name string
+		// Source describes where the rows were read, such as "orders WHERE id = 42".
+		Source string
+		Rows   []CapturedRow
+	}
+	// CapturedRow is inserted through the fixture of Table; Var is empty when no other row refers to it.
+	CapturedRow struct {
+		Var    string
+		Table  string
+		Values []CapturedValue
+	}
+	// CapturedValue sets Column to a Go Literal or, through its foreign key, to the row held by Parent.
+	CapturedValue struct {
+		Column  Column
+		Literal string
+		Parent  string
+	}
+	// ConflictTarget is a key an upsert can resolve on; Name suffixes the method.
+	ConflictTarget struct {
+		Name    string
+		Columns []Column
 	}
 )
 
@@ -32,28 +91,77 @@ type DefaultTemplateType = string
 
 const (
 	PostgresDao           = DefaultTemplateType("PostgresDao")
+	PostgresDbtx          = DefaultTemplateType("PostgresDbtx")
+	PostgresErrors        = DefaultTemplateType("PostgresErrors")
+	PostgresFake          = DefaultTemplateType("PostgresFake")
+	PostgresFakeShared    = DefaultTemplateType("PostgresFakeShared")
+	PostgresMock          = DefaultTemplateType("PostgresMock")
+	PostgresMockShared    = DefaultTemplateType("PostgresMockShared")
+	PostgresRoundTrip     = DefaultTemplateType("PostgresRoundTrip")
+	PostgresTestHelpers   = DefaultTemplateType("PostgresTestHelpers")
+	PostgresPgxDao        = DefaultTemplateType("PostgresPgxDao")
+	PostgresPgxDbtx       = DefaultTemplateType("PostgresPgxDbtx")
+	PostgresPgxErrors     = DefaultTemplateType("PostgresPgxErrors")
+	PostgresSqlxDao       = DefaultTemplateType("PostgresSqlxDao")
+	PostgresSqlxDbtx      = DefaultTemplateType("PostgresSqlxDbtx")
+	PostgresGormModel     = DefaultTemplateType("PostgresGormModel")
 	PostgresTestFixture   = DefaultTemplateType("PostgresTestFixture")
 	PostgresTestContainer = DefaultTemplateType("PostgresTestContainer")
+	PostgresCapture       = DefaultTemplateType("PostgresCapture")
+	PostgresDBAssert      = DefaultTemplateType("PostgresDBAssert")
+	// PostgresList and PostgresConditions are parts of the DAO and DBTX templates of every mode.
+	PostgresList       = DefaultTemplateType("PostgresList")
+	PostgresConditions = DefaultTemplateType("PostgresConditions")
 )
 
 type FuncMapKey = string
 
 const (
-	ListLiner        FuncMapKey = FuncMapKey("listLiner")
-	Where                       = FuncMapKey("where")
-	BackQuote                   = FuncMapKey("backQuote")
-	PkType                      = FuncMapKey("pkType")
-	Argument                    = FuncMapKey("argument")
-	Scan                        = FuncMapKey("scan")
-	Insert                      = FuncMapKey("insert")
-	Update                      = FuncMapKey("update")
-	Delete                      = FuncMapKey("delete")
-	Select                      = FuncMapKey("select")
-	WithTarget                  = FuncMapKey("withTarget")
-	WithPk                      = FuncMapKey("withPk")
-	PkLiner                     = FuncMapKey("pkLiner")
-	ArgumentPk                  = FuncMapKey("argumentPk")
-	IsPrimaryKeyOnly            = FuncMapKey("isPrimaryKeyOnly")
+	ListLiner         FuncMapKey = FuncMapKey("listLiner")
+	BackQuote                    = FuncMapKey("backQuote")
+	PkType                       = FuncMapKey("pkType")
+	Argument                     = FuncMapKey("argument")
+	Scan                         = FuncMapKey("scan")
+	Insert                       = FuncMapKey("insert")
+	Update                       = FuncMapKey("update")
+	Delete                       = FuncMapKey("delete")
+	Select                       = FuncMapKey("select")
+	PkLiner                      = FuncMapKey("pkLiner")
+	ArgumentPk                   = FuncMapKey("argumentPk")
+	IsPrimaryKeyOnly             = FuncMapKey("isPrimaryKeyOnly")
+	Args                         = FuncMapKey("args")
+	NonPk                        = FuncMapKey("nonPk")
+	Imports                      = FuncMapKey("imports")
+	Field                        = FuncMapKey("field")
+	Association                  = FuncMapKey("association")
+	In                           = FuncMapKey("in")
+	NamedInsert                  = FuncMapKey("namedInsert")
+	NamedUpdate                  = FuncMapKey("namedUpdate")
+	HasPrefix                    = FuncMapKey("hasPrefix")
+	InsertValues                 = FuncMapKey("insertValues")
+	Upsert                       = FuncMapKey("upsert")
+	NamedUpsert                  = FuncMapKey("namedUpsert")
+	Conflicts                    = FuncMapKey("conflicts")
+	Ident                        = FuncMapKey("ident")
+	TrimPrefix                   = FuncMapKey("trimPrefix")
+	Idents                       = FuncMapKey("idents")
+	Count                        = FuncMapKey("count")
+	Exists                       = FuncMapKey("exists")
+	LockedUpdate                 = FuncMapKey("lockedUpdate")
+	NamedLockedUpdate            = FuncMapKey("namedLockedUpdate")
+	LockedDelete                 = FuncMapKey("lockedDelete")
+	List                         = FuncMapKey("list")
+	SoftDelete                   = FuncMapKey("softDelete")
+	Restore                      = FuncMapKey("restore")
+	FakeEqual                    = FuncMapKey("fakeEqual")
+	FakeCompare                  = FuncMapKey("fakeCompare")
+	PrimaryKeyName               = FuncMapKey("primaryKeyName")
+	Join                         = FuncMapKey("join")
+	TestValue                    = FuncMapKey("testValue")
+	FkColumns                    = FuncMapKey("fkColumns")
+	GormDefault                  = FuncMapKey("gormDefault")
+	FilterType                   = FuncMapKey("filterType")
+	Nullable                     = FuncMapKey("nullable")
 )
 
 func NewTemplate(optionFuncMap template.FuncMap) (*Template, error) {
@@ -70,11 +178,83 @@ func NewTemplate(optionFuncMap template.FuncMap) (*Template, error) {
 	if err != nil {
 		return nil, err
 	}
-	_, err = tmp.New(PostgresTestFixture).Funcs(funcMap).Parse(postgres.DaoPostgresTemplate)
+	_, err = tmp.New(PostgresList).Funcs(funcMap).Parse(postgres.ListPostgresTemplate)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tmp.New(PostgresConditions).Funcs(funcMap).Parse(postgres.ConditionsPostgresTemplate)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tmp.New(PostgresDbtx).Funcs(funcMap).Parse(postgres.DbtxPostgresTemplate)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tmp.New(PostgresErrors).Funcs(funcMap).Parse(postgres.ErrorsPostgresTemplate)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tmp.New(PostgresFake).Funcs(funcMap).Parse(postgres.FakePostgresTemplate)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tmp.New(PostgresFakeShared).Funcs(funcMap).Parse(postgres.FakeSharedPostgresTemplate)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tmp.New(PostgresMock).Funcs(funcMap).Parse(postgres.MockPostgresTemplate)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tmp.New(PostgresMockShared).Funcs(funcMap).Parse(postgres.MockSharedPostgresTemplate)
 	if err != nil {
 		return nil, err
 	}
-	_, err = tmp.New(PostgresTestContainer).Funcs(funcMap).Parse(postgres.DaoPostgresTemplate)
+	_, err = tmp.New(PostgresRoundTrip).Funcs(funcMap).Parse(postgres.RoundTripPostgresTemplate)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tmp.New(PostgresTestHelpers).Funcs(funcMap).Parse(postgres.TestHelpersPostgresTemplate)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tmp.New(PostgresPgxDao).Funcs(funcMap).Parse(postgres.DaoPostgresPgxTemplate)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tmp.New(PostgresPgxDbtx).Funcs(funcMap).Parse(postgres.DbtxPostgresPgxTemplate)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tmp.New(PostgresPgxErrors).Funcs(funcMap).Parse(postgres.ErrorsPostgresPgxTemplate)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tmp.New(PostgresSqlxDao).Funcs(funcMap).Parse(postgres.DaoPostgresSqlxTemplate)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tmp.New(PostgresSqlxDbtx).Funcs(funcMap).Parse(postgres.DbtxPostgresSqlxTemplate)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tmp.New(PostgresGormModel).Funcs(funcMap).Parse(postgres.ModelPostgresGormTemplate)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tmp.New(PostgresTestFixture).Funcs(funcMap).Parse(postgres.FixturePostgresTemplate)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tmp.New(PostgresCapture).Funcs(funcMap).Parse(postgres.CapturePostgresTemplate)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tmp.New(PostgresTestContainer).Funcs(funcMap).Parse(postgres.TestDBPostgresTemplate)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tmp.New(PostgresDBAssert).Funcs(funcMap).Parse(postgres.DBAssertPostgresTemplate)
 	if err != nil {
 		return nil, err
 	}
@@ -109,23 +289,6 @@ func newFuncMap() template.FuncMap {
 		ListLiner: func(in []string) string {
 			return liner(in)
 		},
-		Where: func(pk []string) string {
-			if len(pk) == 0 {
-				return ""
-			}
-			where := make([]string, 0)
-			for i := range pk {
-				where = append(where, fmt.Sprintf("%v = $%d", pk[i], i+1))
-			}
-			var resp strings.Builder
-			for i := range where {
-				resp.WriteString(where[i])
-				if i != len(where)-1 {
-					resp.WriteString(" AND ")
-				}
-			}
-			return resp.String()
-		},
 		BackQuote: func() string { return "`" },
 		PkType: func(pk Column, columnsByType map[Column]DataType) string {
 			v, ok := columnsByType[pk]
@@ -158,183 +321,262 @@ func newFuncMap() template.FuncMap {
 			}
 			return liner(scan)
 		},
-		Insert: func(table string, columns []Column, reserved map[string]struct{}) string {
-			var builder strings.Builder
-			builder.WriteString(fmt.Sprintf("INSERT INTO %s ", table))
-			func() {
-				builder.WriteString("(")
-				defer builder.WriteString(") ")
-				for i := range columns {
-					if _, ok := reserved[columns[i]]; ok {
-						builder.WriteString(fmt.Sprintf("'%s'", columns[i]))
-					} else {
-						builder.WriteString(columns[i])
-					}
-					if i < len(columns)-1 {
-						builder.WriteRune(',')
-					}
-				}
-			}()
-			builder.WriteString("VALUES ")
-			func() {
-				builder.WriteString("(")
-				defer builder.WriteString(") ")
-				for i := range columns {
-					builder.WriteString(fmt.Sprintf("$%d", i+1))
-					if i < len(columns)-1 {
-						builder.WriteRune(',')
-					}
+		Insert: func(table string, columns []Column, reserved map[string]struct{}) (query.Query, error) {
+			return query.NewBuilder(query.Postgres, reserved).Build(query.Insert{Table: table, Columns: columns})
+		},
+		Update: func(table string, columns []Column, pk []Column, reserved map[string]struct{}, isNull ...Column) (query.Query, error) {
+			return query.NewBuilder(query.Postgres, reserved).Build(query.Update{Table: table, Set: nonPk(columns, pk), Where: pk, IsNull: present(isNull)})
+		},
+		Delete: func(table string, pk []Column, reserved map[string]struct{}) (query.Query, error) {
+			return query.NewBuilder(query.Postgres, reserved).Build(query.Delete{Table: table, Where: pk})
+		},
+		LockedUpdate: func(table string, columns []Column, pk []Column, version Column, dataType DataType, reserved map[string]struct{}, isNull ...Column) (query.Query, error) {
+			return query.NewBuilder(query.Postgres, reserved).Build(query.Update{
+				Table:   table,
+				Set:     nonPk(nonPk(columns, pk), []Column{version}),
+				Where:   pk,
+				IsNull:  present(isNull),
+				Version: newVersion(version, dataType),
+			})
+		},
+		LockedDelete: func(table string, pk []Column, version Column, reserved map[string]struct{}) (query.Query, error) {
+			where := append(append(make([]Column, 0, len(pk)+1), pk...), version)
+			return query.NewBuilder(query.Postgres, reserved).Build(query.Delete{Table: table, Where: where})
+		},
+		List: func(items ...string) []string {
+			return items
+		},
+		SoftDelete: func(table string, pk []Column, column Column, version Column, dataType DataType, reserved map[string]struct{}) (query.Query, error) {
+			return query.NewBuilder(query.Postgres, reserved).Build(query.Update{
+				Table:   table,
+				Touch:   []Column{column},
+				Where:   pk,
+				IsNull:  []Column{column},
+				Version: newVersion(version, dataType),
+			})
+		},
+		Restore: func(table string, pk []Column, column Column, reserved map[string]struct{}) (query.Query, error) {
+			return query.NewBuilder(query.Postgres, reserved).Build(query.Update{
+				Table:   table,
+				Clear:   []Column{column},
+				Where:   pk,
+				NotNull: []Column{column},
+			})
+		},
+		Select: func(table string, columns []Column, pk []Column, reserved map[string]struct{}, isNull ...Column) (query.Query, error) {
+			return query.NewBuilder(query.Postgres, reserved).Build(query.Select{Table: table, Columns: columns, Where: pk, IsNull: present(isNull)})
+		},
+		Count: func(table string, reserved map[string]struct{}) (query.Query, error) {
+			return query.NewBuilder(query.Postgres, reserved).Build(query.Count{Table: table})
+		},
+		Exists: func(table string, pk []Column, reserved map[string]struct{}, isNull ...Column) (query.Query, error) {
+			return query.NewBuilder(query.Postgres, reserved).Build(query.Exists{Table: table, Where: pk, IsNull: present(isNull)})
+		},
+		// Args renders where arguments as parameters named after their columns and the rest
+		// as fields of target; every argument is a parameter when target is empty.
+		Args: func(q query.Query, target string) string {
+			args := make([]string, 0, len(q.Args))
+			for i := range q.Args {
+				if q.Args[i].Clause == query.Where || target == "" {
+					args = append(args, q.Args[i].Column)
+					continue
 				}
-			}()
-			return builder.String()
+				args = append(args, fmt.Sprintf("%s.%s", target, q.Args[i].Column))
+			}
+			return strings.Join(args, ", ")
 		},
-		Update: func(table string, columns []Column, pk []string, reserved map[string]struct{}) string {
-			if len(columns)-len(pk) <= 0 {
-				return ""
+		NonPk: nonPk,
+		NamedInsert: func(table string, columns []Column, reserved map[string]struct{}) (query.Query, error) {
+			return query.NewBuilder(query.Postgres, reserved).Named().Build(query.Insert{Table: table, Columns: columns})
+		},
+		NamedUpdate: func(table string, columns []Column, pk []Column, reserved map[string]struct{}, isNull ...Column) (query.Query, error) {
+			return query.NewBuilder(query.Postgres, reserved).Named().Build(query.Update{Table: table, Set: nonPk(columns, pk), Where: pk, IsNull: present(isNull)})
+		},
+		NamedLockedUpdate: func(table string, columns []Column, pk []Column, version Column, dataType DataType, reserved map[string]struct{}, isNull ...Column) (query.Query, error) {
+			return query.NewBuilder(query.Postgres, reserved).Named().Build(query.Update{
+				Table:   table,
+				Set:     nonPk(nonPk(columns, pk), []Column{version}),
+				Where:   pk,
+				IsNull:  present(isNull),
+				Version: newVersion(version, dataType),
+			})
+		},
+		Field:      field,
+		HasPrefix:  strings.HasPrefix,
+		TrimPrefix: strings.TrimPrefix,
+		Join:       strings.Join,
+		Ident: func(column Column, reserved map[string]struct{}) string {
+			return query.NewBuilder(query.Postgres, reserved).Ident(column)
+		},
+		Idents: func(columns []Column, reserved map[string]struct{}) []string {
+			builder := query.NewBuilder(query.Postgres, reserved)
+			idents := make([]string, 0, len(columns))
+			for i := range columns {
+				idents = append(idents, builder.Ident(columns[i]))
 			}
-			incrementer := func() func() int {
-				add := 0
-				return func() int {
-					add++
-					return add
-				}
-			}()
-			elimitedPk := func() []Column {
-				set := make(map[string]struct{}, len(pk))
-				for i := range pk {
-					set[pk[i]] = struct{}{}
-				}
-				if len(columns)-len(pk) <= 0 {
-					// TODO: log
-					return nil
-				}
-				elimited := make([]Column, 0, 2)
-				for i := range columns {
-					if _, ok := set[columns[i]]; ok {
-						continue
-					}
-					elimited = append(elimited, columns[i])
-				}
-				return elimited
-			}()
-			var builder strings.Builder
-			builder.WriteString("UPDATE ")
-			builder.WriteString(fmt.Sprintf("%s ", table))
-			builder.WriteString("SET")
-			for i := range elimitedPk {
-				if _, ok := reserved[elimitedPk[i]]; ok {
-					builder.WriteString(fmt.Sprintf(" '%s' = $%d", elimitedPk[i], incrementer()))
-				} else {
-					builder.WriteString(fmt.Sprintf(" %s = $%d", elimitedPk[i], incrementer()))
+			return idents
+		},
+		Association: func(column Column) string {
+			trimmed := strings.TrimSuffix(column, "_id")
+			if trimmed == column || trimmed == "" {
+				return field(column) + "Ref"
+			}
+			return field(trimmed)
+		},
+		In: func(column Column, columns []Column) bool {
+			for i := range columns {
+				if columns[i] == column {
+					return true
 				}
-				if i < len(elimitedPk)-1 {
-					builder.WriteRune(',')
+			}
+			return false
+		},
+		InsertValues: func(table string, columns []Column, reserved map[string]struct{}) (string, error) {
+			if len(columns) == 0 {
+				return "", query.ErrNoColumns
+			}
+			insert, err := query.NewBuilder(query.Postgres, reserved).Build(query.Insert{Table: table, Columns: columns})
+			if err != nil {
+				return "", err
+			}
+			return insert.SQL[:strings.LastIndex(insert.SQL, " VALUES ")+len(" VALUES ")], nil
+		},
+		Upsert: func(table string, columns []Column, conflict []Column, pk []Column, reserved map[string]struct{}, doNothing bool) (query.Query, error) {
+			return upsert(query.NewBuilder(query.Postgres, reserved), table, columns, conflict, pk, doNothing)
+		},
+		NamedUpsert: func(table string, columns []Column, conflict []Column, pk []Column, reserved map[string]struct{}, doNothing bool) (query.Query, error) {
+			return upsert(query.NewBuilder(query.Postgres, reserved).Named(), table, columns, conflict, pk, doNothing)
+		},
+		Conflicts: func(pk []Column, uniqueKeys []UniqueKey) []ConflictTarget {
+			targets := make([]ConflictTarget, 0, len(uniqueKeys)+1)
+			names := make(map[string]struct{}, len(uniqueKeys)+1)
+			add := func(name string, columns []Column) {
+				if _, ok := names[name]; ok || len(columns) == 0 {
+					return
 				}
+				names[name] = struct{}{}
+				targets = append(targets, ConflictTarget{Name: name, Columns: columns})
 			}
-			builder.WriteString(" WHERE ")
-			for i := range pk {
-				builder.WriteString(fmt.Sprintf("%s = $%d", pk[i], incrementer()))
-				if i < len(pk)-1 {
-					builder.WriteString(" AND ")
+			add("", pk)
+			for i := range uniqueKeys {
+				name := "By"
+				for _, column := range uniqueKeys[i].Columns {
+					name += field(column)
 				}
+				add(name, uniqueKeys[i].Columns)
 			}
-			return builder.String()
+			return targets
 		},
-		Delete: func(table string, pk []string) string {
-			if len(pk) == 0 {
-				return ""
+		// FakeEqual renders how a fake compares two values of a unique key, where NULL matches nothing.
+		FakeEqual: func(dataType DataType, a, b string) string {
+			switch {
+			case strings.HasPrefix(dataType, "*"):
+				return fmt.Sprintf("fakeEqualPtr(%s, %s)", a, b)
+			case strings.HasPrefix(dataType, "[]"), strings.HasPrefix(dataType, "pq."):
+				return fmt.Sprintf("fakeEqualSlice(%s, %s)", a, b)
+			default:
+				return fmt.Sprintf("%s == %s", a, b)
 			}
-			var builder strings.Builder
-			builder.WriteString(fmt.Sprintf("DELETE FROM %s WHERE ", table))
-			for i := range pk {
-				builder.WriteString(fmt.Sprintf("%s = $%v", pk[i], i+1))
-				if i < len(pk)-1 {
-					builder.WriteString(", ")
-				}
+		},
+		// FakeCompare renders how a fake orders two values, sorting NULL last like Postgres.
+		FakeCompare: func(dataType DataType, a, b string) string {
+			compare := fmt.Sprintf("fakeCompare[%s]", strings.TrimPrefix(dataType, "*"))
+			if strings.TrimPrefix(dataType, "*") == "bool" {
+				compare = "fakeCompareBool"
 			}
-			return builder.String()
+			if strings.HasPrefix(dataType, "*") {
+				return fmt.Sprintf("fakeComparePtr(%s, %s, %s)", a, b, compare)
+			}
+			return fmt.Sprintf("%s(%s, %s)", compare, a, b)
 		},
-		Select: func(table string, columns []Column, pk []Column, reserved map[string]struct{}) string {
-			if len(columns)-len(pk) <= 0 {
-				return ""
+		PrimaryKeyName: func(constraints map[string][]Column, uniqueKeys []UniqueKey, pk []Column) string {
+			names := make([]string, 0, len(constraints))
+			for name := range constraints {
+				names = append(names, name)
 			}
-			eliminatedPk := func() []Column {
-				set := make(map[string]struct{}, len(pk))
-				for i := range pk {
-					set[pk[i]] = struct{}{}
+			sort.Slice(names, func(i, j int) bool {
+				// Postgres names primary keys <table>_pkey unless told otherwise.
+				pi, pj := strings.HasSuffix(names[i], "_pkey"), strings.HasSuffix(names[j], "_pkey")
+				if pi != pj {
+					return pi
 				}
-				eliminated := make([]string, 0, len(columns))
-				for i := range columns {
-					if _, ok := set[columns[i]]; !ok {
-						eliminated = append(eliminated, columns[i])
+				return names[i] < names[j]
+			})
+		next:
+			for _, name := range names {
+				for i := range uniqueKeys {
+					if uniqueKeys[i].Name == name {
+						continue next
 					}
 				}
-				return eliminated
-			}()
-			var builder strings.Builder
-			builder.WriteString("SELECT ")
-			for i := range eliminatedPk {
-				if _, ok := reserved[eliminatedPk[i]]; ok {
-					builder.WriteString(fmt.Sprintf("'%s'", eliminatedPk[i]))
-				} else {
-					builder.WriteString(eliminatedPk[i])
-				}
-				if i < len(eliminatedPk)-1 {
-					builder.WriteString(", ")
+				if strings.Join(constraints[name], ",") == strings.Join(pk, ",") {
+					return name
 				}
 			}
-			builder.WriteString(fmt.Sprintf(" FROM %s ", table))
-			builder.WriteString("WHERE ")
-			for i := range pk {
-				if _, ok := reserved[pk[i]]; ok {
-					builder.WriteString(fmt.Sprintf("'%s' = $%d", pk[i], i+1))
-				} else {
-					builder.WriteString(fmt.Sprintf("%s = $%d", pk[i], i+1))
-				}
-				if i < len(pk)-1 {
-					builder.WriteString(", ")
-				}
+			return ""
+		},
+		// TestValue renders a valid value of the column derived from the int expression n, within
+		// the enum values, CHECK bounds, precision and length of its limit and distinct for distinct
+		// n while the limit leaves room. The helpers it calls are generated with the round-trip tests.
+		TestValue: func(dataType DataType, databaseType string, limit common.Limit, n string) string {
+			value := testValue(strings.TrimPrefix(dataType, "*"), databaseType, limit, n)
+			if strings.HasPrefix(dataType, "*") {
+				return fmt.Sprintf("testPtr(%s)", value)
 			}
-			return builder.String()
+			return value
 		},
-		WithTarget: func(target string, columns []Column) string {
-			fields := make([]string, 0, len(columns))
-			for i := range columns {
-				fields = append(fields, fmt.Sprintf("%s.%s", target, columns[i]))
+		FkColumns: func(foreignKeys []ForeignKey) []Column {
+			columns := make([]Column, 0, len(foreignKeys))
+			for i := range foreignKeys {
+				columns = append(columns, foreignKeys[i].Column)
 			}
-			var builder strings.Builder
-			for i := range fields {
-				builder.WriteString(fields[i])
-				if i < len(fields)-1 {
-					builder.WriteRune(',')
-				}
+			return columns
+		},
+		GormDefault: gormDefault,
+		// FilterType renders the type List filters a column by, empty for arrays it cannot filter.
+		FilterType: func(dataType DataType) string {
+			switch dataType {
+			case "pgtype.Int8":
+				return "int"
+			case "pgtype.Float8":
+				return "float64"
+			case "pgtype.Bool":
+				return "bool"
+			case "pgtype.Text":
+				return "string"
 			}
-			return builder.String()
+			dataType = strings.TrimPrefix(dataType, "*")
+			if strings.HasPrefix(dataType, "pq.") || strings.HasPrefix(dataType, "pgtype.") || strings.HasPrefix(dataType, "[]") {
+				return ""
+			}
+			return dataType
 		},
-		WithPk: func(target string, columns []Column, pk []string) string {
-			var builder strings.Builder
-		LOOP:
-			for i := range columns {
-				for j := range pk {
-					if pk[j] == columns[i] {
-						continue LOOP
+		// Nullable reports whether the Go type of a column holds NULL, as a pointer or a pgtype.
+		Nullable: func(dataType DataType) bool {
+			return strings.HasPrefix(dataType, "*") || (strings.HasPrefix(dataType, "pgtype.") && !strings.HasPrefix(dataType, "pgtype.FlatArray"))
+		},
+		Imports: func(types DataTypeByColumn, base ...string) []string {
+			packages := map[string]string{
+				"pq.":     "github.com/lib/pq",
+				"pgtype.": "github.com/jackc/pgx/v5/pgtype",
+			}
+			set := make(map[string]struct{}, len(packages)+len(base))
+			for i := range base {
+				set[base[i]] = struct{}{}
+			}
+			for _, dataType := range types {
+				for prefix, path := range packages {
+					if strings.HasPrefix(strings.TrimLeft(dataType, "*[]"), prefix) {
+						set[path] = struct{}{}
 					}
 				}
-				builder.WriteString(fmt.Sprintf("%s.%s", target, columns[i]))
-				if i < len(columns)-1 {
-					builder.WriteString(", ")
-				}
-				if i == len(columns)-1 {
-					builder.WriteString((", "))
-				}
 			}
-			for i := range pk {
-				builder.WriteString(pk[i])
-				if i < len(pk)-1 {
-					builder.WriteString((", "))
-				}
+			imports := make([]string, 0, len(set))
+			for path := range set {
+				imports = append(imports, path)
 			}
-			return builder.String()
+			sort.Strings(imports)
+			return imports
 		},
 		PkLiner: func(pk []Column) string {
 			var builder strings.Builder
@@ -366,6 +608,215 @@ func newFuncMap() template.FuncMap {
 	}
 }
 
+// field converts a column name into an exported Go identifier, e.g. user_id to UserId.
+func field(column Column) string {
+	var builder strings.Builder
+	for _, part := range strings.FieldsFunc(column, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		runes := []rune(part)
+		builder.WriteRune(unicode.ToUpper(runes[0]))
+		builder.WriteString(string(runes[1:]))
+	}
+	resp := builder.String()
+	if resp == "" || !unicode.IsLetter([]rune(resp)[0]) {
+		return "X" + resp
+	}
+	return resp
+}
+
+var (
+	numeric = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+	cast    = regexp.MustCompile(`^(::[a-z0-9_ ."]+(\[\])?)?$`)
+)
+
+// gormDefault renders a column default as the value of a gorm default tag, escaped for the
+// tag. Only literals are kept, without their casts, as gorm parses the value and writes it
+// into its DDL; it is empty for expressions such as now().
+func gormDefault(value Value, dataType DataType) string {
+	literal, rest := value, ""
+	if strings.HasPrefix(value, "'") {
+		end := 1
+		for end < len(value) {
+			if value[end] == '\'' {
+				if end+1 < len(value) && value[end+1] == '\'' {
+					end += 2
+					continue
+				}
+				break
+			}
+			end++
+		}
+		if end == len(value) {
+			return ""
+		}
+		literal, rest = value[:end+1], value[end+1:]
+	} else if i := strings.Index(value, "::"); i >= 0 {
+		literal, rest = value[:i], value[i:]
+	}
+	if !cast.MatchString(rest) {
+		return ""
+	}
+	literal = strings.TrimSuffix(strings.TrimPrefix(literal, "("), ")")
+	if strings.HasPrefix(literal, "'") && strings.TrimPrefix(dataType, "*") != "string" {
+		// casts quote numbers and booleans, such as '-1'::integer, which gorm parses unquoted.
+		literal = strings.ReplaceAll(literal[1:len(literal)-1], "''", "'")
+	}
+	if !strings.HasPrefix(literal, "'") && !numeric.MatchString(literal) && literal != "true" && literal != "false" {
+		return ""
+	}
+	if strings.ContainsFunc(literal, func(r rune) bool { return r == '`' || unicode.IsControl(r) }) {
+		return ""
+	}
+	// gorm splits its settings on unescaped semicolons, and the tag is a Go quoted string.
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, ";", `\\;`).Replace(literal)
+}
+
+// testValue renders the Go expression of a value of the column derived from the int expression n.
+func testValue(dataType DataType, databaseType string, limit common.Limit, n string) string {
+	if len(limit.Enum) > 0 {
+		if values, ok := enumValues(dataType, limit.Enum); ok {
+			return fmt.Sprintf("%s{%s}[%s%%%d]", values[0], strings.Join(values[1:], ", "), n, len(limit.Enum))
+		}
+	}
+	bounded := limit.Min != nil || limit.Max != nil
+	switch dataType {
+	case "int":
+		if !bounded && databaseType != "smallint" {
+			return n
+		}
+		if lo, hi, ok := limit.IntRange(databaseType); ok && hi-lo+1 > 0 {
+			return cycle(n, hi-lo+1, lo)
+		}
+		return n
+	case "float64":
+		if !bounded && limit.Precision == 0 {
+			return fmt.Sprintf("float64(%s) + 0.5", n)
+		}
+		first, last, scale, ok := limit.Steps()
+		if !ok || last-first+1 <= 0 {
+			return fmt.Sprintf("float64(%s) + 0.5", n)
+		}
+		value := fmt.Sprintf("float64(%s)", cycle(n, last-first+1, first))
+		if scale > 0 {
+			// dividing by a power of ten gives the float nearest the decimal the database returns.
+			value = fmt.Sprintf("%s / 1e%d", value, scale)
+		}
+		return value
+	case "bool":
+		return fmt.Sprintf("%s%%2 == 0", n)
+	case "pq.Int64Array":
+		return fmt.Sprintf("pq.Int64Array{int64(%s)}", n)
+	case "pq.StringArray":
+		return fmt.Sprintf("pq.StringArray{testText(%s)}", n)
+	}
+	helpers := map[string]string{
+		"timestamp": "testTimestamp",
+		"date":      "testDate",
+		"time":      "testTime",
+		"interval":  "testInterval",
+		"json":      "testJSON",
+		"jsonb":     "testJSON",
+		"uuid":      "testUUID",
+	}
+	if helper, ok := helpers[databaseType]; ok {
+		return fmt.Sprintf("%s(%s)", helper, n)
+	}
+	if limit.Length > 0 {
+		return fmt.Sprintf("testClip(testText(%s), %d)", n, limit.Length)
+	}
+	return fmt.Sprintf("testText(%s)", n)
+}
+
+// cycle renders n wrapped into the span values starting at lo.
+func cycle(n string, span int64, lo int64) string {
+	switch {
+	case lo == 0:
+		return fmt.Sprintf("%s%%%d", n, span)
+	case lo < 0:
+		return fmt.Sprintf("%s%%%d - %d", n, span, -lo)
+	default:
+		return fmt.Sprintf("%s%%%d + %d", n, span, lo)
+	}
+}
+
+// enumValues renders the enum values as the elements of a slice literal of dataType,
+// preceded by its type, or reports false when a value is not a literal of that type.
+func enumValues(dataType DataType, enum []string) ([]string, bool) {
+	values := []string{"[]" + dataType}
+	for _, value := range enum {
+		switch dataType {
+		case "int":
+			if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+				return nil, false
+			}
+		case "float64":
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				return nil, false
+			}
+		case "string":
+			value = strconv.Quote(value)
+		default:
+			return nil, false
+		}
+		values = append(values, value)
+	}
+	return values, true
+}
+
+// present drops empty columns so templates can pass optional ones such as Data.SoftDelete.
+func present(columns []Column) []Column {
+	resp := make([]Column, 0, len(columns))
+	for i := range columns {
+		if columns[i] != "" {
+			resp = append(resp, columns[i])
+		}
+	}
+	return resp
+}
+
+// newVersion locks on column unless it is empty; only integers, pgtype.Int8 when nullable
+// in the pgx mode, are incremented.
+func newVersion(column Column, dataType DataType) *query.Version {
+	if column == "" {
+		return nil
+	}
+	return &query.Version{
+		Column:    column,
+		Timestamp: strings.TrimPrefix(dataType, "*") != "int" && dataType != "pgtype.Int8",
+	}
+}
+
+// upsert overwrites every column outside the conflict target and the primary key.
+func upsert(builder query.Builder, table string, columns []Column, conflict []Column, pk []Column, doNothing bool) (query.Query, error) {
+	var update []Column
+	if !doNothing {
+		update = nonPk(nonPk(columns, conflict), pk)
+		if len(update) == 0 {
+			return query.Query{}, query.ErrNoColumns
+		}
+	}
+	return builder.Build(query.Insert{
+		Table:      table,
+		Columns:    columns,
+		OnConflict: &query.Conflict{Columns: conflict, Update: update},
+	})
+}
+
+func nonPk(columns []Column, pk []Column) []Column {
+	set := make(map[Column]struct{}, len(pk))
+	for i := range pk {
+		set[pk[i]] = struct{}{}
+	}
+	eliminated := make([]Column, 0, len(columns))
+	for i := range columns {
+		if _, ok := set[columns[i]]; !ok {
+			eliminated = append(eliminated, columns[i])
+		}
+	}
+	return eliminated
+}
+
 func (t *Template) Execute(templateType DefaultTemplateType, writer io.Writer, data Data) error {
 	if t == nil {
 		return nil
@@ -1,7 +1,16 @@
 package template
 
 import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/naonao2323/testgen/pkg/common"
+	"github.com/naonao2323/testgen/pkg/query"
 )
 
 func TestFuncMapKeyListLiner(t *testing.T) {
@@ -36,38 +45,6 @@ func TestFuncMapKeyListLiner(t *testing.T) {
 	}
 }
 
-func TestFuncMapKeyWhere(t *testing.T) {
-	t.Parallel()
-	tests := []struct {
-		name     string
-		pk       []string
-		expected string
-	}{
-		{
-			name:     "where",
-			pk:       []string{"test1", "test2"},
-			expected: "test1 = $1 AND test2 = $2",
-		},
-		{
-			name:     "call where when in is empty",
-			pk:       []string{},
-			expected: "",
-		},
-	}
-	funcMap := newFuncMap()
-	for _, _test := range tests {
-		test := _test
-		t.Run(test.name, func(t *testing.T) {
-			t.Parallel()
-			where := funcMap[Where].(func(pk []string) string)
-			actual := where(test.pk)
-			if actual != test.expected {
-				t.Fatalf("does match resp actual: %v, expected: %v", actual, test.expected)
-			}
-		})
-	}
-}
-
 func TestFuncMapArgumentPk(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -156,14 +133,14 @@ func TestFuncMapInsert(t *testing.T) {
 			table:    "test",
 			columns:  []Column{},
 			reserved: map[string]struct{}{},
-			expected: "INSERT INTO test () VALUES () ",
+			expected: "INSERT INTO test DEFAULT VALUES",
 		},
 		{
 			name:     "when colums is not empty",
 			table:    "test",
 			columns:  []Column{"test1", "test2", "test3"},
 			reserved: map[string]struct{}{},
-			expected: "INSERT INTO test (test1,test2,test3) VALUES ($1,$2,$3) ",
+			expected: "INSERT INTO test (test1, test2, test3) VALUES ($1, $2, $3)",
 		},
 		{
 			name:    "when columns is not empty and include reserved",
@@ -172,7 +149,7 @@ func TestFuncMapInsert(t *testing.T) {
 			reserved: map[string]struct{}{
 				"test1": {},
 			},
-			expected: "INSERT INTO test ('test1',test2,test3) VALUES ($1,$2,$3) ",
+			expected: `INSERT INTO test ("test1", test2, test3) VALUES ($1, $2, $3)`,
 		},
 	}
 	funcMap := newFuncMap()
@@ -180,10 +157,13 @@ func TestFuncMapInsert(t *testing.T) {
 		test := _test
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
-			insert := funcMap[Insert].(func(table string, columns []Column, reserved map[string]struct{}) string)
-			actual := insert(test.table, test.columns, test.reserved)
-			if actual != test.expected {
-				t.Fatalf("does match resp actual: %v,expected: %v", actual, test.expected)
+			insert := funcMap[Insert].(func(table string, columns []Column, reserved map[string]struct{}) (query.Query, error))
+			actual, err := insert(test.table, test.columns, test.reserved)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual.SQL != test.expected {
+				t.Fatalf("does match resp actual: %v,expected: %v", actual.SQL, test.expected)
 			}
 		})
 	}
@@ -198,6 +178,7 @@ func TestFuncMapUpdate(t *testing.T) {
 		pk       []string
 		reserved map[string]struct{}
 		expected string
+		err      error
 	}{
 		{
 			name:     "when columns is empty",
@@ -205,7 +186,15 @@ func TestFuncMapUpdate(t *testing.T) {
 			columns:  []Column{},
 			pk:       []string{"test1"},
 			reserved: map[string]struct{}{},
-			expected: "",
+			err:      query.ErrNoColumns,
+		},
+		{
+			name:     "when pk is empty",
+			table:    "test",
+			columns:  []Column{"test1"},
+			pk:       []string{},
+			reserved: map[string]struct{}{},
+			err:      query.ErrNoCondition,
 		},
 		{
 			name:     "when columns is not empty",
@@ -223,7 +212,7 @@ func TestFuncMapUpdate(t *testing.T) {
 			reserved: map[string]struct{}{
 				"test1": {},
 			},
-			expected: "UPDATE test SET 'test1' = $1, test2 = $2 WHERE pk1 = $3 AND pk2 = $4",
+			expected: `UPDATE test SET "test1" = $1, test2 = $2 WHERE pk1 = $3 AND pk2 = $4`,
 		},
 	}
 	funcMap := newFuncMap()
@@ -231,10 +220,13 @@ func TestFuncMapUpdate(t *testing.T) {
 		test := _test
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
-			update := funcMap[Update].(func(table string, columns []Column, pk []string, reserved map[string]struct{}) string)
-			actual := update(test.table, test.columns, test.pk, test.reserved)
-			if actual != test.expected {
-				t.Fatalf("does match resp actual: %v, expected: %v", actual, test.expected)
+			update := funcMap[Update].(func(table string, columns []Column, pk []Column, reserved map[string]struct{}, isNull ...Column) (query.Query, error))
+			actual, err := update(test.table, test.columns, test.pk, test.reserved)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("does match err actual: %v, expected: %v", err, test.err)
+			}
+			if actual.SQL != test.expected {
+				t.Fatalf("does match resp actual: %v, expected: %v", actual.SQL, test.expected)
 			}
 		})
 	}
@@ -246,19 +238,32 @@ func TestFuncMapDelete(t *testing.T) {
 		name     string
 		table    string
 		pk       []string
+		reserved map[string]struct{}
 		expected string
+		err      error
 	}{
 		{
 			name:     "call Delete when pk is empty",
 			table:    "test",
 			pk:       []string{},
-			expected: "",
+			reserved: map[string]struct{}{},
+			err:      query.ErrNoCondition,
 		},
 		{
 			name:     "call Delete",
 			table:    "test",
 			pk:       []string{"test1", "test2"},
-			expected: "DELETE FROM test WHERE test1 = $1, test2 = $2",
+			reserved: map[string]struct{}{},
+			expected: "DELETE FROM test WHERE test1 = $1 AND test2 = $2",
+		},
+		{
+			name:  "call Delete when pk is reserved",
+			table: "test",
+			pk:    []string{"test1"},
+			reserved: map[string]struct{}{
+				"test1": {},
+			},
+			expected: `DELETE FROM test WHERE "test1" = $1`,
 		},
 	}
 	funcMap := newFuncMap()
@@ -266,10 +271,13 @@ func TestFuncMapDelete(t *testing.T) {
 		test := _test
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
-			delete := funcMap[Delete].(func(table string, pk []string) string)
-			actual := delete(test.table, test.pk)
-			if actual != test.expected {
-				t.Fatalf("does match resp actual: %v, expected: %v", actual, test.expected)
+			delete := funcMap[Delete].(func(table string, pk []Column, reserved map[string]struct{}) (query.Query, error))
+			actual, err := delete(test.table, test.pk, test.reserved)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("does match err actual: %v, expected: %v", err, test.err)
+			}
+			if actual.SQL != test.expected {
+				t.Fatalf("does match resp actual: %v, expected: %v", actual.SQL, test.expected)
 			}
 		})
 	}
@@ -284,6 +292,7 @@ func TestFuncMapSelect(t *testing.T) {
 		pk       []Column
 		reserved map[string]struct{}
 		expected string
+		err      error
 	}{
 		{
 			name:     "when columns are enmpty",
@@ -291,7 +300,7 @@ func TestFuncMapSelect(t *testing.T) {
 			columns:  []Column{},
 			pk:       []Column{},
 			reserved: map[string]struct{}{},
-			expected: "",
+			err:      query.ErrNoColumns,
 		},
 		{
 			name:     "when columns is not empty",
@@ -299,17 +308,73 @@ func TestFuncMapSelect(t *testing.T) {
 			columns:  []Column{"test1", "test2", "test3", "pk1", "pk2"},
 			pk:       []Column{"pk1", "pk2"},
 			reserved: map[string]struct{}{},
-			expected: "SELECT test1, test2, test3 FROM test WHERE pk1 = $1, pk2 = $2",
+			expected: "SELECT test1, test2, test3, pk1, pk2 FROM test WHERE pk1 = $1 AND pk2 = $2",
 		},
 		{
-			name:    "when columns is not empty",
+			name:    "when columns is not empty and include reserved",
 			table:   "test",
 			columns: []Column{"test1", "test2", "test3", "pk1", "pk2"},
 			pk:      []Column{"pk1", "pk2"},
 			reserved: map[string]struct{}{
 				"test1": {},
 			},
-			expected: "SELECT 'test1', test2, test3 FROM test WHERE pk1 = $1, pk2 = $2",
+			expected: `SELECT "test1", test2, test3, pk1, pk2 FROM test WHERE pk1 = $1 AND pk2 = $2`,
+		},
+	}
+	funcMap := newFuncMap()
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			do := funcMap[Select].(func(table string, columns []Column, pk []Column, reserved map[string]struct{}, isNull ...Column) (query.Query, error))
+			actual, err := do(test.table, test.columns, test.pk, test.reserved)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("does match err actual: %v, expected: %v", err, test.err)
+			}
+			if actual.SQL != test.expected {
+				t.Fatalf("does match resp actual: %v, expected: %v", actual.SQL, test.expected)
+			}
+		})
+	}
+}
+
+func TestFuncMapArgs(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		query    query.Query
+		target   string
+		expected string
+	}{
+		{
+			name:     "when args are empty",
+			query:    query.Query{},
+			target:   "target",
+			expected: "",
+		},
+		{
+			name: "columns come before pk",
+			query: query.Query{
+				Args: []query.Arg{
+					{Column: "test1", Clause: query.Set},
+					{Column: "test2", Clause: query.Set},
+					{Column: "pk1", Clause: query.Where},
+					{Column: "pk2", Clause: query.Where},
+				},
+			},
+			target:   "target",
+			expected: "target.test1, target.test2, pk1, pk2",
+		},
+		{
+			name: "values are read from target",
+			query: query.Query{
+				Args: []query.Arg{
+					{Column: "pk1", Clause: query.Values},
+					{Column: "test1", Clause: query.Values},
+				},
+			},
+			target:   "target",
+			expected: "target.pk1, target.test1",
 		},
 	}
 	funcMap := newFuncMap()
@@ -317,8 +382,8 @@ func TestFuncMapSelect(t *testing.T) {
 		test := _test
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
-			do := funcMap[Select].(func(table string, columns []Column, pk []Column, reserved map[string]struct{}) string)
-			actual := do(test.table, test.columns, test.pk, test.reserved)
+			args := funcMap[Args].(func(q query.Query, target string) string)
+			actual := args(test.query, test.target)
 			if actual != test.expected {
 				t.Fatalf("does match resp actual: %v, expected: %v", actual, test.expected)
 			}
@@ -380,3 +445,2025 @@ func TestFuncMapIsPrimaryKeyOnly(t *testing.T) {
 		})
 	}
 }
+
+func TestFuncMapImports(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		types    DataTypeByColumn
+		base     []string
+		expected []string
+	}{
+		{
+			name:     "when types are builtin",
+			types:    DataTypeByColumn{"test1": "int", "test2": "*string"},
+			expected: []string{},
+		},
+		{
+			name:     "when types are from lib/pq",
+			types:    DataTypeByColumn{"test1": "pq.Int64Array", "test2": "pq.StringArray"},
+			expected: []string{"github.com/lib/pq"},
+		},
+		{
+			name:     "when types are from pgtype",
+			types:    DataTypeByColumn{"test1": "pgtype.Int8", "test2": "pgtype.FlatArray[string]"},
+			expected: []string{"github.com/jackc/pgx/v5/pgtype"},
+		},
+		{
+			name:     "when base packages are given",
+			types:    DataTypeByColumn{"test1": "pq.Int64Array", "test2": "int"},
+			base:     []string{"github.com/lib/pq", "database/sql"},
+			expected: []string{"database/sql", "github.com/lib/pq"},
+		},
+	}
+	funcMap := newFuncMap()
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			imports := funcMap[Imports].(func(types DataTypeByColumn, base ...string) []string)
+			actual := imports(test.types, test.base...)
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Fatalf("does match resp actual: %v, expected: %v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestFuncMapField(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		column   Column
+		expected string
+	}{
+		{
+			name:     "single word",
+			column:   "id",
+			expected: "Id",
+		},
+		{
+			name:     "snake case",
+			column:   "user_id",
+			expected: "UserId",
+		},
+		{
+			name:     "starts with digit",
+			column:   "1st_place",
+			expected: "X1stPlace",
+		},
+	}
+	funcMap := newFuncMap()
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			field := funcMap[Field].(func(column Column) string)
+			actual := field(test.column)
+			if actual != test.expected {
+				t.Fatalf("does match resp actual: %v, expected: %v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestFuncMapAssociation(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		column   Column
+		expected string
+	}{
+		{
+			name:     "column ends with id",
+			column:   "user_id",
+			expected: "User",
+		},
+		{
+			name:     "column does not end with id",
+			column:   "author",
+			expected: "AuthorRef",
+		},
+	}
+	funcMap := newFuncMap()
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			association := funcMap[Association].(func(column Column) string)
+			actual := association(test.column)
+			if actual != test.expected {
+				t.Fatalf("does match resp actual: %v, expected: %v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestFuncMapInsertValues(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		table    string
+		columns  []Column
+		reserved map[string]struct{}
+		expected string
+		err      error
+	}{
+		{
+			name:     "when columns is empty",
+			table:    "test",
+			columns:  []Column{},
+			reserved: map[string]struct{}{},
+			err:      query.ErrNoColumns,
+		},
+		{
+			name:     "when columns is not empty",
+			table:    "test",
+			columns:  []Column{"test1", "test2"},
+			reserved: map[string]struct{}{},
+			expected: "INSERT INTO test (test1, test2) VALUES ",
+		},
+		{
+			name:    "when columns include reserved",
+			table:   "test",
+			columns: []Column{"test1", "values"},
+			reserved: map[string]struct{}{
+				"values": {},
+			},
+			expected: `INSERT INTO test (test1, "values") VALUES `,
+		},
+	}
+	funcMap := newFuncMap()
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			insertValues := funcMap[InsertValues].(func(table string, columns []Column, reserved map[string]struct{}) (string, error))
+			actual, err := insertValues(test.table, test.columns, test.reserved)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("does match err actual: %v, expected: %v", err, test.err)
+			}
+			if actual != test.expected {
+				t.Fatalf("does match resp actual: %v, expected: %v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestFuncMapConflicts(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		pk         []Column
+		uniqueKeys []UniqueKey
+		expected   []ConflictTarget
+	}{
+		{
+			name:       "when there are no keys",
+			pk:         []Column{},
+			uniqueKeys: []UniqueKey{},
+			expected:   []ConflictTarget{},
+		},
+		{
+			name: "pk and unique keys",
+			pk:   []Column{"id"},
+			uniqueKeys: []UniqueKey{
+				{Name: "users_email_key", Columns: []Column{"email"}},
+				{Name: "users_org_id_name_key", Columns: []Column{"org_id", "name"}},
+			},
+			expected: []ConflictTarget{
+				{Name: "", Columns: []Column{"id"}},
+				{Name: "ByEmail", Columns: []Column{"email"}},
+				{Name: "ByOrgIdName", Columns: []Column{"org_id", "name"}},
+			},
+		},
+		{
+			name: "unique key without pk",
+			pk:   []Column{},
+			uniqueKeys: []UniqueKey{
+				{Name: "logs_code_key", Columns: []Column{"code"}},
+			},
+			expected: []ConflictTarget{
+				{Name: "ByCode", Columns: []Column{"code"}},
+			},
+		},
+	}
+	funcMap := newFuncMap()
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			conflicts := funcMap[Conflicts].(func(pk []Column, uniqueKeys []UniqueKey) []ConflictTarget)
+			actual := conflicts(test.pk, test.uniqueKeys)
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Fatalf("does match resp actual: %v, expected: %v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestFuncMapUpsert(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		table     string
+		columns   []Column
+		conflict  []Column
+		pk        []Column
+		doNothing bool
+		expected  string
+		err       error
+	}{
+		{
+			name:     "on pk",
+			table:    "test",
+			columns:  []Column{"pk1", "test1", "test2"},
+			conflict: []Column{"pk1"},
+			pk:       []Column{"pk1"},
+			expected: "INSERT INTO test (pk1, test1, test2) VALUES ($1, $2, $3) ON CONFLICT (pk1) DO UPDATE SET test1 = EXCLUDED.test1, test2 = EXCLUDED.test2",
+		},
+		{
+			name:     "on unique key keeps pk",
+			table:    "test",
+			columns:  []Column{"pk1", "test1", "test2"},
+			conflict: []Column{"test1"},
+			pk:       []Column{"pk1"},
+			expected: "INSERT INTO test (pk1, test1, test2) VALUES ($1, $2, $3) ON CONFLICT (test1) DO UPDATE SET test2 = EXCLUDED.test2",
+		},
+		{
+			name:      "do nothing",
+			table:     "test",
+			columns:   []Column{"pk1", "test1"},
+			conflict:  []Column{"pk1"},
+			pk:        []Column{"pk1"},
+			doNothing: true,
+			expected:  "INSERT INTO test (pk1, test1) VALUES ($1, $2) ON CONFLICT (pk1) DO NOTHING",
+		},
+		{
+			name:     "when there is nothing to update",
+			table:    "test",
+			columns:  []Column{"pk1"},
+			conflict: []Column{"pk1"},
+			pk:       []Column{"pk1"},
+			err:      query.ErrNoColumns,
+		},
+	}
+	funcMap := newFuncMap()
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			upsert := funcMap[Upsert].(func(table string, columns []Column, conflict []Column, pk []Column, reserved map[string]struct{}, doNothing bool) (query.Query, error))
+			actual, err := upsert(test.table, test.columns, test.conflict, test.pk, map[string]struct{}{}, test.doNothing)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("does match err actual: %v, expected: %v", err, test.err)
+			}
+			if actual.SQL != test.expected {
+				t.Fatalf("does match resp actual: %v, expected: %v", actual.SQL, test.expected)
+			}
+		})
+	}
+}
+
+func TestFuncMapIdents(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		columns  []Column
+		reserved map[string]struct{}
+		expected []string
+	}{
+		{
+			name:     "when there are no columns",
+			columns:  []Column{},
+			reserved: map[string]struct{}{},
+			expected: []string{},
+		},
+		{
+			name:     "quote reserved words",
+			columns:  []Column{"id", "order", "user"},
+			reserved: map[string]struct{}{"order": {}, "user": {}},
+			expected: []string{"id", `"order"`, `"user"`},
+		},
+	}
+	funcMap := newFuncMap()
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			idents := funcMap[Idents].(func(columns []Column, reserved map[string]struct{}) []string)
+			actual := idents(test.columns, test.reserved)
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Fatalf("does match resp actual: %v, expected: %v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestFuncMapExists(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		table    string
+		pk       []Column
+		reserved map[string]struct{}
+		expected string
+		err      error
+	}{
+		{
+			name:     "single pk",
+			table:    "test",
+			pk:       []Column{"pk1"},
+			reserved: map[string]struct{}{},
+			expected: "SELECT EXISTS (SELECT 1 FROM test WHERE pk1 = $1)",
+		},
+		{
+			name:     "composite pk with reserved",
+			table:    "order",
+			pk:       []Column{"pk1", "pk2"},
+			reserved: map[string]struct{}{"order": {}},
+			expected: `SELECT EXISTS (SELECT 1 FROM "order" WHERE pk1 = $1 AND pk2 = $2)`,
+		},
+		{
+			name:     "when there is no pk",
+			table:    "test",
+			pk:       []Column{},
+			reserved: map[string]struct{}{},
+			err:      query.ErrNoCondition,
+		},
+	}
+	funcMap := newFuncMap()
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			exists := funcMap[Exists].(func(table string, pk []Column, reserved map[string]struct{}, isNull ...Column) (query.Query, error))
+			actual, err := exists(test.table, test.pk, test.reserved)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("does match err actual: %v, expected: %v", err, test.err)
+			}
+			if actual.SQL != test.expected {
+				t.Fatalf("does match resp actual: %v, expected: %v", actual.SQL, test.expected)
+			}
+		})
+	}
+}
+
+func TestFuncMapLockedUpdate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		table    string
+		columns  []Column
+		pk       []Column
+		version  Column
+		dataType DataType
+		expected string
+		err      error
+	}{
+		{
+			name:     "integer version",
+			table:    "test",
+			columns:  []Column{"pk1", "test1", "version"},
+			pk:       []Column{"pk1"},
+			version:  "version",
+			dataType: "int",
+			expected: "UPDATE test SET test1 = $1, version = version + 1 WHERE pk1 = $2 AND version = $3",
+		},
+		{
+			name:     "timestamp version",
+			table:    "test",
+			columns:  []Column{"pk1", "test1", "updated_at"},
+			pk:       []Column{"pk1"},
+			version:  "updated_at",
+			dataType: "*string",
+			expected: "UPDATE test SET test1 = $1, updated_at = clock_timestamp() WHERE pk1 = $2 AND updated_at = $3",
+		},
+		{
+			name:     "when there is nothing but the version to update",
+			table:    "test",
+			columns:  []Column{"pk1", "version"},
+			pk:       []Column{"pk1"},
+			version:  "version",
+			dataType: "int",
+			expected: "UPDATE test SET version = version + 1 WHERE pk1 = $1 AND version = $2",
+		},
+	}
+	funcMap := newFuncMap()
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			lockedUpdate := funcMap[LockedUpdate].(func(table string, columns []Column, pk []Column, version Column, dataType DataType, reserved map[string]struct{}, isNull ...Column) (query.Query, error))
+			actual, err := lockedUpdate(test.table, test.columns, test.pk, test.version, test.dataType, map[string]struct{}{})
+			if !errors.Is(err, test.err) {
+				t.Fatalf("does match err actual: %v, expected: %v", err, test.err)
+			}
+			if actual.SQL != test.expected {
+				t.Fatalf("does match resp actual: %v, expected: %v", actual.SQL, test.expected)
+			}
+		})
+	}
+}
+
+func TestFuncMapNamedLockedUpdate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		version  Column
+		dataType DataType
+		expected string
+	}{
+		{
+			name:     "integer version",
+			version:  "version",
+			dataType: "int",
+			expected: "UPDATE test SET test1 = :test1, version = version + 1 WHERE pk1 = :pk1 AND version = :version",
+		},
+		{
+			name:     "nullable integer version of pgx",
+			version:  "version",
+			dataType: "pgtype.Int8",
+			expected: "UPDATE test SET test1 = :test1, version = version + 1 WHERE pk1 = :pk1 AND version = :version",
+		},
+		{
+			name:     "timestamp version",
+			version:  "version",
+			dataType: "string",
+			expected: "UPDATE test SET test1 = :test1, version = clock_timestamp() WHERE pk1 = :pk1 AND version = :version",
+		},
+	}
+	funcMap := newFuncMap()
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			namedLockedUpdate := funcMap[NamedLockedUpdate].(func(table string, columns []Column, pk []Column, version Column, dataType DataType, reserved map[string]struct{}, isNull ...Column) (query.Query, error))
+			actual, err := namedLockedUpdate("test", []Column{"pk1", "test1", test.version}, []Column{"pk1"}, test.version, test.dataType, map[string]struct{}{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual.SQL != test.expected {
+				t.Fatalf("does match resp actual: %v, expected: %v", actual.SQL, test.expected)
+			}
+		})
+	}
+}
+
+func TestFuncMapLockedDelete(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		table    string
+		pk       []Column
+		version  Column
+		expected string
+	}{
+		{
+			name:     "single pk",
+			table:    "test",
+			pk:       []Column{"pk1"},
+			version:  "version",
+			expected: "DELETE FROM test WHERE pk1 = $1 AND version = $2",
+		},
+		{
+			name:     "composite pk",
+			table:    "test",
+			pk:       []Column{"pk1", "pk2"},
+			version:  "updated_at",
+			expected: "DELETE FROM test WHERE pk1 = $1 AND pk2 = $2 AND updated_at = $3",
+		},
+	}
+	funcMap := newFuncMap()
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			lockedDelete := funcMap[LockedDelete].(func(table string, pk []Column, version Column, reserved map[string]struct{}) (query.Query, error))
+			actual, err := lockedDelete(test.table, test.pk, test.version, map[string]struct{}{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual.SQL != test.expected {
+				t.Fatalf("does match resp actual: %v, expected: %v", actual.SQL, test.expected)
+			}
+		})
+	}
+}
+
+func TestFuncMapSoftDelete(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		table    string
+		pk       []Column
+		column   Column
+		version  Column
+		dataType DataType
+		expected string
+	}{
+		{
+			name:     "without version",
+			table:    "test",
+			pk:       []Column{"pk1", "pk2"},
+			column:   "deleted_at",
+			expected: "UPDATE test SET deleted_at = CURRENT_TIMESTAMP WHERE pk1 = $1 AND pk2 = $2 AND deleted_at IS NULL",
+		},
+		{
+			name:     "with version",
+			table:    "test",
+			pk:       []Column{"pk1"},
+			column:   "deleted_at",
+			version:  "version",
+			dataType: "int",
+			expected: "UPDATE test SET deleted_at = CURRENT_TIMESTAMP, version = version + 1 WHERE pk1 = $1 AND deleted_at IS NULL AND version = $2",
+		},
+	}
+	funcMap := newFuncMap()
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			softDelete := funcMap[SoftDelete].(func(table string, pk []Column, column Column, version Column, dataType DataType, reserved map[string]struct{}) (query.Query, error))
+			actual, err := softDelete(test.table, test.pk, test.column, test.version, test.dataType, map[string]struct{}{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual.SQL != test.expected {
+				t.Fatalf("does match resp actual: %v, expected: %v", actual.SQL, test.expected)
+			}
+		})
+	}
+}
+
+func TestFuncMapRestore(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		table    string
+		pk       []Column
+		column   Column
+		reserved map[string]struct{}
+		expected string
+	}{
+		{
+			name:     "single pk",
+			table:    "test",
+			pk:       []Column{"pk1"},
+			column:   "deleted_at",
+			reserved: map[string]struct{}{},
+			expected: "UPDATE test SET deleted_at = NULL WHERE pk1 = $1 AND deleted_at IS NOT NULL",
+		},
+		{
+			name:     "reserved column",
+			table:    "test",
+			pk:       []Column{"pk1"},
+			column:   "deleted",
+			reserved: map[string]struct{}{"deleted": {}},
+			expected: `UPDATE test SET "deleted" = NULL WHERE pk1 = $1 AND "deleted" IS NOT NULL`,
+		},
+	}
+	funcMap := newFuncMap()
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			restore := funcMap[Restore].(func(table string, pk []Column, column Column, reserved map[string]struct{}) (query.Query, error))
+			actual, err := restore(test.table, test.pk, test.column, test.reserved)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual.SQL != test.expected {
+				t.Fatalf("does match resp actual: %v, expected: %v", actual.SQL, test.expected)
+			}
+		})
+	}
+}
+
+func TestFuncMapFakeEqual(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		dataType DataType
+		expected string
+	}{
+		{
+			name:     "value",
+			dataType: "int",
+			expected: "a.id == b.id",
+		},
+		{
+			name:     "nullable",
+			dataType: "*string",
+			expected: "fakeEqualPtr(a.id, b.id)",
+		},
+		{
+			name:     "array",
+			dataType: "pq.Int64Array",
+			expected: "fakeEqualSlice(a.id, b.id)",
+		},
+	}
+	funcMap := newFuncMap()
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			fakeEqual := funcMap[FakeEqual].(func(dataType DataType, a, b string) string)
+			actual := fakeEqual(test.dataType, "a.id", "b.id")
+			if actual != test.expected {
+				t.Fatalf("does match resp actual: %v, expected: %v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestFuncMapFakeCompare(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		dataType DataType
+		expected string
+	}{
+		{
+			name:     "value",
+			dataType: "int",
+			expected: "fakeCompare[int](a.id, b.id)",
+		},
+		{
+			name:     "bool",
+			dataType: "bool",
+			expected: "fakeCompareBool(a.id, b.id)",
+		},
+		{
+			name:     "nullable",
+			dataType: "*string",
+			expected: "fakeComparePtr(a.id, b.id, fakeCompare[string])",
+		},
+		{
+			name:     "nullable bool",
+			dataType: "*bool",
+			expected: "fakeComparePtr(a.id, b.id, fakeCompareBool)",
+		},
+	}
+	funcMap := newFuncMap()
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			fakeCompare := funcMap[FakeCompare].(func(dataType DataType, a, b string) string)
+			actual := fakeCompare(test.dataType, "a.id", "b.id")
+			if actual != test.expected {
+				t.Fatalf("does match resp actual: %v, expected: %v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestFuncMapPrimaryKeyName(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		constraints map[string][]Column
+		uniqueKeys  []UniqueKey
+		pk          []Column
+		expected    string
+	}{
+		{
+			name: "pkey",
+			constraints: map[string][]Column{
+				"memos_pkey":         {"id"},
+				"memos_user_id_fkey": {"user_id"},
+			},
+			pk:       []Column{"id"},
+			expected: "memos_pkey",
+		},
+		{
+			name: "unique key on pk columns",
+			constraints: map[string][]Column{
+				"a_id_key": {"id"},
+				"custom":   {"id"},
+			},
+			uniqueKeys: []UniqueKey{{Name: "a_id_key", Columns: []Column{"id"}}},
+			pk:         []Column{"id"},
+			expected:   "custom",
+		},
+		{
+			name:        "unknown",
+			constraints: map[string][]Column{},
+			pk:          []Column{"id"},
+			expected:    "",
+		},
+	}
+	funcMap := newFuncMap()
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			primaryKeyName := funcMap[PrimaryKeyName].(func(constraints map[string][]Column, uniqueKeys []UniqueKey, pk []Column) string)
+			actual := primaryKeyName(test.constraints, test.uniqueKeys, test.pk)
+			if actual != test.expected {
+				t.Fatalf("does match resp actual: %v, expected: %v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func bound(v float64) *float64 { return &v }
+
+func TestFuncMapTestValue(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		dataType     DataType
+		databaseType string
+		limit        common.Limit
+		expected     string
+	}{
+		{
+			name:         "int",
+			dataType:     "int",
+			databaseType: "integer",
+			expected:     "n",
+		},
+		{
+			name:         "smallint",
+			dataType:     "int",
+			databaseType: "smallint",
+			expected:     "n%32767 + 1",
+		},
+		{
+			name:         "nullable timestamp",
+			dataType:     "*string",
+			databaseType: "timestamp",
+			expected:     "testPtr(testTimestamp(n))",
+		},
+		{
+			name:         "text",
+			dataType:     "string",
+			databaseType: "varchar",
+			expected:     "testText(n)",
+		},
+		{
+			name:         "enum",
+			dataType:     "string",
+			databaseType: "mood",
+			limit:        common.Limit{Enum: []string{"happy", "sad"}},
+			expected:     `[]string{"happy", "sad"}[n%2]`,
+		},
+		{
+			name:         "nullable integer in a check",
+			dataType:     "*int",
+			databaseType: "integer",
+			limit:        common.Limit{Enum: []string{"1", "3"}},
+			expected:     "testPtr([]int{1, 3}[n%2])",
+		},
+		{
+			name:         "int between check bounds",
+			dataType:     "int",
+			databaseType: "integer",
+			limit:        common.Limit{Min: bound(-5.0), Max: bound(5.0)},
+			expected:     "n%11 - 5",
+		},
+		{
+			name:         "int above a check bound",
+			dataType:     "int",
+			databaseType: "smallint",
+			limit:        common.Limit{Min: bound(18.0)},
+			expected:     "n%32750 + 18",
+		},
+		{
+			name:         "float",
+			dataType:     "float64",
+			databaseType: "double precision",
+			expected:     "float64(n) + 0.5",
+		},
+		{
+			name:         "numeric precision",
+			dataType:     "float64",
+			databaseType: "numeric",
+			limit:        common.Limit{Precision: 4, Scale: 2},
+			expected:     "float64(n%10000) / 1e2",
+		},
+		{
+			name:         "positive numeric",
+			dataType:     "float64",
+			databaseType: "numeric",
+			limit:        common.Limit{Precision: 3, Min: bound(1.0)},
+			expected:     "float64(n%999 + 1)",
+		},
+		{
+			name:         "varchar",
+			dataType:     "string",
+			databaseType: "varchar",
+			limit:        common.Limit{Length: 3},
+			expected:     "testClip(testText(n), 3)",
+		},
+		{
+			name:         "array",
+			dataType:     "pq.StringArray",
+			databaseType: "text[]",
+			expected:     "pq.StringArray{testText(n)}",
+		},
+	}
+	funcMap := newFuncMap()
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			testValue := funcMap[TestValue].(func(dataType DataType, databaseType string, limit common.Limit, n string) string)
+			actual := testValue(test.dataType, test.databaseType, test.limit, "n")
+			if actual != test.expected {
+				t.Fatalf("does match resp actual: %v, expected: %v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestFuncMapFkColumns(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		foreignKeys []ForeignKey
+		expected    []Column
+	}{
+		{
+			name:        "no foreign key",
+			foreignKeys: nil,
+			expected:    []Column{},
+		},
+		{
+			name: "foreign keys",
+			foreignKeys: []ForeignKey{
+				{Column: "user_id", Table: "users", References: "id"},
+				{Column: "parent_id", Table: "memos", IsNull: true, References: "id"},
+			},
+			expected: []Column{"user_id", "parent_id"},
+		},
+	}
+	funcMap := newFuncMap()
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			fkColumns := funcMap[FkColumns].(func(foreignKeys []ForeignKey) []Column)
+			actual := fkColumns(test.foreignKeys)
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Fatalf("does match resp actual: %v, expected: %v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestFuncMapGormDefault(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		value    Value
+		dataType DataType
+		expected string
+	}{
+		{
+			name:     "text",
+			value:    "'anon'::text",
+			dataType: "string",
+			expected: "'anon'",
+		},
+		{
+			name:     "enum",
+			value:    "'ok'::mood",
+			dataType: "*string",
+			expected: "'ok'",
+		},
+		{
+			name:     "quote and semicolon",
+			value:    `'it''s "a;b"'::character varying`,
+			dataType: "string",
+			expected: `'it''s \"a\\;b\"'`,
+		},
+		{
+			name:     "quoted number",
+			value:    "'-1'::integer",
+			dataType: "int",
+			expected: "-1",
+		},
+		{
+			name:     "parenthesized number",
+			value:    "(0.5)::numeric",
+			dataType: "float64",
+			expected: "0.5",
+		},
+		{
+			name:     "number",
+			value:    "0",
+			dataType: "int",
+			expected: "0",
+		},
+		{
+			name:     "boolean",
+			value:    "false",
+			dataType: "bool",
+			expected: "false",
+		},
+		{
+			name:     "function",
+			value:    "now()",
+			dataType: "string",
+			expected: "",
+		},
+		{
+			name:     "expression",
+			value:    "CURRENT_TIMESTAMP",
+			dataType: "string",
+			expected: "",
+		},
+		{
+			name:     "concatenation",
+			value:    "'a'::text || 'b'::text",
+			dataType: "string",
+			expected: "",
+		},
+		{
+			name:     "back quote",
+			value:    "'`'::text",
+			dataType: "string",
+			expected: "",
+		},
+	}
+	funcMap := newFuncMap()
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			gormDefault := funcMap[GormDefault].(func(value Value, dataType DataType) string)
+			actual := gormDefault(test.value, test.dataType)
+			if actual != test.expected {
+				t.Fatalf("does match resp actual: %v, expected: %v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestFuncMapFilterType(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		dataType DataType
+		expected string
+		nullable bool
+	}{
+		{
+			name:     "int",
+			dataType: "int",
+			expected: "int",
+		},
+		{
+			name:     "nullable string",
+			dataType: "*string",
+			expected: "string",
+			nullable: true,
+		},
+		{
+			name:     "pgtype",
+			dataType: "pgtype.Int8",
+			expected: "int",
+			nullable: true,
+		},
+		{
+			name:     "pq array",
+			dataType: "pq.StringArray",
+			expected: "",
+		},
+		{
+			name:     "pgtype array",
+			dataType: "pgtype.FlatArray[string]",
+			expected: "",
+		},
+	}
+	funcMap := newFuncMap()
+	for _, _test := range tests {
+		test := _test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			filterType := funcMap[FilterType].(func(dataType DataType) string)
+			if actual := filterType(test.dataType); actual != test.expected {
+				t.Fatalf("does match resp actual: %v, expected: %v", actual, test.expected)
+			}
+			nullable := funcMap[Nullable].(func(dataType DataType) bool)
+			if actual := nullable(test.dataType); actual != test.nullable {
+				t.Fatalf("does match nullable actual: %v, expected: %v", actual, test.nullable)
+			}
+		})
+	}
+}
+
+// tagsData describes a table of tags named uniquely.
+func tagsData() Data {
+	return Data{
+		TableName:     "tags",
+		Pk:            []Column{"id"},
+		Columns:       []Column{"id", "name"},
+		DataTypes:     DataTypeByColumn{"id": "int", "name": "string"},
+		DatabaseTypes: map[Column]string{"id": "integer", "name": "text"},
+		Reserved:      map[string]struct{}{},
+		UniqueKeys:    []UniqueKey{{Name: "tags_name_key", Columns: []Column{"name"}}},
+		Constraints:   map[string][]Column{"tags_pkey": {"id"}, "tags_name_key": {"name"}},
+	}
+}
+
+// render executes templateType with data.
+func render(t *testing.T, templateType DefaultTemplateType, data Data) string {
+	t.Helper()
+	tmp, err := NewTemplate(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var builder strings.Builder
+	if err := tmp.Execute(templateType, &builder, data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return builder.String()
+}
+
+// goRun writes files, keyed by their path, into a directory of this module that ./...
+// skips, and runs the go command on their packages.
+func goRun(t *testing.T, files map[string]string, args ...string) {
+	t.Helper()
+	skipBuild(t)
+	dir, err := os.MkdirTemp(".", "_render")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	// the packages are named one by one, as patterns skip directories starting with _.
+	for _, pkg := range writeFiles(t, dir, files) {
+		args = append(args, "./"+pkg)
+	}
+	cmd := exec.Command("go", args...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+// goModule writes files into a module of their own requiring the modules of requires,
+// such as "github.com/jmoiron/sqlx v1.4.0", and runs the go command on its packages.
+// The test is skipped when the modules can be neither downloaded nor found in the cache.
+func goModule(t *testing.T, requires []string, files map[string]string, args ...string) {
+	t.Helper()
+	skipBuild(t)
+	dir := t.TempDir()
+	gomod := "module render\n\ngo 1.23\n\nrequire (\n\t" + strings.Join(requires, "\n\t") + "\n)\n"
+	writeFiles(t, dir, map[string]string{"go.mod": gomod})
+	writeFiles(t, dir, files)
+	run := func(args ...string) ([]byte, error) {
+		cmd := exec.Command("go", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+		return cmd.CombinedOutput()
+	}
+	if out, err := run("mod", "download"); err != nil {
+		t.Skipf("the modules of the rendered code are not available: %v\n%s", err, out)
+	}
+	args = append(args, "./...")
+	if out, err := run(args...); err != nil {
+		t.Fatalf("go %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+func skipBuild(t *testing.T) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("building the rendered code is skipped in short mode")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+}
+
+// writeFiles writes files under dir and returns the directories of their packages.
+func writeFiles(t *testing.T, dir string, files map[string]string) []string {
+	t.Helper()
+	packages := make(map[string]struct{})
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		packages[filepath.Dir(path)] = struct{}{}
+	}
+	resp := make([]string, 0, len(packages))
+	for pkg := range packages {
+		resp = append(resp, pkg)
+	}
+	return resp
+}
+
+// dbtxTest runs the DAO on a DBTX recording its calls.
+const dbtxTest = `package dao
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+)
+
+type key struct{}
+
+type recorder struct {
+	DBTX
+	ctx   context.Context
+	query string
+	args  []any
+}
+
+func (r *recorder) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	r.ctx, r.query, r.args = ctx, query, args
+	return driver.RowsAffected(1), nil
+}
+
+func TestDBTX(t *testing.T) {
+	ctx := context.WithValue(context.Background(), key{}, "request")
+	db := &recorder{}
+	c, err := tagsDao{}.Delete(ctx, db, 7)
+	if err != nil || c != 1 {
+		t.Fatalf("Delete = %d, %v", c, err)
+	}
+	if db.ctx.Value(key{}) != "request" {
+		t.Fatal("the context is not passed to the DBTX")
+	}
+	if db.query != "DELETE FROM tags WHERE id = $1" || len(db.args) != 1 || db.args[0] != 7 {
+		t.Fatalf("unexpected call %q %v", db.query, db.args)
+	}
+}
+`
+
+func TestRenderDbtx(t *testing.T) {
+	t.Parallel()
+	dbtx := render(t, PostgresDbtx, Data{})
+	for _, expected := range []string{
+		"ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)",
+		"_ DBTX = (*sql.DB)(nil)",
+		"_ DBTX = (*sql.Tx)(nil)",
+		"_ DBTX = (*sql.Conn)(nil)",
+	} {
+		if !strings.Contains(dbtx, expected) {
+			t.Fatalf("%q is not rendered:\n%s", expected, dbtx)
+		}
+	}
+	dao := render(t, PostgresDao, tagsData())
+	if !strings.Contains(dao, "func (d tagsDao) Get(ctx context.Context, db DBTX, id int) (*tags, error)") {
+		t.Fatalf("Get does not take a context and a DBTX:\n%s", dao)
+	}
+	goRun(t, map[string]string{
+		"dao/dbtx.go":      dbtx,
+		"dao/errors.go":    render(t, PostgresErrors, Data{Tables: []Data{tagsData()}}),
+		"dao/tags.go":      dao,
+		"dao/dbtx_test.go": dbtxTest,
+	}, "test", "-run", "TestDBTX")
+}
+
+// translateTest feeds translate the errors of lib/pq and database/sql.
+const translateTest = `package dao
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestTranslate(t *testing.T) {
+	unique := &pq.Error{Code: "23505", Table: "tags", Constraint: "tags_name_key"}
+	var uniqueViolation *UniqueViolation
+	if err := translate(unique); !errors.As(err, &uniqueViolation) || !reflect.DeepEqual(uniqueViolation.Columns, []string{"name"}) || !errors.Is(err, unique) {
+		t.Fatalf("unexpected unique violation %#v", err)
+	}
+	foreignKey := &pq.Error{Code: "23503", Table: "tags", Constraint: "tags_pkey"}
+	var foreignKeyViolation *ForeignKeyViolation
+	if err := translate(foreignKey); !errors.As(err, &foreignKeyViolation) || !reflect.DeepEqual(foreignKeyViolation.Columns, []string{"id"}) {
+		t.Fatalf("unexpected foreign key violation %#v", err)
+	}
+	var notNullViolation *NotNullViolation
+	if err := translate(&pq.Error{Code: "23502", Table: "tags", Column: "name"}); !errors.As(err, &notNullViolation) || notNullViolation.Column != "name" {
+		t.Fatalf("unexpected not null violation %#v", err)
+	}
+	if err := translate(sql.ErrNoRows); err != ErrNotFound || !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("unexpected not found %#v", err)
+	}
+	check := &pq.Error{Code: "23514", Table: "tags"}
+	if err := translate(check); err != check {
+		t.Fatalf("other errors are changed: %#v", err)
+	}
+	if err := translate(nil); err != nil {
+		t.Fatalf("nil is changed: %#v", err)
+	}
+}
+`
+
+func TestRenderErrors(t *testing.T) {
+	t.Parallel()
+	rendered := render(t, PostgresErrors, Data{Tables: []Data{tagsData(), {TableName: "logs"}}})
+	expected := `var constraints = map[string]map[string][]string{
+	"tags": {
+		"tags_name_key": {"name"},
+		"tags_pkey": {"id"},
+	},
+}`
+	if !strings.Contains(rendered, expected) {
+		t.Fatalf("the constraints are not rendered:\n%s", rendered)
+	}
+	goRun(t, map[string]string{
+		"dao/errors.go":      rendered,
+		"dao/errors_test.go": translateTest,
+	}, "test", "-run", "TestTranslate")
+}
+
+func TestRenderPgxErrors(t *testing.T) {
+	t.Parallel()
+	// pgx is not a dependency of this module, so the pgx errors are only rendered.
+	rendered := render(t, PostgresPgxErrors, Data{Tables: []Data{tagsData()}})
+	for _, expected := range []string{
+		`var ErrNotFound = fmt.Errorf("dao: not found: %w", pgx.ErrNoRows)`,
+		`case "23505": // unique_violation`,
+		`Columns:    constraints[pgErr.TableName][pgErr.ConstraintName],`,
+		`"tags_name_key": {"name"},`,
+	} {
+		if !strings.Contains(rendered, expected) {
+			t.Fatalf("%q is not rendered:\n%s", expected, rendered)
+		}
+	}
+}
+
+// mockTest sets expectations on the mock of tags and checks what AssertExpectations reports.
+const mockTest = `package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type reporter struct {
+	errors []string
+}
+
+func (r *reporter) Helper() {}
+
+func (r *reporter) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestMock(t *testing.T) {
+	ctx := context.Background()
+	m := NewMockTagsRepository()
+	m.ExpectGet(7).Return(&tags{id: 7, name: "go"}, nil)
+	m.ExpectDelete(Match(func(id int) bool { return id > 100 })).Return(1, nil).Times(2)
+	m.ExpectCount(Any()).Return(3, nil).AnyTimes()
+	if got, err := m.Get(ctx, nil, 7); err != nil || got.name != "go" {
+		t.Fatalf("Get = %v, %v", got, err)
+	}
+	for i := 0; i < 2; i++ {
+		if c, err := m.Delete(ctx, nil, 101+i); err != nil || c != 1 {
+			t.Fatalf("Delete = %d, %v", c, err)
+		}
+	}
+	m.AssertExpectations(t)
+
+	r := &reporter{}
+	m = NewMockTagsRepository()
+	m.ExpectGet(1).Return(nil, ErrNotFound)
+	if _, err := m.Get(ctx, nil, 2); err == nil || errors.Is(err, ErrNotFound) {
+		t.Fatalf("unexpected call returned %v", err)
+	}
+	m.AssertExpectations(r)
+	expected := []string{"dao: expected call Get(1) 1 times, got 0", "dao: unexpected call Get(2)"}
+	if fmt.Sprint(r.errors) != fmt.Sprint(expected) {
+		t.Fatalf("AssertExpectations reported %q", r.errors)
+	}
+}
+`
+
+func TestRenderMock(t *testing.T) {
+	t.Parallel()
+	mock := render(t, PostgresMock, tagsData())
+	for _, expected := range []string{
+		"var _ TagsRepository = (*MockTagsRepository)(nil)",
+		"func (m *MockTagsRepository) ExpectGet(id any) *MockCall[*tags] {",
+		`return called[*tags](&m.mock, "Get", id)`,
+	} {
+		if !strings.Contains(mock, expected) {
+			t.Fatalf("%q is not rendered:\n%s", expected, mock)
+		}
+	}
+	shared := Data{Tables: []Data{tagsData()}}
+	goRun(t, map[string]string{
+		"dao/dbtx.go":      render(t, PostgresDbtx, Data{}),
+		"dao/errors.go":    render(t, PostgresErrors, shared),
+		"dao/fake.go":      render(t, PostgresFakeShared, shared),
+		"dao/mock.go":      render(t, PostgresMockShared, shared),
+		"dao/tags.go":      render(t, PostgresDao, tagsData()),
+		"dao/tags_fake.go": render(t, PostgresFake, tagsData()),
+		"dao/tags_mock.go": mock,
+		"dao/mock_test.go": mockTest,
+	}, "test", "-run", "TestMock")
+}
+
+// limitsTest draws rows from the fixture of moods and checks every value stays within its limit.
+const limitsTest = `package dao
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLimits(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		row := NewMoodsFixture().row
+		if row.mood != "happy" && row.mood != "sad" {
+			t.Fatalf("mood %q is not a label of the enum", row.mood)
+		}
+		if row.score < 1 || row.score > 5 {
+			t.Fatalf("score %d is out of the check", row.score)
+		}
+		if row.price < 0 || row.price > 99.99 || math.Round(row.price*100)/100 != row.price {
+			t.Fatalf("price %v does not fit numeric(4, 2)", row.price)
+		}
+		if len(row.code) > 3 {
+			t.Fatalf("code %q is longer than varchar(3)", row.code)
+		}
+		seen[row.mood] = true
+	}
+	if len(seen) != 2 {
+		t.Fatalf("only the moods %v are drawn", seen)
+	}
+}
+`
+
+func TestRenderFixtureLimits(t *testing.T) {
+	t.Parallel()
+	data := Data{
+		TableName: "moods",
+		Pk:        []Column{"id"},
+		Columns:   []Column{"code", "id", "mood", "price", "score"},
+		DataTypes: DataTypeByColumn{"code": "string", "id": "int", "mood": "string", "price": "float64", "score": "int"},
+		DatabaseTypes: map[Column]string{
+			"code": "varchar", "id": "integer", "mood": "mood", "price": "numeric", "score": "integer",
+		},
+		Limits: map[Column]common.Limit{
+			"code":  {Length: 3},
+			"mood":  {Enum: []string{"happy", "sad"}},
+			"price": {Precision: 4, Scale: 2},
+			"score": {Min: bound(1), Max: bound(5)},
+		},
+		Reserved:    map[string]struct{}{},
+		Constraints: map[string][]Column{"moods_pkey": {"id"}},
+	}
+	fixture := render(t, PostgresTestFixture, data)
+	for _, expected := range []string{
+		`mood: []string{"happy", "sad"}[n%2],`,
+		"score: n%5 + 1,",
+		"price: float64(n%10000) / 1e2,",
+		"code: testClip(testText(n), 3),",
+	} {
+		if !strings.Contains(fixture, expected) {
+			t.Fatalf("%q is not rendered:\n%s", expected, fixture)
+		}
+	}
+	shared := Data{Tables: []Data{data}}
+	goRun(t, map[string]string{
+		"dao/dbtx.go":               render(t, PostgresDbtx, Data{}),
+		"dao/errors.go":             render(t, PostgresErrors, shared),
+		"dao/moods.go":              render(t, PostgresDao, data),
+		"dao/roundtrip_test.go":     render(t, PostgresTestHelpers, shared),
+		"dao/moods_test.go":         render(t, PostgresRoundTrip, data),
+		"dao/moods_fixture_test.go": fixture,
+		"dao/limits_test.go":        limitsTest,
+	}, "test", "-run", "TestLimits")
+}
+
+// fixtureTest inserts books through their fixture and checks which authors are inserted with them.
+const fixtureTest = `package dao
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"testing"
+)
+
+type inserts struct {
+	DBTX
+	tables []string
+	args   [][]any
+}
+
+func (r *inserts) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	r.tables = append(r.tables, strings.Fields(query)[2])
+	r.args = append(r.args, args)
+	return driver.RowsAffected(1), nil
+}
+
+func TestFixture(t *testing.T) {
+	db := &inserts{}
+	book := NewBooksFixture().Insert(t, db)
+	if strings.Join(db.tables, ",") != "authors,books" || db.args[0][0] != book.author_id || book.editor_id != nil {
+		t.Fatalf("a new book inserted %v %v", db.tables, db.args)
+	}
+
+	db = &inserts{}
+	book = NewBooksFixture().WithAuthor(authors{id: 42}).Insert(t, db)
+	if strings.Join(db.tables, ",") != "books" || book.author_id != 42 {
+		t.Fatalf("a book of an existing author inserted %v %v", db.tables, db.args)
+	}
+
+	db = &inserts{}
+	book = NewBooksFixture().WithAuthorId(5).WithEditorFixture(NewAuthorsFixture()).Insert(t, db)
+	if strings.Join(db.tables, ",") != "authors,books" || book.author_id != 5 || book.editor_id == nil || *book.editor_id != db.args[0][0] {
+		t.Fatalf("an edited book inserted %v %v", db.tables, db.args)
+	}
+}
+`
+
+func TestRenderFixtureParents(t *testing.T) {
+	t.Parallel()
+	authors := Data{
+		TableName:     "authors",
+		Pk:            []Column{"id"},
+		Columns:       []Column{"id", "name"},
+		DataTypes:     DataTypeByColumn{"id": "int", "name": "string"},
+		DatabaseTypes: map[Column]string{"id": "integer", "name": "text"},
+		Reserved:      map[string]struct{}{},
+		Constraints:   map[string][]Column{"authors_pkey": {"id"}},
+	}
+	books := Data{
+		TableName:     "books",
+		Pk:            []Column{"id"},
+		Columns:       []Column{"author_id", "editor_id", "id"},
+		DataTypes:     DataTypeByColumn{"author_id": "int", "editor_id": "*int", "id": "int"},
+		DatabaseTypes: map[Column]string{"author_id": "integer", "editor_id": "integer", "id": "integer"},
+		Reserved:      map[string]struct{}{},
+		ForeignKeys: []ForeignKey{
+			{Column: "author_id", Table: "authors", References: "id"},
+			{Column: "editor_id", Table: "authors", IsNull: true, References: "id"},
+		},
+		Constraints: map[string][]Column{"books_pkey": {"id"}},
+	}
+	fixture := render(t, PostgresTestFixture, books)
+	for _, expected := range []string{
+		"parentAuthor: func(t testing.TB, db DBTX) authors {",
+		"func (f *BooksFixture) WithEditor(parent authors) *BooksFixture {\n\tf.row.editor_id = testPtr(parent.id)",
+		"func (f *BooksFixture) WithEditorFixture(parent *AuthorsFixture) *BooksFixture {",
+	} {
+		if !strings.Contains(fixture, expected) {
+			t.Fatalf("%q is not rendered:\n%s", expected, fixture)
+		}
+	}
+	if strings.Contains(fixture, "parentEditor: func") {
+		t.Fatalf("the nullable editor is inserted by default:\n%s", fixture)
+	}
+	shared := Data{Tables: []Data{authors, books}}
+	goRun(t, map[string]string{
+		"dao/dbtx.go":                 render(t, PostgresDbtx, Data{}),
+		"dao/errors.go":               render(t, PostgresErrors, shared),
+		"dao/authors.go":              render(t, PostgresDao, authors),
+		"dao/books.go":                render(t, PostgresDao, books),
+		"dao/roundtrip_test.go":       render(t, PostgresTestHelpers, shared),
+		"dao/authors_fixture_test.go": render(t, PostgresTestFixture, authors),
+		"dao/books_fixture_test.go":   fixture,
+		"dao/fixture_test.go":         fixtureTest,
+	}, "test", "-run", "TestFixture")
+}
+
+// testdbTest provisions databases through a recording provisioner, which needs no server
+// as sql.Open connects lazily, and checks withDatabase on the DSN forms.
+const testdbTest = `package testdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type provisioner struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (p *provisioner) record(format string, args ...any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, fmt.Sprintf(format, args...))
+}
+
+func (p *provisioner) Create(ctx context.Context, name string, template string) (string, error) {
+	p.record("create %s from %q", name, template)
+	return "postgres://localhost/" + name, nil
+}
+
+func (p *provisioner) Drop(ctx context.Context, name string) error {
+	p.record("drop %s", name)
+	return nil
+}
+
+func TestNew(t *testing.T) {
+	p := &provisioner{}
+	migrations := 0
+	Use(Config{Provisioner: p, Migrate: func(ctx context.Context, db *sql.DB) error {
+		migrations++
+		return nil
+	}})
+	names := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		t.Run("copy", func(t *testing.T) {
+			New(t)
+		})
+	}
+	if err := Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if migrations != 1 || len(p.calls) != 6 {
+		t.Fatalf("migrated %d times with %q", migrations, p.calls)
+	}
+	var template string
+	if _, err := fmt.Sscanf(p.calls[0], "create %s from \"\"", &template); err != nil || !strings.HasPrefix(template, "testdb_template_") {
+		t.Fatalf("the template is not created first: %q", p.calls)
+	}
+	for _, call := range p.calls[1:5] {
+		var name, source string
+		if _, err := fmt.Sscanf(call, "create %s from %q", &name, &source); err == nil {
+			if source != template || names[name] {
+				t.Fatalf("the test database %s is not a new copy of the template: %q", name, p.calls)
+			}
+			names[name] = true
+		} else if _, err := fmt.Sscanf(call, "drop %s", &name); err != nil || !names[name] {
+			t.Fatalf("a database is dropped before it is created: %q", p.calls)
+		}
+	}
+	if len(names) != 2 || p.calls[5] != "drop "+template {
+		t.Fatalf("the template is not dropped last: %q", p.calls)
+	}
+}
+
+func TestWithDatabase(t *testing.T) {
+	tests := []struct {
+		dsn      string
+		expected string
+	}{
+		{dsn: "postgres://u:p@localhost:5432/postgres?sslmode=disable", expected: "postgres://u:p@localhost:5432/db?sslmode=disable"},
+		{dsn: "postgresql://localhost", expected: "postgresql://localhost/db"},
+		{dsn: "host=localhost dbname=postgres sslmode=disable", expected: "host=localhost dbname=db sslmode=disable"},
+		{dsn: "host=localhost", expected: "host=localhost dbname=db"},
+	}
+	for _, test := range tests {
+		actual, err := withDatabase(test.dsn, "db")
+		if err != nil || actual != test.expected {
+			t.Fatalf("withDatabase(%q) = %q, %v", test.dsn, actual, err)
+		}
+	}
+	if _, err := withDatabase(" ", "db"); err == nil {
+		t.Fatal("an empty DSN is accepted")
+	}
+}
+`
+
+func TestRenderTestDB(t *testing.T) {
+	t.Parallel()
+	testdb := render(t, PostgresTestContainer, Data{Schema: []string{"CREATE TABLE tags (id serial, PRIMARY KEY (id))"}})
+	expected := "var Schema = []string{\n\t\"CREATE TABLE tags (id serial, PRIMARY KEY (id))\",\n}"
+	if !strings.Contains(testdb, expected) {
+		t.Fatalf("%q is not rendered:\n%s", expected, testdb)
+	}
+	goRun(t, map[string]string{
+		"testdb/testdb.go":      testdb,
+		"testdb/testdb_test.go": testdbTest,
+	}, "test", "-run", "TestNew|TestWithDatabase")
+}
+
+// txTest runs a Transaction on a driver recording its statements and checks the
+// savepoints nest, and that Tx skips without a provisioner.
+const txTest = `package testdb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type recorder struct {
+	statements []string
+}
+
+func (r *recorder) Open(name string) (driver.Conn, error) { return r, nil }
+
+func (r *recorder) Prepare(query string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+
+func (r *recorder) Close() error { return nil }
+
+func (r *recorder) Begin() (driver.Tx, error) {
+	r.statements = append(r.statements, "BEGIN")
+	return r, nil
+}
+
+func (r *recorder) Commit() error { return errors.New("not supported") }
+
+func (r *recorder) Rollback() error {
+	r.statements = append(r.statements, "ROLLBACK")
+	return nil
+}
+
+func (r *recorder) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	r.statements = append(r.statements, query)
+	return driver.RowsAffected(1), nil
+}
+
+var rec = &recorder{}
+
+func init() {
+	sql.Register("recorder", rec)
+}
+
+func TestSavepoint(t *testing.T) {
+	db, err := sql.Open("recorder", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	sqlTx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := Transaction{tx: sqlTx, savepoints: new(int)}
+	outer := tx.Savepoint(t)
+	if _, err := outer.ExecContext(context.Background(), "INSERT INTO tags VALUES (1)"); err != nil {
+		t.Fatal(err)
+	}
+	inner := outer.Savepoint(t)
+	inner.Restore(t)
+	inner.Release(t)
+	outer.Restore(t)
+	tx.Savepoint(t)
+	if err := sqlTx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"BEGIN",
+		"SAVEPOINT testdb_1",
+		"INSERT INTO tags VALUES (1)",
+		"SAVEPOINT testdb_2",
+		"ROLLBACK TO SAVEPOINT testdb_2",
+		"RELEASE SAVEPOINT testdb_2",
+		"ROLLBACK TO SAVEPOINT testdb_1",
+		"SAVEPOINT testdb_3",
+		"ROLLBACK",
+	}
+	if !reflect.DeepEqual(rec.statements, expected) {
+		t.Fatalf("ran %q", rec.statements)
+	}
+}
+
+func TestTx(t *testing.T) {
+	t.Setenv(EnvDSN, "")
+	Use(Config{})
+	skipped := false
+	t.Run("without a provisioner", func(t *testing.T) {
+		defer func() { skipped = t.Skipped() }()
+		Tx(t)
+	})
+	if !skipped {
+		t.Fatal("Tx does not skip without a provisioner")
+	}
+}
+`
+
+func TestRenderTx(t *testing.T) {
+	t.Parallel()
+	testdb := render(t, PostgresTestContainer, Data{})
+	for _, expected := range []string{
+		"func Tx(t testing.TB) Transaction {",
+		"func (tx Transaction) Savepoint(t testing.TB) Savepoint {",
+		"func (s Savepoint) Restore(t testing.TB) {",
+		"func (s Savepoint) Release(t testing.TB) {",
+	} {
+		if !strings.Contains(testdb, expected) {
+			t.Fatalf("%q is not rendered:\n%s", expected, testdb)
+		}
+	}
+	goRun(t, map[string]string{
+		"testdb/testdb.go":  testdb,
+		"testdb/tx_test.go": txTest,
+	}, "test", "-run", "TestSavepoint|TestTx")
+}
+
+// dbassertTest checks normalize and diffLines, compares rows read through a driver
+// serving fixed rows, and defines an -update flag of its own, which panics on a clash.
+const dbassertTest = `package dbassert
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var _ = flag.Bool("update", false, "an update flag of the tests")
+
+func TestFlag(t *testing.T) {
+	if flag.Lookup("dbassert.update") == nil {
+		t.Fatal("the -dbassert.update flag is not defined")
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	at := time.Date(2001, 2, 3, 4, 5, 6, 0, time.FixedZone("", 3600))
+	var null *int
+	tests := []struct {
+		databaseType string
+		value        any
+		expected     any
+	}{
+		{databaseType: "integer", value: "5", expected: int64(5)},
+		{databaseType: "bigint", value: uint8(5), expected: int64(5)},
+		{databaseType: "numeric", value: []byte("12.50"), expected: 12.5},
+		{databaseType: "double precision", value: 2, expected: 2.0},
+		{databaseType: "boolean", value: true, expected: true},
+		{databaseType: "integer[]", value: []byte("{1,2}"), expected: []int64{1, 2}},
+		{databaseType: "integer[]", value: []int{1, 2}, expected: []int64{1, 2}},
+		{databaseType: "text[]", value: "{a,\"b c\"}", expected: []string{"a", "b c"}},
+		{databaseType: "jsonb", value: []byte(` + "`" + `{"a": 1}` + "`" + `), expected: map[string]any{"a": 1.0}},
+		{databaseType: "json", value: map[string]int{"a": 1}, expected: map[string]any{"a": 1.0}},
+		{databaseType: "date", value: at, expected: "2001-02-03"},
+		{databaseType: "date", value: "2001-02-03T00:00:00Z", expected: "2001-02-03"},
+		{databaseType: "timestamp", value: at, expected: "2001-02-03T03:05:06Z"},
+		{databaseType: "timestamp", value: "2001-02-03T04:05:06+01:00", expected: "2001-02-03T03:05:06Z"},
+		{databaseType: "time", value: time.Date(0, 1, 1, 15, 4, 5, 0, time.UTC), expected: "15:04:05"},
+		{databaseType: "time", value: time.Date(0, 1, 1, 15, 4, 5, 5e8, time.UTC), expected: "15:04:05.5"},
+		{databaseType: "time", value: "15:04:05", expected: "15:04:05"},
+		{databaseType: "interval", value: []byte("1 day 02:00:00"), expected: "1 day 02:00:00"},
+		{databaseType: "interval", value: 26*time.Hour + 90*time.Second, expected: "26:01:30"},
+		{databaseType: "interval", value: -90 * time.Minute, expected: "-01:30:00"},
+		{databaseType: "text", value: []byte("go"), expected: "go"},
+		{databaseType: "integer", value: null, expected: nil},
+	}
+	for _, test := range tests {
+		actual, err := normalize(test.databaseType, test.value)
+		if err != nil || !reflect.DeepEqual(actual, test.expected) {
+			t.Fatalf("normalize(%q, %#v) = %#v, %v", test.databaseType, test.value, actual, err)
+		}
+	}
+	if _, err := normalize("boolean", "yes"); err == nil {
+		t.Fatal("a string is accepted as a boolean")
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	if diff := diffLines([]string{"a", "b"}, []string{"a", "b"}); diff != "" {
+		t.Fatalf("equal lines differ:\n%s", diff)
+	}
+	expected := "  a\n- b\n  c\n+ d\n"
+	if diff := diffLines([]string{"a", "b", "c"}, []string{"a", "c", "d"}); diff != expected {
+		t.Fatalf("diffLines = %q, expected %q", diff, expected)
+	}
+}
+
+// source serves the rows of tags to any query.
+type source struct{}
+
+func (source) Open(name string) (driver.Conn, error) { return source{}, nil }
+
+func (source) Prepare(query string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+
+func (source) Close() error { return nil }
+
+func (source) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+func (source) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return &rows{values: [][]driver.Value{{int64(1), []byte("go")}, {int64(2), []byte("sql")}}}, nil
+}
+
+type rows struct {
+	values [][]driver.Value
+}
+
+func (r *rows) Columns() []string { return []string{"id", "name"} }
+
+func (r *rows) Close() error { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func init() {
+	sql.Register("source", source{})
+}
+
+type reporter struct {
+	testing.TB
+	errors []string
+}
+
+func (r *reporter) Helper() {}
+
+func (r *reporter) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRows(t *testing.T) {
+	db, err := sql.Open("source", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	Rows(t, db, "tags", []Row{{"name": "sql", "id": 2}, {"name": "go", "id": 1}})
+	r := &reporter{TB: t}
+	Rows(r, db, "tags", []Row{{"name": "go"}, {"name": "rust"}})
+	expected := "dbassert: rows of tags differ (-expected +actual):\n  {\"name\": \"go\"}\n- {\"name\": \"rust\"}\n+ {\"name\": \"sql\"}\n"
+	if len(r.errors) != 1 || r.errors[0] != expected {
+		t.Fatalf("Rows reported %q", r.errors)
+	}
+}
+`
+
+func TestRenderDBAssert(t *testing.T) {
+	t.Parallel()
+	dbassert := render(t, PostgresDBAssert, Data{Tables: []Data{tagsData()}})
+	for _, expected := range []string{
+		`var update = flag.Bool("dbassert.update", false, "rewrite the golden files of dbassert")`,
+		"\"tags\": {\n\t\tpk: []string{\"id\"},\n\t\ttypes: map[string]string{\n\t\t\t\"id\": \"integer\",\n\t\t\t\"name\": \"text\",\n\t\t},",
+	} {
+		if !strings.Contains(dbassert, expected) {
+			t.Fatalf("%q is not rendered:\n%s", expected, dbassert)
+		}
+	}
+	goRun(t, map[string]string{
+		"dbassert/dbassert.go":      dbassert,
+		"dbassert/dbassert_test.go": dbassertTest,
+	}, "test", "-run", "TestFlag|TestNormalize|TestDiffLines|TestRows")
+}
+
+func TestRenderSqlxAll(t *testing.T) {
+	t.Parallel()
+	data := tagsData()
+	data.Columns = append(data.Columns, "deleted_at")
+	data.DataTypes["deleted_at"] = "*string"
+	data.DatabaseTypes["deleted_at"] = "timestamp"
+	data.SoftDelete = "deleted_at"
+	dao := render(t, PostgresSqlxDao, data)
+	expected := "// All returns the rows not soft deleted.\nfunc (d tagsDao) All(ctx context.Context, db DBTX) ([]tags, error) {\n\tvar resp []tags\n\tif err := sqlx.SelectContext(ctx, db, &resp, `SELECT id, name, deleted_at FROM tags WHERE deleted_at IS NULL`)"
+	if !strings.Contains(dao, expected) {
+		t.Fatalf("%q is not rendered:\n%s", expected, dao)
+	}
+}
+
+// schemaData describes users and the memos they write, with the Go types of the pgx mode
+// when pgx is set and of the other modes otherwise: nullable, array and defaulted columns,
+// foreign keys, a unique key, a version and a soft delete column.
+func schemaData(pgx bool) []Data {
+	nullable := func(dataType DataType, pgxType DataType) DataType {
+		if pgx {
+			return pgxType
+		}
+		return "*" + dataType
+	}
+	labels := DataType("pq.StringArray")
+	if pgx {
+		labels = "pgtype.FlatArray[string]"
+	}
+	users := Data{
+		TableName:     "users",
+		Pk:            []Column{"id"},
+		Columns:       []Column{"id", "name", "nickname"},
+		DataTypes:     DataTypeByColumn{"id": "int", "name": "string", "nickname": nullable("string", "pgtype.Text")},
+		DatabaseTypes: map[Column]string{"id": "integer", "name": "text", "nickname": "text"},
+		Reserved:      map[string]struct{}{},
+		Defaults:      map[Column]Value{"id": "nextval('users_id_seq'::regclass)", "name": "'anon'::text"},
+		Constraints:   map[string][]Column{"users_pkey": {"id"}},
+	}
+	memos := Data{
+		TableName: "memos",
+		Pk:        []Column{"id"},
+		Columns:   []Column{"active", "body", "deleted_at", "id", "labels", "parent_id", "score", "user_id", "version"},
+		DataTypes: DataTypeByColumn{
+			"active": "bool", "body": "string", "deleted_at": nullable("string", "pgtype.Text"), "id": "int", "labels": labels,
+			"parent_id": nullable("int", "pgtype.Int8"), "score": nullable("float64", "pgtype.Float8"), "user_id": "int", "version": "int",
+		},
+		DatabaseTypes: map[Column]string{
+			"active": "boolean", "body": "text", "deleted_at": "timestamp", "id": "integer", "labels": "text[]",
+			"parent_id": "integer", "score": "double precision", "user_id": "integer", "version": "integer",
+		},
+		Reserved: map[string]struct{}{},
+		Defaults: map[Column]Value{"id": "nextval('memos_id_seq'::regclass)", "active": "true", "version": "1"},
+		ForeignKeys: []ForeignKey{
+			{Column: "parent_id", Table: "memos", IsNull: true, References: "id"},
+			{Column: "user_id", Table: "users", References: "id"},
+		},
+		UniqueKeys: []UniqueKey{{Name: "memos_body_key", Columns: []Column{"body"}}},
+		Constraints: map[string][]Column{
+			"memos_pkey": {"id"}, "memos_body_key": {"body"}, "memos_parent_id_fkey": {"parent_id"}, "memos_user_id_fkey": {"user_id"},
+		},
+		Version:    "version",
+		SoftDelete: "deleted_at",
+	}
+	return []Data{users, memos}
+}
+
+// renderMode renders the shared files and the DAO of every table of schemaData for a mode.
+func renderMode(t *testing.T, dbtx DefaultTemplateType, errors DefaultTemplateType, dao DefaultTemplateType, pgx bool) map[string]string {
+	t.Helper()
+	tables := schemaData(pgx)
+	files := map[string]string{
+		"dao/dbtx.go":   render(t, dbtx, Data{Tables: tables}),
+		"dao/errors.go": render(t, errors, Data{Tables: tables}),
+	}
+	for _, table := range tables {
+		files["dao/"+table.TableName+".go"] = render(t, dao, table)
+	}
+	return files
+}
+
+func TestRenderPgxBuild(t *testing.T) {
+	t.Parallel()
+	files := renderMode(t, PostgresPgxDbtx, PostgresPgxErrors, PostgresPgxDao, true)
+	goModule(t, []string{"github.com/jackc/pgx/v5 v5.7.2"}, files, "vet")
+}
+
+func TestRenderSqlxBuild(t *testing.T) {
+	t.Parallel()
+	files := renderMode(t, PostgresSqlxDbtx, PostgresErrors, PostgresSqlxDao, false)
+	expected := "func (d memosDao) CopyFrom(ctx context.Context, tx *sqlx.Tx, targets []memos) (int64, error) {"
+	if !strings.Contains(files["dao/memos.go"], expected) {
+		t.Fatalf("%q is not rendered:\n%s", expected, files["dao/memos.go"])
+	}
+	goModule(t, []string{"github.com/jmoiron/sqlx v1.4.0", "github.com/lib/pq v1.10.9"}, files, "vet")
+}
+
+// gormTest parses the rendered models with gorm, which rejects tags it cannot apply.
+const gormTest = `package dao
+
+import (
+	"sync"
+	"testing"
+
+	"gorm.io/gorm/schema"
+)
+
+func TestSchema(t *testing.T) {
+	cache := &sync.Map{}
+	users, err := schema.Parse(&users{}, cache, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := users.LookUpField("name"); name == nil || name.DefaultValue != "anon" {
+		t.Fatalf("the default of users.name is not parsed: %+v", name)
+	}
+	if id := users.LookUpField("id"); id == nil || !id.PrimaryKey || !id.AutoIncrement {
+		t.Fatalf("users.id is not an auto incremented primary key: %+v", id)
+	}
+	memos, err := schema.Parse(&memos{}, cache, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"User", "Parent"} {
+		relation, ok := memos.Relationships.Relations[name]
+		if !ok || relation.Type != schema.BelongsTo {
+			t.Fatalf("memos.%s is not a belongs to relation: %+v", name, relation)
+		}
+	}
+	if active := memos.LookUpField("active"); active == nil || active.DefaultValue != "true" {
+		t.Fatalf("the default of memos.active is not parsed: %+v", active)
+	}
+}
+`
+
+func TestRenderGormBuild(t *testing.T) {
+	t.Parallel()
+	files := map[string]string{"dao/schema_test.go": gormTest}
+	for _, table := range schemaData(false) {
+		files["dao/"+table.TableName+".go"] = render(t, PostgresGormModel, table)
+	}
+	goModule(t, []string{"gorm.io/gorm v1.31.2", "github.com/lib/pq v1.10.9"}, files, "test")
+}